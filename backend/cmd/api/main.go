@@ -10,13 +10,26 @@ import (
 
 	"github.com/SHILOP0P/Yardly/backend/internal/app/httpserver"
 	"github.com/SHILOP0P/Yardly/backend/internal/auth"
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
 	"github.com/SHILOP0P/Yardly/backend/internal/booking/pgrepo"
+	"github.com/SHILOP0P/Yardly/backend/internal/calendar"
 	bookingpg "github.com/SHILOP0P/Yardly/backend/internal/booking/pgrepo"
 	"github.com/SHILOP0P/Yardly/backend/internal/db"
 	itempg "github.com/SHILOP0P/Yardly/backend/internal/item/pgrepo"
 	userpg "github.com/SHILOP0P/Yardly/backend/internal/user/pgrepo"
     favoritepg "github.com/SHILOP0P/Yardly/backend/internal/favorite/pgrepo"
     adminpg "github.com/SHILOP0P/Yardly/backend/internal/admin/pgrepo"
+    disputepg "github.com/SHILOP0P/Yardly/backend/internal/dispute/pgrepo"
+    calendarpg "github.com/SHILOP0P/Yardly/backend/internal/calendar/pgrepo"
+    messagepg "github.com/SHILOP0P/Yardly/backend/internal/message/pgrepo"
+    reviewpg "github.com/SHILOP0P/Yardly/backend/internal/review/pgrepo"
+    "github.com/SHILOP0P/Yardly/backend/internal/netguard"
+    "github.com/SHILOP0P/Yardly/backend/internal/stream"
+    streampg "github.com/SHILOP0P/Yardly/backend/internal/stream/pgrepo"
+    "github.com/SHILOP0P/Yardly/backend/internal/webhook"
+    webhookpg "github.com/SHILOP0P/Yardly/backend/internal/webhook/pgrepo"
+    "github.com/SHILOP0P/Yardly/backend/internal/outbox"
+    outboxpg "github.com/SHILOP0P/Yardly/backend/internal/outbox/pgrepo"
 )
 
 func main() {
@@ -40,6 +53,59 @@ func main() {
     }
 
 
+    ownerCancelCutoff := 48 * time.Hour
+    if v := os.Getenv("OWNER_CANCEL_CUTOFF"); v != "" {
+        ownerCancelCutoff, err = time.ParseDuration(v)
+        if err != nil {
+            log.Fatal(err)
+        }
+    }
+
+
+    waitlistWindow := 24 * time.Hour
+    if v := os.Getenv("WAITLIST_PRIORITY_WINDOW"); v != "" {
+        waitlistWindow, err = time.ParseDuration(v)
+        if err != nil {
+            log.Fatal(err)
+        }
+    }
+
+    // сколько дней после завершения брони стороны могут оставить отзывы
+    reviewWindow := 14 * 24 * time.Hour
+    if v := os.Getenv("REVIEW_WINDOW"); v != "" {
+        reviewWindow, err = time.ParseDuration(v)
+        if err != nil {
+            log.Fatal(err)
+        }
+    }
+
+    // сколько залог ещё удерживается после завершения аренды — окно для спора о повреждениях
+    depositDisputeWindow := 72 * time.Hour
+    if v := os.Getenv("DEPOSIT_DISPUTE_WINDOW"); v != "" {
+        depositDisputeWindow, err = time.ParseDuration(v)
+        if err != nil {
+            log.Fatal(err)
+        }
+    }
+
+    icsSyncInterval := time.Hour
+    if v := os.Getenv("ICS_SYNC_INTERVAL"); v != "" {
+        icsSyncInterval, err = time.ParseDuration(v)
+        if err != nil {
+            log.Fatal(err)
+        }
+    }
+
+
+    // исходящие запросы по пользовательским ссылкам (вебхуки, ICS-импорт) не ходят во внутреннюю сеть;
+    // OUTBOUND_ALLOW_CIDRS — исключения для локальной разработки, например "127.0.0.1/32"
+    outboundAllow, err := netguard.ParseAllowlist(os.Getenv("OUTBOUND_ALLOW_CIDRS"))
+    if err != nil {
+        log.Fatal(err)
+    }
+    outboundGuard := netguard.New(outboundAllow)
+
+
     jwtSecret := os.Getenv("JWT_SECRET")
 
     jwtSvc := auth.NewJWT(
@@ -62,39 +128,66 @@ func main() {
 
     eventRepo :=pgrepo.NewEventRepo()
     // 🔹 СОЗДАЁМ REPO ЗДЕСЬ (composition root)
-    bookingRepo := bookingpg.New(pool, eventRepo)
+    bookingRepo := bookingpg.New(pool, eventRepo, bookingpg.NewDepositRepo(depositDisputeWindow), bookingpg.NewWaitlistRepo(waitlistWindow))
     itemRepo:= itempg.New(pool)
     userRepo :=userpg.New(pool)
     refreshRepo := auth.NewRefreshRepo(pool, jwtSecret)
     favoriteRepo := favoritepg.New(pool)
     adminRepo := adminpg.New(pool)
+    disputeRepo := disputepg.New(pool, disputepg.NewEventRepo(), eventRepo)
+    calendarRepo := calendarpg.New(pool)
+    messageRepo := messagepg.New(pool)
+    reviewRepo := reviewpg.New(pool, reviewWindow)
+    streamHub := stream.NewHub()
+    webhookRepo := webhookpg.New(pool)
+    outboxRepo := outboxpg.New(pool)
 
     
 
 
-    srv := httpserver.New(port, pool, itemRepo, bookingRepo, userRepo, refreshRepo, favoriteRepo, adminRepo, jwtSvc, refreshTTL)
+    bookingCfg := booking.Config{
+        OwnerCancelCutoff: ownerCancelCutoff,
+    }
+
+    srv := httpserver.New(port, pool, itemRepo, bookingRepo, userRepo, refreshRepo, favoriteRepo, adminRepo, disputeRepo, calendarRepo, messageRepo, reviewRepo, streamHub, webhookRepo, outboundGuard, jwtSvc, refreshTTL, bookingCfg)
 
     jobCtx, jobCancel := context.WithCancel(context.Background())
 
     srv.RegisterOnShutdown(func(){
         jobCancel()
+        streamHub.Close()
     })
 
+    // LISTEN booking_events -> SSE подключения /api/my/stream
+    go streampg.NewListener(pool, streamHub).Run(jobCtx)
+
     go func() {
         ticker := time.NewTicker(1 * time.Minute)
         defer ticker.Stop()
 
-        runOnce:=func(){
-            ctx, cancel := context.WithTimeout(jobCtx, 5*time.Second)
-            defer cancel()
+        // задачи независимы: ошибка или зависание одной не мешает остальным, у каждой свой таймаут
+        jobs := []struct {
+            name string
+            run  func(ctx context.Context, now time.Time) (int64, error)
+        }{
+            {"expire overdue handovers", bookingRepo.ExpireOverdueHandovers},
+            {"mark overdue returns", bookingRepo.MarkOverdueReturns},
+            {"expire waitlist offers", bookingRepo.ExpireWaitlistOffers},
+            {"release deposits", bookingRepo.ReleaseDueDeposits},
+        }
 
-            n, err:= bookingRepo.ExpireOverdueHandovers(ctx, time.Now().UTC())
-            if err!= nil{
-                 log.Println("expire overdue handovers error:", err)
-                return
-            }
-            if n > 0{
-                log.Println("expired overdue handovers:", n)
+        runOnce := func() {
+            for _, job := range jobs {
+                ctx, cancel := context.WithTimeout(jobCtx, 5*time.Second)
+                n, err := job.run(ctx, time.Now().UTC())
+                cancel()
+                if err != nil {
+                    log.Printf("%s error: %v", job.name, err)
+                    continue
+                }
+                if n > 0 {
+                    log.Printf("%s: %d", job.name, n)
+                }
             }
         }
 
@@ -111,6 +204,82 @@ func main() {
         }
     }()
 
+    // пересинхронизация внешних календарей (ICS по ссылке)
+    go func() {
+        syncer := calendar.NewSyncer(calendarRepo, outboundGuard)
+        ticker := time.NewTicker(1 * time.Minute)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-jobCtx.Done():
+                log.Println("ics sync job stopped")
+                return
+            case <-ticker.C:
+                ctx, cancel := context.WithTimeout(jobCtx, 50*time.Second)
+                n, err := syncer.SyncDue(ctx, time.Now().UTC(), icsSyncInterval)
+                cancel()
+                if err != nil {
+                    log.Println("ics sync error:", err)
+                    continue
+                }
+                if n > 0 {
+                    log.Println("ics imports synced:", n)
+                }
+            }
+        }
+    }()
+
+    // раздача событий outbox потребителям (новый подписчик — ещё один Consumer здесь)
+    go func() {
+        dispatcher := outbox.NewDispatcher(outboxRepo,
+            webhook.NewOutboxConsumer(webhookRepo),
+        )
+        ticker := time.NewTicker(5 * time.Second)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-jobCtx.Done():
+                log.Println("outbox dispatcher stopped")
+                return
+            case <-ticker.C:
+                ctx, cancel := context.WithTimeout(jobCtx, 50*time.Second)
+                _, err := dispatcher.RunOnce(ctx, time.Now().UTC())
+                cancel()
+                if err != nil {
+                    log.Println("outbox dispatch error:", err)
+                }
+            }
+        }
+    }()
+
+    // доставка исходящих вебхуков с повторами
+    go func() {
+        dispatcher := webhook.NewDispatcher(webhookRepo, outboundGuard.Client(10*time.Second))
+        ticker := time.NewTicker(10 * time.Second)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-jobCtx.Done():
+                log.Println("webhook dispatcher stopped")
+                return
+            case <-ticker.C:
+                ctx, cancel := context.WithTimeout(jobCtx, 2*time.Minute)
+                n, err := dispatcher.RunOnce(ctx, time.Now().UTC())
+                cancel()
+                if err != nil {
+                    log.Println("webhook dispatch error:", err)
+                    continue
+                }
+                if n > 0 {
+                    log.Println("webhook deliveries sent:", n)
+                }
+            }
+        }
+    }()
+
     log.Printf("Starting HTTP server on :%s\n", port)
     if err := srv.ListenAndServe(); err != nil {
         log.Fatal(err)
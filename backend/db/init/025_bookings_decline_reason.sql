@@ -0,0 +1,2 @@
+ALTER TABLE bookings
+  ADD COLUMN IF NOT EXISTS decline_reason text NULL;
@@ -0,0 +1,14 @@
+ALTER TABLE bookings
+  ADD COLUMN IF NOT EXISTS cancelled_by text NULL,
+  ADD COLUMN IF NOT EXISTS cancel_reason text NULL;
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_cancelled_by_check;
+
+ALTER TABLE bookings
+ADD CONSTRAINT bookings_cancelled_by_check
+CHECK (cancelled_by IS NULL OR cancelled_by IN ('requester', 'owner'));
+
+-- счётчик отмен владельцем в публичном профиле
+CREATE INDEX IF NOT EXISTS bookings_owner_cancelled_idx
+  ON bookings (owner_id)
+  WHERE cancelled_by = 'owner';
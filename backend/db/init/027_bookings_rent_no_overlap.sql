@@ -0,0 +1,16 @@
+-- Пересечение занятых интервалов аренды запрещаем на уровне БД:
+-- SELECT-проверка в Create/ApproveRent не защищает от параллельных одобрений.
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_rent_no_overlap;
+
+ALTER TABLE bookings
+ADD CONSTRAINT bookings_rent_no_overlap
+EXCLUDE USING gist (
+  item_id WITH =,
+  tstzrange(start_at, end_at, '[)') WITH &&
+)
+WHERE (
+  type = 'rent'
+  AND status IN ('approved', 'handover_pending', 'in_use', 'return_pending')
+);
@@ -0,0 +1,6 @@
+-- Ожидающее предложение перенести даты аренды (одно на бронь)
+ALTER TABLE bookings
+  ADD COLUMN IF NOT EXISTS reschedule_start_at timestamptz NULL,
+  ADD COLUMN IF NOT EXISTS reschedule_end_at timestamptz NULL,
+  ADD COLUMN IF NOT EXISTS reschedule_proposed_by bigint NULL REFERENCES users(id),
+  ADD COLUMN IF NOT EXISTS reschedule_proposed_at timestamptz NULL;
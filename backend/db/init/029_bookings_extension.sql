@@ -0,0 +1,4 @@
+-- Запрос на продление аренды, пока вещь у арендатора (одна заявка на бронь)
+ALTER TABLE bookings
+  ADD COLUMN IF NOT EXISTS extension_end_at timestamptz NULL,
+  ADD COLUMN IF NOT EXISTS extension_requested_at timestamptz NULL;
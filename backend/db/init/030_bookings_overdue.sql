@@ -0,0 +1,8 @@
+-- Просроченный возврат: аренда in_use/return_pending после end_at
+ALTER TABLE bookings
+  ADD COLUMN IF NOT EXISTS overdue_since timestamptz NULL,
+  ADD COLUMN IF NOT EXISTS overdue_days int NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS bookings_overdue_idx
+  ON bookings (owner_id, overdue_since)
+  WHERE overdue_since IS NOT NULL;
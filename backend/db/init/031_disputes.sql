@@ -0,0 +1,49 @@
+-- Споры по аренде: повреждение / невозврат. Пока спор открыт, бронь заморожена.
+ALTER TABLE bookings
+  ADD COLUMN IF NOT EXISTS frozen_at timestamptz NULL;
+
+CREATE TABLE IF NOT EXISTS disputes (
+  id              bigserial PRIMARY KEY,
+  booking_id      bigint NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+  opened_by       bigint NOT NULL REFERENCES users(id),
+  reason          text   NOT NULL CHECK (reason IN ('damage','non_return','other')),
+  description     text   NOT NULL,
+  status          text   NOT NULL DEFAULT 'open' CHECK (status IN ('open','resolved')),
+  outcome         text   NULL CHECK (outcome IN ('owner_favored','requester_favored','split','dismissed')),
+  resolution_note text   NULL,
+  resolved_by     bigint NULL REFERENCES users(id),
+  resolved_at     timestamptz NULL,
+  created_at      timestamptz NOT NULL DEFAULT now()
+);
+
+-- не больше одного открытого спора на бронь
+CREATE UNIQUE INDEX IF NOT EXISTS disputes_one_open_per_booking
+  ON disputes (booking_id)
+  WHERE status = 'open';
+
+CREATE INDEX IF NOT EXISTS disputes_status_created_at_idx
+  ON disputes (status, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS dispute_attachments (
+  id          bigserial PRIMARY KEY,
+  dispute_id  bigint NOT NULL REFERENCES disputes(id) ON DELETE CASCADE,
+  uploaded_by bigint NOT NULL REFERENCES users(id),
+  url         text   NOT NULL,
+  created_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS dispute_attachments_dispute_id_idx
+  ON dispute_attachments (dispute_id);
+
+-- таймлайн спора, видят обе стороны и админы
+CREATE TABLE IF NOT EXISTS dispute_events (
+  id            bigserial PRIMARY KEY,
+  dispute_id    bigint NOT NULL REFERENCES disputes(id) ON DELETE CASCADE,
+  actor_user_id bigint NULL REFERENCES users(id),
+  action        text   NOT NULL,
+  meta          jsonb  NULL,
+  created_at    timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS dispute_events_dispute_id_created_at_idx
+  ON dispute_events (dispute_id, created_at, id);
@@ -0,0 +1,22 @@
+-- Залог: сумма фиксируется на брони при одобрении аренды, движения — в журнале deposit_entries.
+-- Суммы в минимальных единицах (как items.price / items.deposit).
+ALTER TABLE bookings
+  ADD COLUMN IF NOT EXISTS deposit_amount bigint NULL CHECK (deposit_amount >= 0);
+
+CREATE TABLE IF NOT EXISTS deposit_entries (
+  id            bigserial PRIMARY KEY,
+  booking_id    bigint NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+  kind          text   NOT NULL CHECK (kind IN ('hold','release','forfeit')),
+  amount        bigint NOT NULL CHECK (amount > 0),
+  actor_user_id bigint NULL REFERENCES users(id),
+  dispute_id    bigint NULL REFERENCES disputes(id),
+  created_at    timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS deposit_entries_booking_id_idx
+  ON deposit_entries (booking_id, created_at, id);
+
+-- залог удерживается один раз на бронь
+CREATE UNIQUE INDEX IF NOT EXISTS deposit_entries_one_hold_per_booking
+  ON deposit_entries (booking_id)
+  WHERE kind = 'hold';
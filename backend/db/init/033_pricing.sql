@@ -0,0 +1,10 @@
+-- Скидки на длинную аренду и валюта вещи
+ALTER TABLE items
+  ADD COLUMN IF NOT EXISTS weekly_discount_pct int NOT NULL DEFAULT 0 CHECK (weekly_discount_pct BETWEEN 0 AND 100),
+  ADD COLUMN IF NOT EXISTS monthly_discount_pct int NOT NULL DEFAULT 0 CHECK (monthly_discount_pct BETWEEN 0 AND 100),
+  ADD COLUMN IF NOT EXISTS currency text NOT NULL DEFAULT 'RUB';
+
+-- Снимок цены на брони при создании: последующие правки item не меняют договорённость
+ALTER TABLE bookings
+  ADD COLUMN IF NOT EXISTS price_total bigint NULL CHECK (price_total >= 0),
+  ADD COLUMN IF NOT EXISTS currency text NULL;
@@ -0,0 +1,4 @@
+-- Шаг аренды вещи: посуточно (YYYY-MM-DD) или почасово (RFC3339, целые часы)
+ALTER TABLE items
+  ADD COLUMN IF NOT EXISTS booking_granularity text NOT NULL DEFAULT 'day'
+    CHECK (booking_granularity IN ('day','hour'));
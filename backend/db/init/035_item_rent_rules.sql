@@ -0,0 +1,10 @@
+-- Правила аренды, которые задаёт владелец:
+-- буфер между арендами, минимальный/максимальный срок, минимальное уведомление и горизонт бронирования.
+-- 0 в max_* означает «без ограничения».
+ALTER TABLE items
+  ADD COLUMN IF NOT EXISTS buffer_before_days int NOT NULL DEFAULT 0 CHECK (buffer_before_days >= 0),
+  ADD COLUMN IF NOT EXISTS buffer_after_days  int NOT NULL DEFAULT 0 CHECK (buffer_after_days >= 0),
+  ADD COLUMN IF NOT EXISTS min_rental_days    int NOT NULL DEFAULT 0 CHECK (min_rental_days >= 0),
+  ADD COLUMN IF NOT EXISTS max_rental_days    int NOT NULL DEFAULT 0 CHECK (max_rental_days >= 0),
+  ADD COLUMN IF NOT EXISTS min_notice_hours   int NOT NULL DEFAULT 0 CHECK (min_notice_hours >= 0),
+  ADD COLUMN IF NOT EXISTS max_horizon_days   int NOT NULL DEFAULT 0 CHECK (max_horizon_days >= 0);
@@ -0,0 +1,14 @@
+-- Ручные блокировки календаря вещи владельцем («сам пользуюсь в эти даты»).
+-- Для арендаторов это просто занятые даты; note видит только владелец.
+CREATE TABLE IF NOT EXISTS item_blocks (
+  id          bigserial PRIMARY KEY,
+  item_id     bigint NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+  start_at    timestamptz NOT NULL,
+  end_at      timestamptz NOT NULL,
+  note        text,
+  created_by  bigint NOT NULL REFERENCES users(id),
+  created_at  timestamptz NOT NULL DEFAULT now(),
+  CHECK (end_at > start_at)
+);
+
+CREATE INDEX IF NOT EXISTS item_blocks_item_range_idx ON item_blocks (item_id, start_at, end_at);
@@ -0,0 +1,5 @@
+-- Instant book: заявки на такую вещь одобряются сразу при создании.
+-- instant_book_min_completed — сколько завершённых сделок нужно арендатору, чтобы бронировать мгновенно (0 — всем).
+ALTER TABLE items
+  ADD COLUMN IF NOT EXISTS instant_book boolean NOT NULL DEFAULT false,
+  ADD COLUMN IF NOT EXISTS instant_book_min_completed int NOT NULL DEFAULT 0 CHECK (instant_book_min_completed >= 0);
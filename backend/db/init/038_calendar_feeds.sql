@@ -0,0 +1,14 @@
+-- Секретные ICS-ленты владельца: по вещи (item_id) или по всем вещам (item_id IS NULL).
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+  id          bigserial PRIMARY KEY,
+  owner_id    bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  item_id     bigint NULL REFERENCES items(id) ON DELETE CASCADE,
+  token       text NOT NULL UNIQUE,
+  created_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS calendar_feeds_owner_item_uidx
+  ON calendar_feeds (owner_id, item_id) WHERE item_id IS NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS calendar_feeds_owner_all_uidx
+  ON calendar_feeds (owner_id) WHERE item_id IS NULL;
@@ -0,0 +1,23 @@
+-- Импорт внешних календарей (.ics файлом или ссылкой) в блокировки вещи.
+CREATE TABLE IF NOT EXISTS calendar_imports (
+  id              bigserial PRIMARY KEY,
+  item_id         bigint NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+  owner_id        bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  source          text NOT NULL CHECK (source IN ('file','url')),
+  url             text NULL,
+  name            text NULL,
+  last_synced_at  timestamptz NULL,
+  last_error      text NULL,
+  created_at      timestamptz NOT NULL DEFAULT now(),
+  CHECK ((source = 'url') = (url IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS calendar_imports_item_idx ON calendar_imports (item_id);
+
+-- импортированные блокировки привязаны к источнику; повторный импорт заменяет их по UID события
+ALTER TABLE item_blocks
+  ADD COLUMN IF NOT EXISTS import_id bigint NULL REFERENCES calendar_imports(id) ON DELETE CASCADE,
+  ADD COLUMN IF NOT EXISTS external_uid text NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS item_blocks_import_uid_uidx
+  ON item_blocks (import_id, external_uid) WHERE import_id IS NOT NULL;
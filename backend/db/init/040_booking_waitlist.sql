@@ -0,0 +1,26 @@
+-- Лист ожидания на занятые даты аренды.
+-- Когда мешавшая бронь отменена/отклонена/истекла, ожидающие по очереди получают
+-- приоритетное окно (priority_until), в которое даты придержаны только для них.
+CREATE TABLE IF NOT EXISTS booking_waitlist (
+  id              bigserial PRIMARY KEY,
+  item_id         bigint NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+  user_id         bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  start_at        timestamptz NOT NULL,
+  end_at          timestamptz NOT NULL,
+  status          text NOT NULL DEFAULT 'waiting'
+                  CHECK (status IN ('waiting','notified','booked','expired','cancelled')),
+  notified_at     timestamptz NULL,
+  priority_until  timestamptz NULL,
+  booking_id      bigint NULL REFERENCES bookings(id) ON DELETE SET NULL,
+  created_at      timestamptz NOT NULL DEFAULT now(),
+  CHECK (end_at > start_at)
+);
+
+CREATE INDEX IF NOT EXISTS booking_waitlist_item_status_idx ON booking_waitlist (item_id, status, id);
+CREATE INDEX IF NOT EXISTS booking_waitlist_user_idx ON booking_waitlist (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS booking_waitlist_offer_idx ON booking_waitlist (priority_until) WHERE status = 'notified';
+
+-- одна активная запись пользователя на одни и те же даты
+CREATE UNIQUE INDEX IF NOT EXISTS booking_waitlist_active_uidx
+  ON booking_waitlist (item_id, user_id, start_at, end_at)
+  WHERE status IN ('waiting','notified');
@@ -0,0 +1,16 @@
+-- Подтверждение передачи/возврата одноразовым кодом: одна сторона показывает код (или QR),
+-- другая вводит его. Храним только хэш; у кода есть срок жизни и лимит попыток.
+ALTER TABLE items
+  ADD COLUMN IF NOT EXISTS require_handover_code boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS booking_confirmation_codes (
+  booking_id   bigint NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+  purpose      text NOT NULL CHECK (purpose IN ('handover','return')),
+  code_hash    text NOT NULL,
+  issued_by    bigint NOT NULL REFERENCES users(id),
+  expires_at   timestamptz NOT NULL,
+  attempts     int NOT NULL DEFAULT 0,
+  consumed_at  timestamptz NULL,
+  created_at   timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (booking_id, purpose)
+);
@@ -0,0 +1,21 @@
+-- Переписка сторон внутри брони. Админ читает её при разборе спора.
+CREATE TABLE IF NOT EXISTS booking_messages (
+  id              bigserial PRIMARY KEY,
+  booking_id      bigint NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+  sender_id       bigint NOT NULL REFERENCES users(id),
+  body            text NOT NULL DEFAULT '',
+  attachment_url  text NULL,
+  created_at      timestamptz NOT NULL DEFAULT now(),
+  CHECK (body <> '' OR attachment_url IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS booking_messages_booking_idx ON booking_messages (booking_id, id);
+
+-- до какого сообщения пользователь дочитал переписку брони
+CREATE TABLE IF NOT EXISTS booking_message_reads (
+  booking_id            bigint NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+  user_id               bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  last_read_message_id  bigint NOT NULL DEFAULT 0,
+  updated_at            timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (booking_id, user_id)
+);
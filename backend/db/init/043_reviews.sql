@@ -0,0 +1,34 @@
+-- Взаимные отзывы после завершённой брони: арендатор оценивает вещь/владельца, владелец — арендатора.
+CREATE TABLE IF NOT EXISTS reviews (
+  id                bigserial PRIMARY KEY,
+  booking_id        bigint NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+  item_id           bigint NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+  author_id         bigint NOT NULL REFERENCES users(id),
+  target_user_id    bigint NOT NULL REFERENCES users(id),
+  author_role       text NOT NULL CHECK (author_role IN ('requester', 'owner')),
+  rating            smallint NOT NULL CHECK (rating BETWEEN 1 AND 5),
+  body              text NOT NULL DEFAULT '',
+  window_closes_at  timestamptz NOT NULL, -- до этого момента отзыв скрыт, пока не оставлен встречный
+  hidden_at         timestamptz NULL,
+  hidden_by         bigint NULL REFERENCES users(id),
+  hidden_reason     text NULL,
+  created_at        timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (booking_id, author_id)
+);
+
+CREATE INDEX IF NOT EXISTS reviews_item_idx ON reviews (item_id, id DESC) WHERE author_role = 'requester';
+CREATE INDEX IF NOT EXISTS reviews_target_idx ON reviews (target_user_id, id DESC);
+
+-- Публично видимые отзывы: не скрыты модерацией и раскрыты —
+-- либо обе стороны уже написали, либо окно закрылось.
+CREATE OR REPLACE VIEW visible_reviews AS
+SELECT r.*
+FROM reviews r
+WHERE r.hidden_at IS NULL
+  AND (
+    r.window_closes_at <= now()
+    OR EXISTS (
+      SELECT 1 FROM reviews o
+      WHERE o.booking_id = r.booking_id AND o.author_id <> r.author_id
+    )
+  );
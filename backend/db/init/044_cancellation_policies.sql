@@ -0,0 +1,16 @@
+-- Условия отмены аренды: выбираются владельцем для вещи и фиксируются в брони при создании.
+ALTER TABLE items
+  ADD COLUMN IF NOT EXISTS cancellation_policy text NOT NULL DEFAULT 'flexible'
+    CHECK (cancellation_policy IN ('flexible', 'moderate', 'strict'));
+
+ALTER TABLE bookings
+  ADD COLUMN IF NOT EXISTS cancellation_policy text NULL
+    CHECK (cancellation_policy IN ('flexible', 'moderate', 'strict'));
+
+-- у уже созданных аренд — текущие условия вещи
+UPDATE bookings b
+SET cancellation_policy = i.cancellation_policy
+FROM items i
+WHERE i.id = b.item_id
+  AND b.type = 'rent'
+  AND b.cancellation_policy IS NULL;
@@ -0,0 +1,30 @@
+-- Исходящие вебхуки пользователя: события его броней и вещей уходят POST'ом с HMAC-подписью.
+CREATE TABLE IF NOT EXISTS webhooks (
+  id          bigserial PRIMARY KEY,
+  user_id     bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  url         text NOT NULL,
+  secret      text NOT NULL,             -- ключ HMAC, показывается только при создании
+  events      text[] NOT NULL DEFAULT '{}', -- пусто = все события
+  active      boolean NOT NULL DEFAULT true,
+  created_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_user_idx ON webhooks (user_id) WHERE active;
+
+-- Журнал доставок: одна строка на событие × вебхук, попытки с экспоненциальной задержкой.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id                bigserial PRIMARY KEY,
+  webhook_id        bigint NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+  event_type        text NOT NULL,
+  payload           jsonb NOT NULL,
+  status            text NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+  attempts          int NOT NULL DEFAULT 0,
+  next_attempt_at   timestamptz NOT NULL DEFAULT now(),
+  last_status_code  int NULL,
+  last_error        text NULL,
+  delivered_at      timestamptz NULL,
+  created_at        timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, id DESC);
@@ -0,0 +1,58 @@
+-- Transactional outbox: доменные события пишутся в той же транзакции, что и изменение,
+-- и читаются потребителями (вебхуки, уведомления, индексация) со своими чекпоинтами.
+CREATE TABLE IF NOT EXISTS outbox_events (
+  id              bigserial PRIMARY KEY,
+  -- id транзакции-автора: читаем только события завершённых транзакций в порядке (txid, id),
+  -- иначе поздний commit с меньшим id проскочил бы мимо чекпоинта
+  txid            bigint NOT NULL DEFAULT pg_current_xact_id()::text::bigint,
+  aggregate_type  text NOT NULL,   -- booking | item | user | ...
+  aggregate_id    bigint NOT NULL,
+  event_type      text NOT NULL,
+  payload         jsonb NOT NULL,
+  created_at      timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS outbox_events_order_idx ON outbox_events (txid, id);
+CREATE INDEX IF NOT EXISTS outbox_events_aggregate_idx ON outbox_events (aggregate_type, aggregate_id, id);
+
+-- Чекпоинт потребителя: последнее обработанное (txid, id) + lease, чтобы один потребитель
+-- одновременно работал только в одном экземпляре приложения.
+CREATE TABLE IF NOT EXISTS outbox_consumers (
+  name          text PRIMARY KEY,
+  last_txid     bigint NOT NULL DEFAULT 0,
+  last_id       bigint NOT NULL DEFAULT 0,
+  locked_until  timestamptz NULL,
+  updated_at    timestamptz NOT NULL DEFAULT now()
+);
+
+-- Отложенные события потребителя: упавшие с ошибкой и стоящие за ними события того же агрегата
+-- (порядок внутри агрегата сохраняется, остальные агрегаты идут дальше).
+CREATE TABLE IF NOT EXISTS outbox_retries (
+  consumer         text NOT NULL REFERENCES outbox_consumers(name) ON DELETE CASCADE,
+  event_id         bigint NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+  aggregate_type   text NOT NULL,
+  aggregate_id     bigint NOT NULL,
+  attempts         int NOT NULL DEFAULT 0,
+  next_attempt_at  timestamptz NOT NULL DEFAULT now(),
+  last_error       text NULL,
+  created_at       timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (consumer, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS outbox_retries_aggregate_idx ON outbox_retries (consumer, aggregate_type, aggregate_id);
+
+-- Dead letters: события, которые потребитель так и не смог обработать; повтор — вручную через админку.
+CREATE TABLE IF NOT EXISTS outbox_dead_letters (
+  consumer    text NOT NULL REFERENCES outbox_consumers(name) ON DELETE CASCADE,
+  event_id    bigint NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+  attempts    int NOT NULL,
+  last_error  text NULL,
+  created_at  timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (consumer, event_id)
+);
+
+-- Вебхуки теперь наполняются из outbox: повторная доставка события потребителю не дублирует отправку.
+ALTER TABLE webhook_deliveries
+  ADD COLUMN IF NOT EXISTS outbox_event_id bigint NULL REFERENCES outbox_events(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_outbox_uq ON webhook_deliveries (webhook_id, outbox_event_id);
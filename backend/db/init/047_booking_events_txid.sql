@@ -0,0 +1,8 @@
+-- SSE дочитывает события броней по курсору (txid, id), а не по одному id:
+-- bigserial выдаётся при вставке, а видимость — при commit, поэтому событие с меньшим id
+-- может появиться позже большего и проскочить мимо Last-Event-ID. Как в outbox_events,
+-- читаются только события транзакций старше pg_snapshot_xmin — они уже не изменятся.
+ALTER TABLE booking_events
+  ADD COLUMN IF NOT EXISTS txid bigint NOT NULL DEFAULT pg_current_xact_id()::text::bigint;
+
+CREATE INDEX IF NOT EXISTS booking_events_txid_id_idx ON booking_events (txid, id);
@@ -0,0 +1,8 @@
+-- Залог возвращается не сразу при завершении аренды, а по окончании окна споров:
+-- повреждения обычно обнаруживаются уже после возврата, и спор должен успеть удержать залог.
+ALTER TABLE bookings
+  ADD COLUMN IF NOT EXISTS deposit_release_at timestamptz NULL;
+
+CREATE INDEX IF NOT EXISTS bookings_deposit_release_idx
+  ON bookings (deposit_release_at)
+  WHERE deposit_release_at IS NOT NULL;
@@ -0,0 +1,28 @@
+-- Предложение переноса относится к состоянию брони, в котором его сделали: при любой смене статуса
+-- (одобрение, отмена, передача, истечение, авто-отклонение...) оно сбрасывается. Статус меняют
+-- десятки запросов, поэтому сброс — в триггере, а не в каждом UPDATE.
+CREATE OR REPLACE FUNCTION bookings_clear_reschedule() RETURNS trigger AS $$
+BEGIN
+  NEW.reschedule_start_at := NULL;
+  NEW.reschedule_end_at := NULL;
+  NEW.reschedule_proposed_by := NULL;
+  NEW.reschedule_proposed_at := NULL;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS bookings_clear_reschedule_trg ON bookings;
+CREATE TRIGGER bookings_clear_reschedule_trg
+  BEFORE UPDATE OF status ON bookings
+  FOR EACH ROW
+  WHEN (OLD.status IS DISTINCT FROM NEW.status)
+  EXECUTE FUNCTION bookings_clear_reschedule();
+
+-- предложения, оставшиеся у уже завершённых/отменённых броней
+UPDATE bookings
+SET reschedule_start_at = NULL,
+    reschedule_end_at = NULL,
+    reschedule_proposed_by = NULL,
+    reschedule_proposed_at = NULL
+WHERE reschedule_proposed_by IS NOT NULL
+  AND status NOT IN ('requested', 'approved');
@@ -0,0 +1,19 @@
+-- Снимок ценовых правил на брони аренды: по ним price_total пересчитывается при переносе и продлении,
+-- а правки цены вещи после создания брони на договорённость не влияют.
+ALTER TABLE bookings
+  ADD COLUMN IF NOT EXISTS price_unit bigint NULL CHECK (price_unit >= 0),
+  ADD COLUMN IF NOT EXISTS price_hourly boolean NULL,
+  ADD COLUMN IF NOT EXISTS price_weekly_discount_pct int NULL CHECK (price_weekly_discount_pct BETWEEN 0 AND 100),
+  ADD COLUMN IF NOT EXISTS price_monthly_discount_pct int NULL CHECK (price_monthly_discount_pct BETWEEN 0 AND 100);
+
+-- у старых броней снимка нет — берём текущие правила вещи (как deposit_amount при одобрении)
+UPDATE bookings b
+SET price_unit = i.price,
+    price_hourly = (i.booking_granularity = 'hour'),
+    price_weekly_discount_pct = i.weekly_discount_pct,
+    price_monthly_discount_pct = i.monthly_discount_pct
+FROM items i
+WHERE i.id = b.item_id
+  AND b.type = 'rent'
+  AND b.price_total IS NOT NULL
+  AND b.price_unit IS NULL;
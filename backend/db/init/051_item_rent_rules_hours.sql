@@ -0,0 +1,21 @@
+-- Правила срока и буферов в единицах шага вещи: у почасовых — отдельные *_hours,
+-- чтобы min_rental_days не читался как часы, а буфер в днях не съедал сутки после почасовой аренды.
+ALTER TABLE items
+  ADD COLUMN IF NOT EXISTS buffer_before_hours int NOT NULL DEFAULT 0 CHECK (buffer_before_hours >= 0),
+  ADD COLUMN IF NOT EXISTS buffer_after_hours  int NOT NULL DEFAULT 0 CHECK (buffer_after_hours >= 0),
+  ADD COLUMN IF NOT EXISTS min_rental_hours    int NOT NULL DEFAULT 0 CHECK (min_rental_hours >= 0),
+  ADD COLUMN IF NOT EXISTS max_rental_hours    int NOT NULL DEFAULT 0 CHECK (max_rental_hours >= 0);
+
+-- почасовые вещи: min/max_rental_days уже применялись как часы — переносим как есть;
+-- буферы были в сутках — переводим в часы без изменения смысла
+UPDATE items
+SET min_rental_hours = min_rental_days,
+    max_rental_hours = max_rental_days,
+    buffer_before_hours = LEAST(buffer_before_days * 24, 3650),
+    buffer_after_hours  = LEAST(buffer_after_days * 24, 3650),
+    min_rental_days = 0,
+    max_rental_days = 0,
+    buffer_before_days = 0,
+    buffer_after_days = 0
+WHERE booking_granularity = 'hour'
+  AND (min_rental_days <> 0 OR max_rental_days <> 0 OR buffer_before_days <> 0 OR buffer_after_days <> 0);
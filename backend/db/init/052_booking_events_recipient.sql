@@ -0,0 +1,14 @@
+-- События для одного пользователя без брони (предложение из листа ожидания): booking_id пуст,
+-- адресат — recipient_user_id. SSE отдаёт их только адресату, в ленту событий брони они не попадают.
+ALTER TABLE booking_events
+  ALTER COLUMN booking_id DROP NOT NULL,
+  ADD COLUMN IF NOT EXISTS recipient_user_id bigint NULL REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE booking_events
+  DROP CONSTRAINT IF EXISTS booking_events_target_chk;
+ALTER TABLE booking_events
+  ADD CONSTRAINT booking_events_target_chk CHECK (booking_id IS NOT NULL OR recipient_user_id IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS booking_events_recipient_idx
+  ON booking_events (recipient_user_id, txid, id)
+  WHERE recipient_user_id IS NOT NULL;
@@ -0,0 +1,8 @@
+-- Вложения переписки больше не раздаются публично по /uploads: файлы лежат в
+-- PRIVATE_UPLOADS_DIR/messages/<booking_id>/ и отдаются только участникам брони.
+-- Старые ссылки переводим на защищённый маршрут; сами файлы из UPLOADS_DIR/messages/
+-- нужно перенести в PRIVATE_UPLOADS_DIR/messages/<booking_id>/ (см. README).
+UPDATE booking_messages
+SET attachment_url = '/api/bookings/' || booking_id || '/messages/attachments/'
+                     || substring(attachment_url FROM '^/uploads/messages/([^/]+)$')
+WHERE attachment_url ~ '^/uploads/messages/[^/]+$';
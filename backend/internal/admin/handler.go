@@ -2,11 +2,16 @@ package admin
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/SHILOP0P/Yardly/backend/internal/auth"
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/dispute"
 	"github.com/SHILOP0P/Yardly/backend/internal/httpx"
+	"github.com/SHILOP0P/Yardly/backend/internal/outbox"
+	"github.com/SHILOP0P/Yardly/backend/internal/review"
 )
 
 type Handler struct {
@@ -195,6 +200,14 @@ func (h *Handler) ListBookings(w http.ResponseWriter, r *http.Request){
 		}
 		f.UserID = &v
 	}
+	if s := q.Get("overdue"); s != "" {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, "invalid overdue")
+			return
+		}
+		f.Overdue = v
+	}
 
 	list, err:= h.repo.ListBookings(r.Context(), f)
 	if err!=nil{
@@ -253,6 +266,39 @@ func (h *Handler) ListBookingEvents(w http.ResponseWriter, r *http.Request){
 	})
 }
 
+// GET /api/admin/bookings/{id}/messages — переписка сторон для разбора спора
+func (h *Handler) ListBookingMessages(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+
+	limit := httpx.QueryInt(r, "limit", 50, 1, 200)
+	offset := httpx.QueryInt(r, "offset", 0, 0, 1_000_000)
+
+	msgs, err := h.repo.ListBookingMessages(r.Context(), actorID, bookingID, limit, offset)
+	if err != nil {
+		if errors.Is(err, booking.ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "booking not found")
+			return
+		}
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, map[string]any{
+		"messages": msgs,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
 func (h *Handler) ListItems(w http.ResponseWriter, r *http.Request){
 	qp := r.URL.Query()
 
@@ -399,9 +445,185 @@ func (h *Handler) DeleteItem(w http.ResponseWriter, r *http.Request) {
 }
 
 
+func (h *Handler) ListDisputes(w http.ResponseWriter, r *http.Request) {
+	f := dispute.ListFilter{
+		Limit:  httpx.QueryInt(r, "limit", 20, 1, 100),
+		Offset: httpx.QueryInt(r, "offset", 0, 0, 1_000_000),
+	}
+	if s := r.URL.Query().Get("status"); s != "" {
+		st := dispute.Status(s)
+		if st != dispute.StatusOpen && st != dispute.StatusResolved {
+			httpx.WriteError(w, http.StatusBadRequest, "invalid status")
+			return
+		}
+		f.Status = &st
+	}
+
+	list, err := h.repo.ListDisputes(r.Context(), f)
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, map[string]any{
+		"disputes": list,
+		"limit":    f.Limit,
+		"offset":   f.Offset,
+	})
+}
+
+func (h *Handler) GetDispute(w http.ResponseWriter, r *http.Request) {
+	disputeID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || disputeID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid dispute id")
+		return
+	}
+
+	d, err := h.repo.GetDispute(r.Context(), disputeID)
+	if err != nil {
+		if errors.Is(err, dispute.ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "dispute not found")
+			return
+		}
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, d)
+}
+
+func (h *Handler) ResolveDispute(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	disputeID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || disputeID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid dispute id")
+		return
+	}
+
+	var req ResolveDisputeRequest
+	if err := httpx.ReadJSON(r, &req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if !req.Outcome.Valid() {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid outcome")
+		return
+	}
+	if req.ForfeitAmount != nil && *req.ForfeitAmount < 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid forfeit_amount")
+		return
+	}
+
+	d, err := h.repo.ResolveDispute(r.Context(), actorID, disputeID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, dispute.ErrNotFound):
+			httpx.WriteError(w, http.StatusNotFound, "dispute not found")
+		case errors.Is(err, dispute.ErrInvalidState):
+			httpx.WriteError(w, http.StatusConflict, err.Error())
+		default:
+			httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, d)
+}
+
 
 
 //	HELPERS
+// POST /api/admin/reviews/{id}/hide — {"reason"} опционально
+func (h *Handler) HideReview(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	reviewID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || reviewID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid review id")
+		return
+	}
+
+	var body ModerationRequest
+	_ = httpx.ReadJSON(r, &body)
+
+	rv, err := h.repo.HideReview(r.Context(), actorID, reviewID, body.Reason)
+	if err != nil {
+		if errors.Is(err, review.ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "review not found")
+			return
+		}
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, rv)
+}
+
+// GET /api/admin/outbox/consumers — чекпоинты, отставание, отложенные и dead letters по потребителям
+func (h *Handler) ListOutboxConsumers(w http.ResponseWriter, r *http.Request) {
+	list, err := h.repo.ListOutboxConsumers(r.Context())
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, map[string]any{"consumers": list})
+}
+
+// GET /api/admin/outbox/dead-letters?consumer=&limit=&offset=
+func (h *Handler) ListOutboxDeadLetters(w http.ResponseWriter, r *http.Request) {
+	limit := httpx.QueryInt(r, "limit", 20, 1, 100)
+	offset := httpx.QueryInt(r, "offset", 0, 0, 1_000_000)
+
+	var consumer *string
+	if s := r.URL.Query().Get("consumer"); s != "" {
+		consumer = &s
+	}
+
+	list, err := h.repo.ListOutboxDeadLetters(r.Context(), consumer, limit, offset)
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, map[string]any{
+		"dead_letters": list,
+		"limit":        limit,
+		"offset":       offset,
+	})
+}
+
+// POST /api/admin/outbox/dead-letters/{consumer}/{eventId}/retry
+func (h *Handler) RetryOutboxDeadLetter(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	consumer := r.PathValue("consumer")
+	eventID, err := strconv.ParseInt(r.PathValue("eventId"), 10, 64)
+	if err != nil || eventID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid event id")
+		return
+	}
+
+	if err := h.repo.RetryOutboxDeadLetter(r.Context(), actorID, consumer, eventID); err != nil {
+		if errors.Is(err, outbox.ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "dead letter not found")
+			return
+		}
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func parseBool(s string) bool {
 	switch s {
 	case "1", "true", "True", "TRUE", "yes", "on":
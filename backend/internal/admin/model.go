@@ -1,6 +1,10 @@
 package admin
 
-import "time"
+import (
+	"time"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/dispute"
+)
 
 type UserListItem struct {
 	ID           int64      `json:"id"`
@@ -61,6 +65,9 @@ type AdminBooking struct {
 	ReturnConfirmedByOwnerAt       *time.Time `json:"return_confirmed_by_owner_at,omitempty"`
 	ReturnConfirmedByRequesterAt   *time.Time `json:"return_confirmed_by_requester_at,omitempty"`
 
+	OverdueSince *time.Time `json:"overdue_since,omitempty"`
+	OverdueDays  int        `json:"overdue_days,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -69,6 +76,7 @@ type AdminBookingsFilter struct {
 	Type   *string
 	ItemID *int64
 	UserID *int64 // requester OR owner
+	Overdue bool  // только не возвращённые вовремя
 
 	Limit  int
 	Offset int
@@ -118,3 +126,10 @@ type PatchItemRequest struct {
 type ModerationRequest struct {
 	Reason *string `json:"reason,omitempty"`
 }
+
+type ResolveDisputeRequest struct {
+	Outcome dispute.Outcome `json:"outcome"` // owner_favored | requester_favored | split | dismissed
+	Note    *string         `json:"note,omitempty"`
+	// ForfeitAmount — сколько залога отдать владельцу (минимальные единицы); не больше удержанного остатка.
+	ForfeitAmount *int64 `json:"forfeit_amount,omitempty"`
+}
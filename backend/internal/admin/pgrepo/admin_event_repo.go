@@ -9,6 +9,8 @@ import (
 	bookingpg "github.com/SHILOP0P/Yardly/backend/internal/booking/pgrepo"
 	"github.com/SHILOP0P/Yardly/backend/internal/admin"
 	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/outbox"
+	outboxpg "github.com/SHILOP0P/Yardly/backend/internal/outbox/pgrepo"
 )
 
 type execer interface {
@@ -41,6 +43,20 @@ VALUES ($1, $2, $3, $4, $5, $6)
 		return fmt.Errorf("admin event insert: %w", err)
 	}
 
+	// то же действие — в outbox, по сущности, над которой оно выполнено
+	payload, err := json.Marshal(outbox.AdminPayload{
+		ActorUserID: ev.ActorID,
+		Action:      ev.Action,
+		Reason:      ev.Reason,
+		Meta:        metaBytes,
+	})
+	if err != nil {
+		return fmt.Errorf("admin event marshal outbox payload: %w", err)
+	}
+	if err := outboxpg.AppendTx(ctx, q, ev.EntityType, ev.EntityID, outbox.AdminEventPrefix+ev.Action, payload); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -0,0 +1,157 @@
+package pgrepo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/admin"
+	bookingpg "github.com/SHILOP0P/Yardly/backend/internal/booking/pgrepo"
+	"github.com/SHILOP0P/Yardly/backend/internal/dispute"
+	disputepg "github.com/SHILOP0P/Yardly/backend/internal/dispute/pgrepo"
+)
+
+func (r *Repo) ListDisputes(ctx context.Context, f dispute.ListFilter) ([]dispute.Dispute, error) {
+	const q = `
+	SELECT ` + disputepg.SelectDisputeCols + `
+	FROM disputes
+	WHERE ($1::text IS NULL OR status = $1)
+	ORDER BY created_at DESC, id DESC
+	LIMIT $2 OFFSET $3
+	`
+	rows, err := r.pool.Query(ctx, q, f.Status, f.Limit, f.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("admin list disputes: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]dispute.Dispute, 0, f.Limit)
+	for rows.Next() {
+		var d dispute.Dispute
+		if err := disputepg.ScanDispute(rows, &d); err != nil {
+			return nil, fmt.Errorf("admin list disputes scan: %w", err)
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("admin list disputes rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *Repo) GetDispute(ctx context.Context, id int64) (dispute.Details, error) {
+	const q = `SELECT ` + disputepg.SelectDisputeCols + ` FROM disputes WHERE id = $1`
+	var d dispute.Dispute
+	if err := disputepg.ScanDispute(r.pool.QueryRow(ctx, q, id), &d); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return dispute.Details{}, dispute.ErrNotFound
+		}
+		return dispute.Details{}, fmt.Errorf("admin get dispute: %w", err)
+	}
+	atts, err := disputepg.ListAttachments(ctx, r.pool, id)
+	if err != nil {
+		return dispute.Details{}, err
+	}
+	evs, err := disputepg.NewEventRepo().ListDisputeEvents(ctx, r.pool, id)
+	if err != nil {
+		return dispute.Details{}, err
+	}
+	return dispute.Details{Dispute: d, Attachments: atts, Events: evs}, nil
+}
+
+// ResolveDispute — закрывает спор с решением и размораживает бронь (если других открытых споров нет).
+func (r *Repo) ResolveDispute(ctx context.Context, actorAdminID, disputeID int64, req admin.ResolveDisputeRequest) (dispute.Dispute, error) {
+	now := time.Now().UTC()
+
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return dispute.Dispute{}, fmt.Errorf("admin resolve dispute begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const sel = `SELECT ` + disputepg.SelectDisputeCols + ` FROM disputes WHERE id = $1 FOR UPDATE`
+	var old dispute.Dispute
+	if err := disputepg.ScanDispute(tx.QueryRow(ctx, sel, disputeID), &old); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return dispute.Dispute{}, dispute.ErrNotFound
+		}
+		return dispute.Dispute{}, fmt.Errorf("admin resolve dispute select: %w", err)
+	}
+	if old.Status != dispute.StatusOpen {
+		return dispute.Dispute{}, dispute.ErrInvalidState
+	}
+
+	const upd = `
+	UPDATE disputes
+	SET status = 'resolved',
+	    outcome = $2,
+	    resolution_note = $3,
+	    resolved_by = $4,
+	    resolved_at = $5
+	WHERE id = $1
+	RETURNING ` + disputepg.SelectDisputeCols + `
+	`
+	var cur dispute.Dispute
+	if err := disputepg.ScanDispute(tx.QueryRow(ctx, upd, disputeID, req.Outcome, req.Note, actorAdminID, now), &cur); err != nil {
+		return dispute.Dispute{}, fmt.Errorf("admin resolve dispute update: %w", err)
+	}
+
+	const unfreeze = `
+	UPDATE bookings
+	SET frozen_at = NULL
+	WHERE id = $1
+	  AND NOT EXISTS (SELECT 1 FROM disputes WHERE booking_id = $1 AND status = 'open')
+	`
+	if _, err := tx.Exec(ctx, unfreeze, cur.BookingID); err != nil {
+		return dispute.Dispute{}, fmt.Errorf("admin resolve dispute unfreeze: %w", err)
+	}
+
+	actor := actorAdminID
+
+	var forfeited int64
+	if req.ForfeitAmount != nil {
+		did := disputeID
+		// окно споров здесь не используется — только списание из удерживаемого остатка
+		forfeited, err = bookingpg.NewDepositRepo(0).ForfeitTx(ctx, tx, cur.BookingID, *req.ForfeitAmount, &actor, &did)
+		if err != nil {
+			return dispute.Dispute{}, fmt.Errorf("admin resolve dispute forfeit: %w", err)
+		}
+	}
+
+	meta, _ := json.Marshal(map[string]any{"outcome": req.Outcome, "note": req.Note, "deposit_forfeited": forfeited})
+	if err := disputepg.NewEventRepo().InsertDisputeEvent(ctx, tx, disputeID, &actor, "resolve", meta); err != nil {
+		return dispute.Dispute{}, err
+	}
+	bMeta, _ := json.Marshal(map[string]any{"dispute_id": disputeID, "outcome": req.Outcome, "deposit_forfeited": forfeited})
+	if err := bookingpg.NewEventRepo().InsertBookingEvent(ctx, tx, cur.BookingID, &actor, "dispute_resolve", nil, nil, bMeta); err != nil {
+		return dispute.Dispute{}, err
+	}
+
+	ev := admin.AdminEvent{
+		ActorID:    actorAdminID,
+		EntityType: "dispute",
+		EntityID:   disputeID,
+		Action:     "dispute.resolve",
+		Reason:     req.Note,
+		Meta: map[string]any{
+			"booking_id":                cur.BookingID,
+			"outcome":                   req.Outcome,
+			"deposit_forfeit_requested": req.ForfeitAmount,
+			"deposit_forfeited":         forfeited,
+			"old":                       old,
+			"new":                       cur,
+		},
+	}
+	if err := r.CreateAdminEventTx(ctx, tx, ev); err != nil {
+		return dispute.Dispute{}, fmt.Errorf("admin resolve dispute audit: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return dispute.Dispute{}, fmt.Errorf("admin resolve dispute commit: %w", err)
+	}
+	return cur, nil
+}
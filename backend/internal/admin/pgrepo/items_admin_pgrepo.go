@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/SHILOP0P/Yardly/backend/internal/admin"
+	"github.com/SHILOP0P/Yardly/backend/internal/outbox"
+	outboxpg "github.com/SHILOP0P/Yardly/backend/internal/outbox/pgrepo"
 	"github.com/jackc/pgx/v5"
 )
 
@@ -183,6 +185,9 @@ func (r *Repo) PatchItem(ctx context.Context, actorAdminID, itemID int64, req ad
 		if err := r.CreateAdminEventTx(ctx, tx, ev); err != nil {
 			return admin.AdminItem{}, fmt.Errorf("admin patch item audit: %w", err)
 		}
+		if err := outboxpg.AppendItemEventTx(ctx, tx, itemID, outbox.EventItemUpdated); err != nil {
+			return admin.AdminItem{}, err
+		}
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -231,6 +236,9 @@ func (r *Repo) BlockItem(ctx context.Context, actorAdminID, itemID int64, reason
 	if err := r.CreateAdminEventTx(ctx, tx, ev); err != nil {
 		return admin.AdminItem{}, fmt.Errorf("admin block item audit: %w", err)
 	}
+	if err := outboxpg.AppendItemEventTx(ctx, tx, itemID, outbox.EventItemBlocked); err != nil {
+		return admin.AdminItem{}, err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return admin.AdminItem{}, fmt.Errorf("admin block item commit: %w", err)
@@ -276,6 +284,9 @@ func (r *Repo) UnblockItem(ctx context.Context, actorAdminID, itemID int64, reas
 	if err := r.CreateAdminEventTx(ctx, tx, ev); err != nil {
 		return admin.AdminItem{}, fmt.Errorf("admin unblock item audit: %w", err)
 	}
+	if err := outboxpg.AppendItemEventTx(ctx, tx, itemID, outbox.EventItemUnblocked); err != nil {
+		return admin.AdminItem{}, err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return admin.AdminItem{}, fmt.Errorf("admin unblock item commit: %w", err)
@@ -322,6 +333,9 @@ func (r *Repo) DeleteItem(ctx context.Context, actorAdminID, itemID int64, reaso
 	if err := r.CreateAdminEventTx(ctx, tx, ev); err != nil {
 		return admin.AdminItem{}, fmt.Errorf("admin delete item audit: %w", err)
 	}
+	if err := outboxpg.AppendItemEventTx(ctx, tx, itemID, outbox.EventItemDeleted); err != nil {
+		return admin.AdminItem{}, err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return admin.AdminItem{}, fmt.Errorf("admin delete item commit: %w", err)
@@ -0,0 +1,55 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/admin"
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/message"
+	messagepg "github.com/SHILOP0P/Yardly/backend/internal/message/pgrepo"
+)
+
+// ListBookingMessages — админ читает переписку по брони; сам факт чтения пишется в admin_events.
+func (r *Repo) ListBookingMessages(ctx context.Context, actorAdminID, bookingID int64, limit, offset int) ([]message.Message, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("admin list booking messages begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var exists int
+	if err := tx.QueryRow(ctx, `SELECT 1 FROM bookings WHERE id = $1`, bookingID).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, booking.ErrNotFound
+		}
+		return nil, fmt.Errorf("admin list booking messages booking: %w", err)
+	}
+
+	msgs, err := messagepg.ListByBooking(ctx, tx, bookingID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	ev := admin.AdminEvent{
+		ActorID:    actorAdminID,
+		EntityType: "booking",
+		EntityID:   bookingID,
+		Action:     "booking.messages_view",
+		Meta: map[string]any{
+			"limit":  limit,
+			"offset": offset,
+		},
+	}
+	if err := r.CreateAdminEventTx(ctx, tx, ev); err != nil {
+		return nil, fmt.Errorf("admin list booking messages audit: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("admin list booking messages commit: %w", err)
+	}
+	return msgs, nil
+}
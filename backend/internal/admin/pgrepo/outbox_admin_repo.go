@@ -0,0 +1,126 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/admin"
+	"github.com/SHILOP0P/Yardly/backend/internal/outbox"
+	outboxpg "github.com/SHILOP0P/Yardly/backend/internal/outbox/pgrepo"
+)
+
+func (r *Repo) ListOutboxConsumers(ctx context.Context) ([]outbox.ConsumerState, error) {
+	const q = `
+	SELECT c.name, c.last_txid, c.last_id, c.locked_until, c.updated_at,
+	       (SELECT count(*) FROM outbox_events e WHERE (e.txid, e.id) > (c.last_txid, c.last_id)),
+	       (SELECT count(*) FROM outbox_retries rt WHERE rt.consumer = c.name),
+	       (SELECT count(*) FROM outbox_dead_letters dl WHERE dl.consumer = c.name)
+	FROM outbox_consumers c
+	ORDER BY c.name
+	`
+	rows, err := r.pool.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("admin list outbox consumers: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]outbox.ConsumerState, 0)
+	for rows.Next() {
+		var c outbox.ConsumerState
+		if err := rows.Scan(&c.Name, &c.TxID, &c.ID, &c.LockedUntil, &c.UpdatedAt,
+			&c.Lag, &c.Retries, &c.DeadLetters); err != nil {
+			return nil, fmt.Errorf("admin list outbox consumers scan: %w", err)
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("admin list outbox consumers rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *Repo) ListOutboxDeadLetters(ctx context.Context, consumer *string, limit, offset int) ([]outbox.DeadLetter, error) {
+	const q = `
+	SELECT ` + outboxpg.SelectEventCols + `, dl.consumer, dl.attempts, dl.last_error, dl.created_at
+	FROM outbox_dead_letters dl
+	JOIN outbox_events e ON e.id = dl.event_id
+	WHERE ($1::text IS NULL OR dl.consumer = $1)
+	ORDER BY dl.created_at DESC, dl.event_id DESC
+	LIMIT $2 OFFSET $3
+	`
+	rows, err := r.pool.Query(ctx, q, consumer, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("admin list outbox dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]outbox.DeadLetter, 0)
+	for rows.Next() {
+		var dl outbox.DeadLetter
+		if err := outboxpg.ScanEvent(rows, &dl.Event, &dl.Consumer, &dl.Attempts, &dl.LastError, &dl.CreatedAt); err != nil {
+			return nil, fmt.Errorf("admin list outbox dead letters scan: %w", err)
+		}
+		out = append(out, dl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("admin list outbox dead letters rows: %w", err)
+	}
+	return out, nil
+}
+
+// RetryOutboxDeadLetter — событие возвращается в отложенные потребителя и уйдёт следующим проходом
+// (вне исходного порядка агрегата: более поздние события к этому моменту уже обработаны).
+func (r *Repo) RetryOutboxDeadLetter(ctx context.Context, actorAdminID int64, consumer string, eventID int64) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("admin retry dead letter begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const del = `
+	DELETE FROM outbox_dead_letters
+	WHERE consumer = $1 AND event_id = $2
+	RETURNING attempts, last_error
+	`
+	var attempts int
+	var lastErr *string
+	if err := tx.QueryRow(ctx, del, consumer, eventID).Scan(&attempts, &lastErr); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return outbox.ErrNotFound
+		}
+		return fmt.Errorf("admin retry dead letter delete: %w", err)
+	}
+
+	const ins = `
+	INSERT INTO outbox_retries (consumer, event_id, aggregate_type, aggregate_id, attempts, next_attempt_at, last_error)
+	SELECT $1, e.id, e.aggregate_type, e.aggregate_id, 0, now(), $3
+	FROM outbox_events e
+	WHERE e.id = $2
+	ON CONFLICT (consumer, event_id) DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, ins, consumer, eventID, lastErr); err != nil {
+		return fmt.Errorf("admin retry dead letter requeue: %w", err)
+	}
+
+	ev := admin.AdminEvent{
+		ActorID:    actorAdminID,
+		EntityType: "outbox_event",
+		EntityID:   eventID,
+		Action:     "outbox.dead_letter.retry",
+		Meta: map[string]any{
+			"consumer": consumer,
+			"attempts": attempts,
+		},
+	}
+	if err := r.CreateAdminEventTx(ctx, tx, ev); err != nil {
+		return fmt.Errorf("admin retry dead letter audit: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("admin retry dead letter commit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/admin"
+	"github.com/SHILOP0P/Yardly/backend/internal/review"
+	reviewpg "github.com/SHILOP0P/Yardly/backend/internal/review/pgrepo"
+)
+
+// HideReview — отзыв пропадает из публичных списков и рейтингов; автор по-прежнему видит его с hidden_at.
+func (r *Repo) HideReview(ctx context.Context, actorAdminID, reviewID int64, reason *string) (review.Review, error) {
+	now := time.Now().UTC()
+
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return review.Review{}, fmt.Errorf("admin hide review begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const sel = `SELECT ` + reviewpg.SelectReviewCols + ` FROM reviews WHERE id = $1 FOR UPDATE`
+	var old review.Review
+	if err := reviewpg.ScanReview(tx.QueryRow(ctx, sel, reviewID), &old); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return review.Review{}, review.ErrNotFound
+		}
+		return review.Review{}, fmt.Errorf("admin hide review select: %w", err)
+	}
+
+	const upd = `
+	UPDATE reviews
+	SET hidden_at = COALESCE(hidden_at, $2),
+		hidden_by = COALESCE(hidden_by, $3),
+		hidden_reason = COALESCE($4, hidden_reason)
+	WHERE id = $1
+	RETURNING ` + reviewpg.SelectReviewCols
+	var cur review.Review
+	if err := reviewpg.ScanReview(tx.QueryRow(ctx, upd, reviewID, now, actorAdminID, reason), &cur); err != nil {
+		return review.Review{}, fmt.Errorf("admin hide review update: %w", err)
+	}
+
+	ev := admin.AdminEvent{
+		ActorID:    actorAdminID,
+		EntityType: "review",
+		EntityID:   reviewID,
+		Action:     "review.hide",
+		Reason:     reason,
+		Meta: map[string]any{
+			"booking_id": cur.BookingID,
+			"author_id":  cur.AuthorID,
+			"old":        old,
+			"new":        cur,
+		},
+	}
+	if err := r.CreateAdminEventTx(ctx, tx, ev); err != nil {
+		return review.Review{}, fmt.Errorf("admin hide review audit: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return review.Review{}, fmt.Errorf("admin hide review commit: %w", err)
+	}
+	return cur, nil
+}
@@ -24,6 +24,8 @@ const selectAdminBookingCols = `
 	handover_confirmed_by_requester_at,
 	return_confirmed_by_owner_at,
 	return_confirmed_by_requester_at,
+	overdue_since,
+	overdue_days,
 	created_at
 `
 
@@ -42,6 +44,8 @@ func scanAdminBooking(rs interface{ Scan(...any) error }, b *admin.AdminBooking)
 		&b.HandoverConfirmedByRequesterAt,
 		&b.ReturnConfirmedByOwnerAt,
 		&b.ReturnConfirmedByRequesterAt,
+		&b.OverdueSince,
+		&b.OverdueDays,
 		&b.CreatedAt,
 	)
 }
@@ -255,10 +259,11 @@ func(r *Repo) ListBookings(ctx context.Context, f admin.AdminBookingsFilter) ([]
 	AND ($2::text IS NULL OR type = $2)
 	AND ($3::bigint IS NULL OR item_id = $3)
 	AND ($4::bigint IS NULL OR requester_id = $4 OR owner_id = $4)
+	AND ($7::bool = false OR (overdue_since IS NOT NULL AND status IN ('in_use','return_pending')))
 	ORDER BY created_at DESC, id DESC
 	LIMIT $5 OFFSET $6
 	`
-	rows, err := r.pool.Query(ctx, q, f.Status, f.Type, f.ItemID, f.UserID, f.Limit, f.Offset)
+	rows, err := r.pool.Query(ctx, q, f.Status, f.Type, f.ItemID, f.UserID, f.Limit, f.Offset, f.Overdue)
 	if err != nil {
 		return nil, fmt.Errorf("admin list bookings: %w", err)
 	}
@@ -3,6 +3,10 @@ package admin
 import (
 	"context"
 	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/dispute"
+	"github.com/SHILOP0P/Yardly/backend/internal/message"
+	"github.com/SHILOP0P/Yardly/backend/internal/outbox"
+	"github.com/SHILOP0P/Yardly/backend/internal/review"
 )
 
 type Repo interface {
@@ -15,6 +19,8 @@ type Repo interface {
 	ListBookings(ctx context.Context, f AdminBookingsFilter) ([]AdminBooking, error)
 	GetBooking(ctx context.Context, id int64) (AdminBooking, error)
 	ListBookingEvents(ctx context.Context, bookingID int64, limit, offset int) ([]booking.Event, error)
+	// ListBookingMessages — чтение переписки сторон фиксируется в admin_events.
+	ListBookingMessages(ctx context.Context, actorAdminID, bookingID int64, limit, offset int) ([]message.Message, error)
 
 	//Items
 	ListItems(ctx context.Context, f AdminItemsFilter) ([]AdminItem, error)
@@ -27,7 +33,19 @@ type Repo interface {
 
 	DeleteItem(ctx context.Context, actorAdminID, itemID int64, reason *string) (AdminItem, error)
 
-	
+	//Disputes
+	ListDisputes(ctx context.Context, f dispute.ListFilter) ([]dispute.Dispute, error)
+	GetDispute(ctx context.Context, id int64) (dispute.Details, error)
+	ResolveDispute(ctx context.Context, actorAdminID, disputeID int64, req ResolveDisputeRequest) (dispute.Dispute, error)
+
+	//Reviews
+	// HideReview — повторное скрытие не меняет hidden_at, но тоже пишется в admin_events.
+	HideReview(ctx context.Context, actorAdminID, reviewID int64, reason *string) (review.Review, error)
+
+	//Outbox
+	ListOutboxConsumers(ctx context.Context) ([]outbox.ConsumerState, error)
+	ListOutboxDeadLetters(ctx context.Context, consumer *string, limit, offset int) ([]outbox.DeadLetter, error)
+	RetryOutboxDeadLetter(ctx context.Context, actorAdminID int64, consumer string, eventID int64) error
 
 	//events
 	ListAdminEvents(ctx context.Context, f AdminEventsFilter) ([]AdminEvent, error)
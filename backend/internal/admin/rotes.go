@@ -18,6 +18,7 @@ func RegisterRoutes(mux *http.ServeMux, adminRepo Repo, adminChain adminHandler)
 	mux.Handle("GET /api/admin/bookings", adminChain(http.HandlerFunc(h.ListBookings)))
 	mux.Handle("GET /api/admin/bookings/{id}", adminChain(http.HandlerFunc(h.GetBooking)))
 	mux.Handle("GET /api/admin/bookings/{id}/events", adminChain(http.HandlerFunc(h.ListBookingEvents)))
+	mux.Handle("GET /api/admin/bookings/{id}/messages", adminChain(http.HandlerFunc(h.ListBookingMessages)))
 
 	//Items
 	mux.Handle("GET /api/admin/items", adminChain(http.HandlerFunc(h.ListItems)))
@@ -28,6 +29,19 @@ func RegisterRoutes(mux *http.ServeMux, adminRepo Repo, adminChain adminHandler)
 	mux.Handle("POST /api/admin/items/{id}/delete", adminChain(http.HandlerFunc(h.DeleteItem)))
 
 
+	//Disputes
+	mux.Handle("GET /api/admin/disputes", adminChain(http.HandlerFunc(h.ListDisputes)))
+	mux.Handle("GET /api/admin/disputes/{id}", adminChain(http.HandlerFunc(h.GetDispute)))
+	mux.Handle("POST /api/admin/disputes/{id}/resolve", adminChain(http.HandlerFunc(h.ResolveDispute)))
+
+	//Reviews
+	mux.Handle("POST /api/admin/reviews/{id}/hide", adminChain(http.HandlerFunc(h.HideReview)))
+
+	//Outbox
+	mux.Handle("GET /api/admin/outbox/consumers", adminChain(http.HandlerFunc(h.ListOutboxConsumers)))
+	mux.Handle("GET /api/admin/outbox/dead-letters", adminChain(http.HandlerFunc(h.ListOutboxDeadLetters)))
+	mux.Handle("POST /api/admin/outbox/dead-letters/{consumer}/{eventId}/retry", adminChain(http.HandlerFunc(h.RetryOutboxDeadLetter)))
+
 	//events
 	mux.Handle("GET /api/admin/events", adminChain(http.HandlerFunc(h.ListAdminEvents)))
 }
\ No newline at end of file
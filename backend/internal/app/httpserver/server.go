@@ -17,12 +17,19 @@ import (
 
 	"github.com/SHILOP0P/Yardly/backend/internal/auth"
 	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/calendar"
+	"github.com/SHILOP0P/Yardly/backend/internal/dispute"
 	"github.com/SHILOP0P/Yardly/backend/internal/favorite"
 	"github.com/SHILOP0P/Yardly/backend/internal/httpx"
 	"github.com/SHILOP0P/Yardly/backend/internal/item"
+	"github.com/SHILOP0P/Yardly/backend/internal/message"
+	"github.com/SHILOP0P/Yardly/backend/internal/netguard"
+	"github.com/SHILOP0P/Yardly/backend/internal/review"
+	"github.com/SHILOP0P/Yardly/backend/internal/stream"
+	"github.com/SHILOP0P/Yardly/backend/internal/webhook"
 )
 
-func New(port string, pool *pgxpool.Pool, itemsRepo *itempg.Repo, bookingRepo *bookingpg.Repo, userRepo *userpg.Repo, refreshesRepo *auth.RefreshRepo, favoriteRepo favorite.Repo, adminRepo admin.Repo, jwtSvc *auth.JWT, refreshTTL time.Duration) *http.Server {
+func New(port string, pool *pgxpool.Pool, itemsRepo *itempg.Repo, bookingRepo *bookingpg.Repo, userRepo *userpg.Repo, refreshesRepo *auth.RefreshRepo, favoriteRepo favorite.Repo, adminRepo admin.Repo, disputeRepo dispute.Repo, calendarRepo calendar.Repo, messageRepo message.Repo, reviewRepo review.Repo, streamHub *stream.Hub, webhookRepo webhook.Repo, outboundGuard *netguard.Guard, jwtSvc *auth.JWT, refreshTTL time.Duration, bookingCfg booking.Config) *http.Server {
 	mux := http.NewServeMux()
 
 	RegisterBaseRotes(mux)
@@ -42,11 +49,17 @@ func New(port string, pool *pgxpool.Pool, itemsRepo *itempg.Repo, bookingRepo *b
 	// }
 
 	item.RegisterRoutes(mux, itemsRepo, protectedChain)
-	booking.RegisterRoutes(mux, bookingRepo, itemsRepo, protectedChain)
+	booking.RegisterRoutes(mux, bookingRepo, itemsRepo, bookingCfg, protectedChain)
 	user.RegisterRoutes(mux, authMw, userRepo, jwtSvc)
 	auth.RegisterRoutes(mux, jwtSvc, refreshesRepo, refreshTTL, userRepo, authMw)
 	favorite.RegisterRoutes(mux, favoriteRepo, authMw)
 	admin.RegisterRoutes(mux, adminRepo, adminChain)
+	dispute.RegisterRoutes(mux, disputeRepo, bookingRepo, protectedChain)
+	calendar.RegisterRoutes(mux, calendarRepo, itemsRepo, outboundGuard, protectedChain)
+	message.RegisterRoutes(mux, messageRepo, bookingRepo, protectedChain)
+	review.RegisterRoutes(mux, reviewRepo, bookingRepo, protectedChain)
+	stream.RegisterRoutes(mux, streamHub, bookingRepo, protectedChain)
+	webhook.RegisterRoutes(mux, webhookRepo, outboundGuard, protectedChain)
 
 	return &http.Server{
 		Addr:    ":" + port,
@@ -0,0 +1,54 @@
+package booking
+
+import (
+	"time"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/item"
+)
+
+// CancelOutcome — сколько вернётся арендатору при отмене в момент At.
+// Залог до передачи вещи не удерживается, поэтому возвращается целиком.
+type CancelOutcome struct {
+	Policy          *item.CancellationPolicy `json:"policy,omitempty"`
+	CancelledBy     string                   `json:"cancelled_by"` // "requester" | "owner"
+	At              time.Time                `json:"at"`
+	HoursUntilStart *int                     `json:"hours_until_start,omitempty"` // отрицательное — аренда уже началась
+	RefundPct       int                      `json:"refund_pct"`
+	PriceTotal      int64                    `json:"price_total"`
+	PriceRefund     int64                    `json:"price_refund"`
+	DepositAmount   int64                    `json:"deposit_amount"`
+	DepositRefund   int64                    `json:"deposit_refund"`
+	Currency        *string                  `json:"currency,omitempty"`
+}
+
+// ComputeCancelOutcome — отмена владельцем и отзыв ещё не одобренной заявки возвращают всё;
+// иначе доля цены по политике, зафиксированной в брони. Возврат округляется вниз.
+func ComputeCancelOutcome(b Booking, by string, now time.Time) CancelOutcome {
+	out := CancelOutcome{
+		Policy:      b.CancellationPolicy,
+		CancelledBy: by,
+		At:          now,
+		RefundPct:   100,
+		Currency:    b.Currency,
+	}
+	if b.PriceTotal != nil {
+		out.PriceTotal = *b.PriceTotal
+	}
+	if b.DepositAmount != nil {
+		out.DepositAmount = *b.DepositAmount
+	}
+
+	if b.Start != nil {
+		until := b.Start.Sub(now)
+		hours := int(until / time.Hour)
+		out.HoursUntilStart = &hours
+
+		if by == "requester" && b.Status != StatusRequested && b.CancellationPolicy != nil {
+			out.RefundPct = b.CancellationPolicy.RefundPct(until)
+		}
+	}
+
+	out.PriceRefund = out.PriceTotal * int64(out.RefundPct) / 100
+	out.DepositRefund = out.DepositAmount
+	return out
+}
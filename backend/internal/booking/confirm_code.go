@@ -0,0 +1,79 @@
+package booking
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/booking/fsm"
+)
+
+// CodePurpose — что подтверждает одноразовый код.
+type CodePurpose string
+
+const (
+	CodeHandover CodePurpose = "handover" // код показывает владелец, вводит арендатор
+	CodeReturn   CodePurpose = "return"   // код показывает арендатор, вводит владелец
+)
+
+const (
+	ConfirmationCodeTTL          = 10 * time.Minute
+	ConfirmationCodeMaxAttempts  = 5
+	ConfirmationCodeReissueAfter = 30 * time.Second // не чаще одного нового кода за это время
+	confirmationCodeDigits       = 6
+)
+
+// ConfirmationCode — выданный код; открытый текст есть только в ответе на выдачу.
+type ConfirmationCode struct {
+	BookingID int64       `json:"booking_id"`
+	Purpose   CodePurpose `json:"purpose"`
+	Code      string      `json:"code"`
+	QRPayload string      `json:"qr_payload"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+func (p CodePurpose) Valid() bool {
+	return p == CodeHandover || p == CodeReturn
+}
+
+func (p CodePurpose) Action() fsm.Action {
+	if p == CodeReturn {
+		return fsm.ActionReturnCode
+	}
+	return fsm.ActionHandoverCode
+}
+
+// Issuer — чья сторона показывает код; вводит его другая.
+func (p CodePurpose) Issuer() fsm.Role {
+	if p == CodeReturn {
+		return fsm.RoleRequester
+	}
+	return fsm.RoleOwner
+}
+
+// NewConfirmationCode — случайный 6-значный код.
+func NewConfirmationCode() (string, error) {
+	lim := big.NewInt(1)
+	for i := 0; i < confirmationCodeDigits; i++ {
+		lim.Mul(lim, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, lim)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", confirmationCodeDigits, n.Int64()), nil
+}
+
+// HashConfirmationCode — код привязан к брони и назначению, чужой хэш не подойдёт.
+func HashConfirmationCode(bookingID int64, p CodePurpose, code string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s:%s", bookingID, p, code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// CodeQRPayload — содержимое QR, которое приложение второй стороны сканирует вместо ввода.
+func CodeQRPayload(bookingID int64, p CodePurpose, code string) string {
+	return fmt.Sprintf("yardly://bookings/%d/%s?code=%s", bookingID, p, code)
+}
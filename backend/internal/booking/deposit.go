@@ -0,0 +1,33 @@
+package booking
+
+import "time"
+
+type DepositEntryKind string
+
+const (
+	DepositHold    DepositEntryKind = "hold"    // вещь передана — залог удержан
+	DepositRelease DepositEntryKind = "release" // остаток вернули арендатору
+	DepositForfeit DepositEntryKind = "forfeit" // часть/весь залог ушла владельцу по спору
+)
+
+type DepositEntry struct {
+	ID          int64            `json:"id"`
+	BookingID   int64            `json:"booking_id"`
+	Kind        DepositEntryKind `json:"kind"`
+	Amount      int64            `json:"amount"`
+	ActorUserID *int64           `json:"actor_user_id,omitempty"`
+	DisputeID   *int64           `json:"dispute_id,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// Deposit — состояние залога по брони; Balance = Held - Released - Forfeited.
+type Deposit struct {
+	BookingID int64          `json:"booking_id"`
+	Amount    *int64         `json:"amount,omitempty"` // снимок items.deposit на момент одобрения
+	Held      int64          `json:"held"`
+	Released  int64          `json:"released"`
+	Forfeited int64          `json:"forfeited"`
+	Balance   int64          `json:"balance"`
+	ReleaseAt *time.Time     `json:"release_at,omitempty"` // когда вернётся остаток (идёт окно споров)
+	Entries   []DepositEntry `json:"entries"`
+}
@@ -9,4 +9,16 @@ var (
     ErrInvalidState = errors.New("invalid booking status")
 	ErrDuplicateActiveRequest = errors.New("active request already exists")
     ErrConflict = errors.New("busy date")
+    ErrCancelCutoff = errors.New("cancellation cutoff passed")
+    ErrFrozen = errors.New("booking is frozen by an open dispute")
+
+    ErrWaitlistHold   = errors.New("dates are held for a waitlisted user")
+    ErrNotBusy        = errors.New("dates are free, book them directly")
+    ErrAlreadyWaiting = errors.New("already on the waitlist for these dates")
+
+    ErrCodeRequired = errors.New("confirmation code required")
+    ErrInvalidCode  = errors.New("invalid confirmation code")
+    ErrCodeExpired  = errors.New("confirmation code expired")
+    ErrCodeAttempts = errors.New("too many attempts, request a new code")
+    ErrCodeTooSoon  = errors.New("code was issued recently, try again later")
 )
@@ -2,13 +2,30 @@ package booking
 
 import "time"
 
+// EventsChannel — канал pg_notify, в который пишется каждое новое событие брони
+// (payload — JSON с id события, брони и её участников).
+const EventsChannel = "booking_events"
+
+// EventCursor — позиция в ленте событий в порядке commit: (txid транзакции-автора, id события).
+type EventCursor struct {
+	TxID int64
+	ID   int64
+}
+
 type Event struct {
 	ID         int64      `json:"id"`
-	BookingID  int64      `json:"booking_id"`
+	BookingID  int64      `json:"booking_id,omitempty"` // 0 — событие для одного пользователя без брони
 	ActorUserID *int64    `json:"actor_user_id,omitempty"`
 	Action     string     `json:"action"`
 	FromStatus *Status    `json:"from_status,omitempty"`
 	ToStatus   *Status    `json:"to_status,omitempty"`
 	Meta       any        `json:"meta,omitempty"`
 	CreatedAt  time.Time  `json:"created_at"`
+
+	TxID int64 `json:"-"` // заполняется только в ленте для SSE (ListUserEventsSince)
+}
+
+// Cursor — позиция события в ленте (для Last-Event-ID).
+func (e Event) Cursor() EventCursor {
+	return EventCursor{TxID: e.TxID, ID: e.ID}
 }
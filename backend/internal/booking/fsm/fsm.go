@@ -0,0 +1,314 @@
+// Package fsm — таблица допустимых переходов брони: по типу сделки, текущему статусу,
+// действию и роли участника. Пакет ничего не знает о БД и о пакете booking,
+// поэтому статусы и типы здесь — просто строки.
+package fsm
+
+import (
+	"errors"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindRent Kind = "rent"
+	KindBuy  Kind = "buy"
+	KindGive Kind = "give"
+)
+
+type State string
+
+const (
+	StateRequested       State = "requested"
+	StateApproved        State = "approved"
+	StateHandoverPending State = "handover_pending"
+	StateInUse           State = "in_use"
+	StateReturnPending   State = "return_pending"
+	StateCompleted       State = "completed"
+	StateDeclined        State = "declined"
+	StateCancelled       State = "cancelled"
+	StateExpired         State = "expired"
+)
+
+type Role string
+
+const (
+	RoleOwner     Role = "owner"
+	RoleRequester Role = "requester"
+	RoleSystem    Role = "system" // фоновые задачи и автоматические действия
+	RoleAdmin     Role = "admin"
+)
+
+type Action string
+
+const (
+	ActionApprove      Action = "approve"
+	ActionAutoApprove  Action = "auto_approve"
+	ActionDecline      Action = "decline"
+	ActionCancel       Action = "cancel"        // requester отзывает заявку или отменяет аренду до передачи
+	ActionOwnerCancel  Action = "owner_cancel"  // owner отменяет одобренную бронь
+	ActionHandover     Action = "handover"      // подтверждение передачи одной из сторон
+	ActionHandoverCode Action = "handover_code" // передача по одноразовому коду — сразу за обе стороны
+	ActionReturn       Action = "return"        // подтверждение возврата одной из сторон
+	ActionReturnCode   Action = "return_code"   // возврат по одноразовому коду
+	ActionExpire       Action = "expire"        // передача не подтверждена до handover_deadline
+
+	// действия без смены статуса
+	ActionReschedule        Action = "reschedule"         // предложить перенос дат (или заменить своё предложение)
+	ActionRescheduleRespond Action = "reschedule_respond" // принять или отклонить перенос второй стороны
+	ActionExtend            Action = "extend"             // арендатор просит продлить
+	ActionExtendRespond     Action = "extend_respond"     // владелец одобряет или отклоняет продление
+	ActionOpenDispute       Action = "open_dispute"
+)
+
+var (
+	ErrNotAllowed         = errors.New("action is not allowed in current status")
+	ErrRole               = errors.New("action is not allowed for this role")
+	ErrFrozen             = errors.New("booking is frozen")
+	ErrDeadlinePassed     = errors.New("handover deadline passed")
+	ErrDeadlineNotReached = errors.New("handover deadline not reached")
+	ErrCancelCutoff       = errors.New("cancellation cutoff passed")
+	ErrCodeRequired       = errors.New("confirmation code required")
+	ErrPending            = errors.New("another request is pending")
+	ErrNothingPending     = errors.New("no pending request")
+)
+
+// Facts — то, что нужно guard'ам помимо статуса.
+type Facts struct {
+	Now               time.Time
+	Start             *time.Time
+	HandoverDeadline  *time.Time
+	Frozen            bool
+	OwnerCancelCutoff time.Duration
+
+	CodeRequired     bool // вещь принимает передачу и возврат только по коду
+	ExtensionPending bool // арендатор ждёт ответа на продление
+	RescheduleBy     Role // кто предложил ожидающий ответа перенос; "" — предложения нет
+}
+
+// Guard — дополнительное условие перехода для роли role; nil = без условий.
+type Guard func(f Facts, role Role) error
+
+// Transition — одна строка таблицы. To — возможные статусы после действия
+// (подтверждение передачи/возврата одной стороной может оставить статус «ожидания»).
+type Transition struct {
+	Kind   Kind
+	From   State
+	Action Action
+	Roles  []Role
+	To     []State
+	Guard  Guard
+}
+
+func (t Transition) allows(role Role) bool {
+	for _, r := range t.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Leads — допустим ли итоговый статус после этого перехода.
+func (t Transition) Leads(to State) bool {
+	for _, s := range t.To {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+func handoverInTime(f Facts, _ Role) error {
+	if f.HandoverDeadline == nil || f.Now.After(*f.HandoverDeadline) {
+		return ErrDeadlinePassed
+	}
+	return nil
+}
+
+func handoverExpired(f Facts, _ Role) error {
+	if f.HandoverDeadline == nil || !f.HandoverDeadline.Before(f.Now) {
+		return ErrDeadlineNotReached
+	}
+	return nil
+}
+
+func beforeCancelCutoff(f Facts, _ Role) error {
+	if f.Start != nil && f.Now.Add(f.OwnerCancelCutoff).After(*f.Start) {
+		return ErrCancelCutoff
+	}
+	return nil
+}
+
+// withoutCode — подтверждение одной стороной недоступно, если вещь требует код.
+func withoutCode(f Facts, _ Role) error {
+	if f.CodeRequired {
+		return ErrCodeRequired
+	}
+	return nil
+}
+
+func noExtensionPending(f Facts, _ Role) error {
+	if f.ExtensionPending {
+		return ErrPending
+	}
+	return nil
+}
+
+func extensionPending(f Facts, _ Role) error {
+	if !f.ExtensionPending {
+		return ErrNothingPending
+	}
+	return nil
+}
+
+// canPropose — своё предложение можно заменить, чужое сначала принимают или отклоняют.
+func canPropose(f Facts, role Role) error {
+	if f.RescheduleBy != "" && f.RescheduleBy != role {
+		return ErrPending
+	}
+	return nil
+}
+
+// canRespond — ответить можно только на предложение второй стороны.
+func canRespond(f Facts, role Role) error {
+	switch f.RescheduleBy {
+	case "":
+		return ErrNothingPending
+	case role:
+		return ErrRole
+	}
+	return nil
+}
+
+// all — guard проходит, если проходят все перечисленные.
+func all(gs ...Guard) Guard {
+	return func(f Facts, role Role) error {
+		for _, g := range gs {
+			if err := g(f, role); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+var (
+	participants  = []Role{RoleOwner, RoleRequester}
+	ownerOnly     = []Role{RoleOwner}
+	requesterOnly = []Role{RoleRequester}
+	systemOnly    = []Role{RoleSystem}
+)
+
+func rentTable() []Transition {
+	return []Transition{
+		{KindRent, StateRequested, ActionApprove, ownerOnly, []State{StateApproved}, nil},
+		{KindRent, StateRequested, ActionAutoApprove, systemOnly, []State{StateApproved}, nil},
+		{KindRent, StateRequested, ActionDecline, ownerOnly, []State{StateDeclined}, nil},
+		{KindRent, StateRequested, ActionCancel, requesterOnly, []State{StateCancelled}, nil},
+		{KindRent, StateRequested, ActionReschedule, participants, []State{StateRequested}, canPropose},
+		{KindRent, StateRequested, ActionRescheduleRespond, participants, []State{StateRequested}, canRespond},
+
+		{KindRent, StateApproved, ActionHandover, participants, []State{StateHandoverPending, StateInUse}, all(handoverInTime, withoutCode)},
+		{KindRent, StateApproved, ActionHandoverCode, participants, []State{StateInUse}, handoverInTime},
+		{KindRent, StateApproved, ActionOwnerCancel, ownerOnly, []State{StateCancelled}, beforeCancelCutoff},
+		{KindRent, StateApproved, ActionCancel, requesterOnly, []State{StateCancelled}, nil},
+		{KindRent, StateApproved, ActionExpire, systemOnly, []State{StateExpired}, handoverExpired},
+		{KindRent, StateApproved, ActionReschedule, participants, []State{StateApproved}, canPropose},
+		{KindRent, StateApproved, ActionRescheduleRespond, participants, []State{StateApproved}, canRespond},
+
+		{KindRent, StateHandoverPending, ActionHandover, participants, []State{StateHandoverPending, StateInUse}, all(handoverInTime, withoutCode)},
+		{KindRent, StateHandoverPending, ActionHandoverCode, participants, []State{StateInUse}, handoverInTime},
+		{KindRent, StateHandoverPending, ActionOwnerCancel, ownerOnly, []State{StateCancelled}, beforeCancelCutoff},
+		{KindRent, StateHandoverPending, ActionCancel, requesterOnly, []State{StateCancelled}, nil},
+
+		{KindRent, StateInUse, ActionReturn, participants, []State{StateReturnPending, StateCompleted}, withoutCode},
+		{KindRent, StateInUse, ActionReturnCode, participants, []State{StateCompleted}, nil},
+		{KindRent, StateInUse, ActionExtend, requesterOnly, []State{StateInUse}, noExtensionPending},
+		{KindRent, StateInUse, ActionExtendRespond, ownerOnly, []State{StateInUse}, extensionPending},
+
+		{KindRent, StateReturnPending, ActionReturn, participants, []State{StateReturnPending, StateCompleted}, withoutCode},
+		{KindRent, StateReturnPending, ActionReturnCode, participants, []State{StateCompleted}, nil},
+		{KindRent, StateReturnPending, ActionOpenDispute, participants, []State{StateReturnPending}, nil},
+
+		{KindRent, StateCompleted, ActionOpenDispute, participants, []State{StateCompleted}, nil},
+	}
+}
+
+// transferTable — buy и give устроены одинаково: передача сразу завершает сделку.
+func transferTable(k Kind) []Transition {
+	return []Transition{
+		{k, StateRequested, ActionApprove, ownerOnly, []State{StateApproved}, nil},
+		{k, StateRequested, ActionAutoApprove, systemOnly, []State{StateApproved}, nil},
+		{k, StateRequested, ActionDecline, ownerOnly, []State{StateDeclined}, nil},
+		{k, StateRequested, ActionCancel, requesterOnly, []State{StateCancelled}, nil},
+
+		{k, StateApproved, ActionHandover, participants, []State{StateHandoverPending, StateCompleted}, nil},
+		{k, StateApproved, ActionOwnerCancel, ownerOnly, []State{StateCancelled}, nil},
+		{k, StateApproved, ActionExpire, systemOnly, []State{StateExpired}, handoverExpired},
+
+		{k, StateHandoverPending, ActionHandover, participants, []State{StateHandoverPending, StateCompleted}, nil},
+		{k, StateHandoverPending, ActionOwnerCancel, ownerOnly, []State{StateCancelled}, nil},
+
+		{k, StateCompleted, ActionOpenDispute, participants, []State{StateCompleted}, nil},
+	}
+}
+
+var table = func() []Transition {
+	t := rentTable()
+	t = append(t, transferTable(KindBuy)...)
+	t = append(t, transferTable(KindGive)...)
+	return t
+}()
+
+// Table — копия таблицы переходов (для документации и проверок).
+func Table() []Transition {
+	out := make([]Transition, len(table))
+	copy(out, table)
+	return out
+}
+
+func find(kind Kind, from State, action Action) (Transition, bool) {
+	for _, t := range table {
+		if t.Kind == kind && t.From == from && t.Action == action {
+			return t, true
+		}
+	}
+	return Transition{}, false
+}
+
+// Check — можно ли выполнить action из статуса from от имени role.
+// Порядок ошибок: нет такого перехода -> чужая роль -> заморозка -> guard.
+// Замороженную спором бронь меняет только admin.
+func Check(kind Kind, from State, action Action, role Role, f Facts) (Transition, error) {
+	t, ok := find(kind, from, action)
+	if !ok {
+		return Transition{}, ErrNotAllowed
+	}
+	if !t.allows(role) {
+		return Transition{}, ErrRole
+	}
+	if f.Frozen && role != RoleAdmin {
+		return Transition{}, ErrFrozen
+	}
+	if t.Guard != nil {
+		if err := t.Guard(f, role); err != nil {
+			return Transition{}, err
+		}
+	}
+	return t, nil
+}
+
+// Available — действия, которые role может выполнить прямо сейчас.
+func Available(kind Kind, from State, role Role, f Facts) []Action {
+	out := make([]Action, 0, 4)
+	for _, t := range table {
+		if t.Kind != kind || t.From != from {
+			continue
+		}
+		if _, err := Check(kind, from, t.Action, role, f); err == nil {
+			out = append(out, t.Action)
+		}
+	}
+	return out
+}
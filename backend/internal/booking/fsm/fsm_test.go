@@ -0,0 +1,307 @@
+package fsm
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	allKinds   = []Kind{KindRent, KindBuy, KindGive}
+	allStates  = []State{StateRequested, StateApproved, StateHandoverPending, StateInUse, StateReturnPending, StateCompleted, StateDeclined, StateCancelled, StateExpired}
+	allRoles   = []Role{RoleOwner, RoleRequester, RoleSystem, RoleAdmin}
+	allActions = []Action{ActionApprove, ActionAutoApprove, ActionDecline, ActionCancel, ActionOwnerCancel, ActionHandover, ActionHandoverCode, ActionReturn, ActionReturnCode, ActionExpire, ActionReschedule, ActionRescheduleRespond, ActionExtend, ActionExtendRespond, ActionOpenDispute}
+)
+
+type key struct {
+	kind   Kind
+	from   State
+	action Action
+}
+
+// expected — ожидаемая таблица, записанная независимо от table: кто может и куда ведёт.
+func expected() map[key]struct {
+	roles []Role
+	to    []State
+} {
+	type row = struct {
+		roles []Role
+		to    []State
+	}
+	o, rq, sys := []Role{RoleOwner}, []Role{RoleRequester}, []Role{RoleSystem}
+	both := []Role{RoleOwner, RoleRequester}
+
+	m := map[key]row{
+		{KindRent, StateRequested, ActionApprove}:           {o, []State{StateApproved}},
+		{KindRent, StateRequested, ActionAutoApprove}:       {sys, []State{StateApproved}},
+		{KindRent, StateRequested, ActionDecline}:           {o, []State{StateDeclined}},
+		{KindRent, StateRequested, ActionCancel}:            {rq, []State{StateCancelled}},
+		{KindRent, StateRequested, ActionReschedule}:        {both, []State{StateRequested}},
+		{KindRent, StateRequested, ActionRescheduleRespond}: {both, []State{StateRequested}},
+
+		{KindRent, StateApproved, ActionHandover}:          {both, []State{StateHandoverPending, StateInUse}},
+		{KindRent, StateApproved, ActionHandoverCode}:      {both, []State{StateInUse}},
+		{KindRent, StateApproved, ActionOwnerCancel}:       {o, []State{StateCancelled}},
+		{KindRent, StateApproved, ActionCancel}:            {rq, []State{StateCancelled}},
+		{KindRent, StateApproved, ActionExpire}:            {sys, []State{StateExpired}},
+		{KindRent, StateApproved, ActionReschedule}:        {both, []State{StateApproved}},
+		{KindRent, StateApproved, ActionRescheduleRespond}: {both, []State{StateApproved}},
+
+		{KindRent, StateHandoverPending, ActionHandover}:     {both, []State{StateHandoverPending, StateInUse}},
+		{KindRent, StateHandoverPending, ActionHandoverCode}: {both, []State{StateInUse}},
+		{KindRent, StateHandoverPending, ActionOwnerCancel}:  {o, []State{StateCancelled}},
+		{KindRent, StateHandoverPending, ActionCancel}:       {rq, []State{StateCancelled}},
+
+		{KindRent, StateInUse, ActionReturn}:        {both, []State{StateReturnPending, StateCompleted}},
+		{KindRent, StateInUse, ActionReturnCode}:    {both, []State{StateCompleted}},
+		{KindRent, StateInUse, ActionExtend}:        {rq, []State{StateInUse}},
+		{KindRent, StateInUse, ActionExtendRespond}: {o, []State{StateInUse}},
+
+		{KindRent, StateReturnPending, ActionReturn}:      {both, []State{StateReturnPending, StateCompleted}},
+		{KindRent, StateReturnPending, ActionReturnCode}:  {both, []State{StateCompleted}},
+		{KindRent, StateReturnPending, ActionOpenDispute}: {both, []State{StateReturnPending}},
+
+		{KindRent, StateCompleted, ActionOpenDispute}: {both, []State{StateCompleted}},
+	}
+	for _, k := range []Kind{KindBuy, KindGive} {
+		m[key{k, StateRequested, ActionApprove}] = row{o, []State{StateApproved}}
+		m[key{k, StateRequested, ActionAutoApprove}] = row{sys, []State{StateApproved}}
+		m[key{k, StateRequested, ActionDecline}] = row{o, []State{StateDeclined}}
+		m[key{k, StateRequested, ActionCancel}] = row{rq, []State{StateCancelled}}
+		m[key{k, StateApproved, ActionHandover}] = row{both, []State{StateHandoverPending, StateCompleted}}
+		m[key{k, StateApproved, ActionOwnerCancel}] = row{o, []State{StateCancelled}}
+		m[key{k, StateApproved, ActionExpire}] = row{sys, []State{StateExpired}}
+		m[key{k, StateHandoverPending, ActionHandover}] = row{both, []State{StateHandoverPending, StateCompleted}}
+		m[key{k, StateHandoverPending, ActionOwnerCancel}] = row{o, []State{StateCancelled}}
+		m[key{k, StateCompleted, ActionOpenDispute}] = row{both, []State{StateCompleted}}
+	}
+	return m
+}
+
+func contains[T comparable](xs []T, x T) bool {
+	for _, v := range xs {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
+
+var now = time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// passingFacts — факты, при которых guard действия проходит для role (expire требует прошедшего
+// дедлайна, остальные — будущего; ответы на продление и перенос — ожидающего запроса второй стороны).
+func passingFacts(a Action, role Role) Facts {
+	start := now.Add(72 * time.Hour)
+	deadline := now.Add(time.Hour)
+	if a == ActionExpire {
+		deadline = now.Add(-time.Hour)
+	}
+	f := Facts{Now: now, Start: &start, HandoverDeadline: &deadline, OwnerCancelCutoff: 24 * time.Hour}
+	switch a {
+	case ActionExtendRespond:
+		f.ExtensionPending = true
+	case ActionRescheduleRespond:
+		f.RescheduleBy = RoleOwner
+		if role == RoleOwner {
+			f.RescheduleBy = RoleRequester
+		}
+	}
+	return f
+}
+
+func TestCheckAllCombinations(t *testing.T) {
+	exp := expected()
+	for _, kind := range allKinds {
+		for _, from := range allStates {
+			for _, action := range allActions {
+				for _, role := range allRoles {
+					row, ok := exp[key{kind, from, action}]
+					tr, err := Check(kind, from, action, role, passingFacts(action, role))
+
+					switch {
+					case !ok:
+						if !errors.Is(err, ErrNotAllowed) {
+							t.Errorf("%s %s %s as %s: want ErrNotAllowed, got %v", kind, from, action, role, err)
+						}
+					case !contains(row.roles, role):
+						if !errors.Is(err, ErrRole) {
+							t.Errorf("%s %s %s as %s: want ErrRole, got %v", kind, from, action, role, err)
+						}
+					default:
+						if err != nil {
+							t.Errorf("%s %s %s as %s: want ok, got %v", kind, from, action, role, err)
+							continue
+						}
+						for _, s := range allStates {
+							if tr.Leads(s) != contains(row.to, s) {
+								t.Errorf("%s %s %s: Leads(%s) = %v", kind, from, action, s, tr.Leads(s))
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestTableHasNoExtraRows(t *testing.T) {
+	exp := expected()
+	seen := make(map[key]bool)
+	for _, tr := range Table() {
+		k := key{tr.Kind, tr.From, tr.Action}
+		if seen[k] {
+			t.Errorf("duplicate transition %v", k)
+		}
+		seen[k] = true
+		if _, ok := exp[k]; !ok {
+			t.Errorf("unexpected transition %v", k)
+		}
+	}
+	if len(seen) != len(exp) {
+		t.Errorf("table has %d transitions, want %d", len(seen), len(exp))
+	}
+}
+
+func TestAvailableAllCombinations(t *testing.T) {
+	exp := expected()
+	for _, kind := range allKinds {
+		for _, from := range allStates {
+			for _, role := range allRoles {
+				// с дедлайном в будущем expire недоступен, без ожидающих запросов нечего принимать,
+				// всё остальное — по таблице
+				f := passingFacts(ActionHandover, role)
+				got := Available(kind, from, role, f)
+
+				want := make([]Action, 0)
+				for _, a := range allActions {
+					row, ok := exp[key{kind, from, a}]
+					if a == ActionExpire || a == ActionExtendRespond || a == ActionRescheduleRespond {
+						continue
+					}
+					if ok && contains(row.roles, role) {
+						want = append(want, a)
+					}
+				}
+				if len(got) != len(want) {
+					t.Errorf("%s %s as %s: Available = %v, want %v", kind, from, role, got, want)
+					continue
+				}
+				for _, a := range want {
+					if !contains(got, a) {
+						t.Errorf("%s %s as %s: Available = %v, want %v", kind, from, role, got, want)
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestFrozenBlocksParticipants(t *testing.T) {
+	for _, tr := range Table() {
+		for _, role := range tr.Roles {
+			f := passingFacts(tr.Action, role)
+			f.Frozen = true
+			if _, err := Check(tr.Kind, tr.From, tr.Action, role, f); !errors.Is(err, ErrFrozen) {
+				t.Errorf("%s %s %s as %s on frozen booking: want ErrFrozen, got %v", tr.Kind, tr.From, tr.Action, role, err)
+			}
+		}
+		f := passingFacts(tr.Action, RoleOwner)
+		f.Frozen = true
+		if got := Available(tr.Kind, tr.From, RoleOwner, f); len(got) != 0 {
+			t.Errorf("%s %s: frozen booking offers %v", tr.Kind, tr.From, got)
+		}
+	}
+}
+
+func TestGuards(t *testing.T) {
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+	soon := now.Add(2 * time.Hour)
+	later := now.Add(48 * time.Hour)
+
+	tests := []struct {
+		name   string
+		kind   Kind
+		from   State
+		action Action
+		role   Role
+		facts  Facts
+		want   error
+	}{
+		{"handover after deadline", KindRent, StateApproved, ActionHandover, RoleOwner, Facts{Now: now, HandoverDeadline: &past}, ErrDeadlinePassed},
+		{"handover without deadline", KindRent, StateHandoverPending, ActionHandover, RoleRequester, Facts{Now: now}, ErrDeadlinePassed},
+		{"handover in time", KindRent, StateApproved, ActionHandover, RoleOwner, Facts{Now: now, HandoverDeadline: &future}, nil},
+		{"transfer handover has no deadline guard", KindBuy, StateApproved, ActionHandover, RoleOwner, Facts{Now: now, HandoverDeadline: &past}, nil},
+
+		{"expire before deadline", KindRent, StateApproved, ActionExpire, RoleSystem, Facts{Now: now, HandoverDeadline: &future}, ErrDeadlineNotReached},
+		{"expire without deadline", KindGive, StateApproved, ActionExpire, RoleSystem, Facts{Now: now}, ErrDeadlineNotReached},
+		{"expire after deadline", KindGive, StateApproved, ActionExpire, RoleSystem, Facts{Now: now, HandoverDeadline: &past}, nil},
+
+		{"owner cancel inside cutoff", KindRent, StateApproved, ActionOwnerCancel, RoleOwner, Facts{Now: now, Start: &soon, OwnerCancelCutoff: 24 * time.Hour}, ErrCancelCutoff},
+		{"owner cancel before cutoff", KindRent, StateHandoverPending, ActionOwnerCancel, RoleOwner, Facts{Now: now, Start: &later, OwnerCancelCutoff: 24 * time.Hour}, nil},
+		{"transfer owner cancel ignores cutoff", KindBuy, StateApproved, ActionOwnerCancel, RoleOwner, Facts{Now: now, Start: &soon, OwnerCancelCutoff: 24 * time.Hour}, nil},
+
+		{"handover without code on code item", KindRent, StateApproved, ActionHandover, RoleRequester, Facts{Now: now, HandoverDeadline: &future, CodeRequired: true}, ErrCodeRequired},
+		{"handover by code on code item", KindRent, StateHandoverPending, ActionHandoverCode, RoleRequester, Facts{Now: now, HandoverDeadline: &future, CodeRequired: true}, nil},
+		{"handover by code after deadline", KindRent, StateApproved, ActionHandoverCode, RoleRequester, Facts{Now: now, HandoverDeadline: &past}, ErrDeadlinePassed},
+		{"return without code on code item", KindRent, StateInUse, ActionReturn, RoleOwner, Facts{Now: now, CodeRequired: true}, ErrCodeRequired},
+		{"return by code on code item", KindRent, StateReturnPending, ActionReturnCode, RoleOwner, Facts{Now: now, CodeRequired: true}, nil},
+		{"transfer handover ignores code", KindGive, StateApproved, ActionHandover, RoleOwner, Facts{Now: now, CodeRequired: true}, nil},
+
+		{"extend while extension pending", KindRent, StateInUse, ActionExtend, RoleRequester, Facts{Now: now, ExtensionPending: true}, ErrPending},
+		{"extend respond without extension", KindRent, StateInUse, ActionExtendRespond, RoleOwner, Facts{Now: now}, ErrNothingPending},
+		{"extend respond to pending extension", KindRent, StateInUse, ActionExtendRespond, RoleOwner, Facts{Now: now, ExtensionPending: true}, nil},
+
+		{"reschedule over own proposal", KindRent, StateApproved, ActionReschedule, RoleOwner, Facts{Now: now, RescheduleBy: RoleOwner}, nil},
+		{"reschedule over other side proposal", KindRent, StateRequested, ActionReschedule, RoleOwner, Facts{Now: now, RescheduleBy: RoleRequester}, ErrPending},
+		{"reschedule respond without proposal", KindRent, StateApproved, ActionRescheduleRespond, RoleRequester, Facts{Now: now}, ErrNothingPending},
+		{"reschedule respond to own proposal", KindRent, StateApproved, ActionRescheduleRespond, RoleRequester, Facts{Now: now, RescheduleBy: RoleRequester}, ErrRole},
+		{"reschedule respond to other side proposal", KindRent, StateRequested, ActionRescheduleRespond, RoleRequester, Facts{Now: now, RescheduleBy: RoleOwner}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Check(tt.kind, tt.from, tt.action, tt.role, tt.facts)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestAvailablePending — список действий учитывает ожидающие запросы и требование кода.
+func TestAvailablePending(t *testing.T) {
+	future := now.Add(time.Hour)
+	tests := []struct {
+		name    string
+		from    State
+		role    Role
+		facts   Facts
+		want    []Action
+		notWant []Action
+	}{
+		{"requester with pending extension", StateInUse, RoleRequester, Facts{Now: now, ExtensionPending: true}, []Action{ActionReturn}, []Action{ActionExtend}},
+		{"owner with pending extension", StateInUse, RoleOwner, Facts{Now: now, ExtensionPending: true}, []Action{ActionExtendRespond}, nil},
+		{"owner without extension", StateInUse, RoleOwner, Facts{Now: now}, []Action{ActionReturn}, []Action{ActionExtendRespond}},
+		{"proposer waits for answer", StateApproved, RoleOwner, Facts{Now: now, HandoverDeadline: &future, RescheduleBy: RoleOwner}, []Action{ActionReschedule}, []Action{ActionRescheduleRespond}},
+		{"other side answers", StateApproved, RoleRequester, Facts{Now: now, HandoverDeadline: &future, RescheduleBy: RoleOwner}, []Action{ActionRescheduleRespond}, []Action{ActionReschedule}},
+		{"handover on code item", StateApproved, RoleRequester, Facts{Now: now, HandoverDeadline: &future, CodeRequired: true}, []Action{ActionHandoverCode}, []Action{ActionHandover}},
+		{"return on code item", StateReturnPending, RoleOwner, Facts{Now: now, CodeRequired: true}, []Action{ActionReturnCode}, []Action{ActionReturn}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Available(KindRent, tt.from, tt.role, tt.facts)
+			for _, a := range tt.want {
+				if !contains(got, a) {
+					t.Errorf("Available = %v, want %s", got, a)
+				}
+			}
+			for _, a := range tt.notWant {
+				if contains(got, a) {
+					t.Errorf("Available = %v, must not offer %s", got, a)
+				}
+			}
+		})
+	}
+}
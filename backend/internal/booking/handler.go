@@ -3,6 +3,7 @@ package booking
 import (
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
@@ -12,21 +13,30 @@ import (
 	"context"
 
 	"github.com/SHILOP0P/Yardly/backend/internal/auth"
+	"github.com/SHILOP0P/Yardly/backend/internal/booking/fsm"
 	"github.com/SHILOP0P/Yardly/backend/internal/httpx"
 	"github.com/SHILOP0P/Yardly/backend/internal/item"
+	"github.com/SHILOP0P/Yardly/backend/internal/pricing"
 )
 
 type Handler struct{
 	repo Repo
 	items ItemGetter
+	cfg Config
+}
+
+// Config — настраиваемые правила бронирований.
+type Config struct {
+	// OwnerCancelCutoff — владелец может отменить одобренную аренду не позже, чем за это время до start_at.
+	OwnerCancelCutoff time.Duration
 }
 
 type ItemGetter interface{
 	GetByID(ctx context.Context, id int64)(item.Item, error)
 }
 
-func NewHandler(repo Repo, items ItemGetter) *Handler{
-	return &Handler{repo: repo, items: items}
+func NewHandler(repo Repo, items ItemGetter, cfg Config) *Handler{
+	return &Handler{repo: repo, items: items, cfg: cfg}
 }
 
 type createRentRequestDTO struct{
@@ -34,29 +44,87 @@ type createRentRequestDTO struct{
 	End   string `json:"end_at"`   // "2006-01-02"
 }
 
+type extendRequestDTO struct {
+	End string `json:"end_at"` // YYYY-MM-DD включительно; для почасовых вещей — RFC3339
+}
+
+// confirmRequestDTO — необязательное тело handover/return: одноразовый код второй стороны.
+type confirmRequestDTO struct {
+	Code string `json:"code,omitempty"`
+}
+
+type reasonRequestDTO struct {
+	Reason *string `json:"reason,omitempty"`
+}
+
 type approveResponseDTO struct {
 	Approved Booking   `json:"approved"`
 	Declined []Booking `json:"declined"`
 }
 
+const maxBulkIDs = 50
+
+type bulkRequestDTO struct {
+	Action string  `json:"action"` // "approve" | "decline"
+	IDs    []int64 `json:"ids"`
+	Reason *string `json:"reason,omitempty"` // только для decline
+}
+
+// Итог по одной брони в массовой операции.
+const (
+	BulkOK           = "ok"
+	BulkConflict     = "conflict"
+	BulkInvalidState = "invalid_state"
+	BulkForbidden    = "forbidden"
+	BulkNotFound     = "not_found"
+	BulkInvalid      = "invalid" // id <= 0 или повтор в ids
+	BulkError        = "error"
+)
+
+// bulkResultDTO — для approve при успехе поля approveResponseDTO (approved/declined) встраиваются как есть.
+type bulkResultDTO struct {
+	BookingID int64  `json:"booking_id"`
+	Result    string `json:"result"`
+	Error     string `json:"error,omitempty"`
+
+	*approveResponseDTO
+	Booking *Booking `json:"booking,omitempty"` // отклонённая заявка для decline
+}
+
+type bulkResponseDTO struct {
+	Action  string          `json:"action"`
+	OK      int             `json:"ok"`
+	Failed  int             `json:"failed"`
+	Results []bulkResultDTO `json:"results"`
+}
+
 type DayRange struct {
 	Start string `json:"start"` // YYYY-MM-DD
 	End   string `json:"end"`   // YYYY-MM-DD
 }
 
+// TimeRange — занятый интервал [start, end) для почасовых вещей.
+type TimeRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
 type availabilityResponse struct {
 	ItemID      int64            `json:"item_id"`
 	From        string           `json:"from"`
 	To          string           `json:"to"`
 	Timezone    string           `json:"timezone"`
+	Granularity string           `json:"granularity"`
 	IsInUseNow  bool             `json:"is_in_use_now"`
 	Busy        []DayRange       `json:"busy"`
+	Slots       []TimeRange      `json:"slots,omitempty"` // только для granularity=hour
+	Rules       item.RentRules   `json:"rules"`           // busy/slots уже включают буферные дни
 }
 
 
 type createBookingRequestDTO struct {
 	Type  string `json:"type"` // "rent" | "buy" | "give"
-	Start string `json:"start_at,omitempty"`
+	Start string `json:"start_at,omitempty"` // YYYY-MM-DD; для почасовых вещей — RFC3339 на границе часа
 	End   string `json:"end_at,omitempty"`
 }
 
@@ -132,18 +200,15 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request){
 	}
 
 	if tp == TypeRent{
-		startDay, err1 := time.Parse("2006-01-02", dto.Start)
-		endDay, err2 := time.Parse("2006-01-02", dto.End)
-		if err1 != nil || err2 != nil {
-			httpx.WriteError(w, http.StatusBadRequest, "start/end must be YYYY-MM-DD")
+		start, endExclusive, err := item.ParseRentRange(it.BookingGranularity, dto.Start, dto.End)
+		if err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, err.Error())
 			return
 		}
-		if endDay.Before(startDay) {
-			httpx.WriteError(w, http.StatusBadRequest, "end must be >= start")
+		if err := it.RentRules.Check(it.BookingGranularity, start, endExclusive, time.Now().UTC()); err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, err.Error())
 			return
 		}
-		start := time.Date(startDay.Year(), startDay.Month(), startDay.Day(), 0, 0, 0, 0, time.UTC)
-		endExclusive := time.Date(endDay.Year(), endDay.Month(), endDay.Day(), 0, 0, 0, 0, time.UTC).Add(24 * time.Hour)
 
 		b.Start = &start
 		b.End = &endExclusive
@@ -153,10 +218,32 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request){
 			return
 		}
 	}
-	if err := h.repo.Create(r.Context(), &b); err != nil {
+
+	// фиксируем цену сейчас: последующие правки item не меняют эту бронь
+	if err := snapshotPrice(&b, it); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	instant, err := h.instantBookAllowed(r, it, requesterID)
+	if err != nil {
+		log.Println("instant book check error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if instant {
+		err = h.repo.CreateAndApprove(r.Context(), &b, time.Now().UTC())
+	} else {
+		err = h.repo.Create(r.Context(), &b)
+	}
+	if err != nil {
 		switch {
 		case errors.Is(err, ErrDuplicateActiveRequest):
 			httpx.WriteError(w, http.StatusConflict, "active request already exists")
+		case errors.Is(err, ErrConflict), errors.Is(err, ErrWaitlistHold):
+			// можно встать в лист ожидания: POST /api/items/{id}/waitlist
+			httpx.WriteError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, item.ErrRentRule):
+			httpx.WriteError(w, http.StatusBadRequest, err.Error())
 		default:
 			log.Println("create booking error:", err)
 			httpx.WriteError(w, http.StatusInternalServerError, "internal error")
@@ -169,6 +256,22 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request){
 
 
 
+// instantBookAllowed — можно ли одобрить заявку сразу: вещь в режиме instant book и
+// у арендатора достаточно завершённых сделок.
+func (h *Handler) instantBookAllowed(r *http.Request, it item.Item, requesterID int64) (bool, error) {
+	if !it.InstantBook {
+		return false, nil
+	}
+	if it.InstantBookMinCompleted <= 0 {
+		return true, nil
+	}
+	n, err := h.repo.CountCompletedByRequester(r.Context(), requesterID)
+	if err != nil {
+		return false, err
+	}
+	return n >= it.InstantBookMinCompleted, nil
+}
+
 func (h *Handler) CreateRent(w http.ResponseWriter, r *http.Request){
 	itemID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err!=nil{
@@ -311,6 +414,127 @@ func (h *Handler) Approve(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// POST /api/bookings/bulk — {"action":"approve|decline","ids":[...],"reason":"..."}
+// Каждая бронь обрабатывается в своей транзакции, ошибки по одной не отменяют остальные.
+func (h *Handler) Bulk(w http.ResponseWriter, r *http.Request) {
+	ownerID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var dto bulkRequestDTO
+	if err := httpx.ReadJSON(r, &dto); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if dto.Action != "approve" && dto.Action != "decline" {
+		httpx.WriteError(w, http.StatusBadRequest, "action must be approve or decline")
+		return
+	}
+	if len(dto.IDs) == 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "ids are required")
+		return
+	}
+	if len(dto.IDs) > maxBulkIDs {
+		httpx.WriteError(w, http.StatusBadRequest, "at most "+strconv.Itoa(maxBulkIDs)+" ids per request")
+		return
+	}
+	reason, err := normalizeReason(dto.Reason)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp := bulkResponseDTO{Action: dto.Action, Results: make([]bulkResultDTO, 0, len(dto.IDs))}
+	seen := make(map[int64]bool, len(dto.IDs))
+	for _, id := range dto.IDs {
+		res := bulkResultDTO{BookingID: id, Result: BulkOK}
+		// на каждый id из запроса — ровно одна запись, чтобы ok + failed == len(ids)
+		switch {
+		case id <= 0:
+			res.Result, res.Error = BulkInvalid, "invalid booking id"
+		case seen[id]:
+			res.Result, res.Error = BulkInvalid, "duplicate booking id"
+		case dto.Action == "approve":
+			approved, declined, err := h.repo.ApproveWithDeclined(r.Context(), id, ownerID, time.Now().UTC())
+			if err != nil {
+				res.Result, res.Error = bulkResultOf(err)
+				break
+			}
+			res.approveResponseDTO = &approveResponseDTO{Approved: approved, Declined: declined}
+		case dto.Action == "decline":
+			b, err := h.repo.DeclineRequest(r.Context(), id, ownerID, reason)
+			if err != nil {
+				res.Result, res.Error = bulkResultOf(err)
+				break
+			}
+			res.Booking = &b
+		}
+		seen[id] = true
+
+		if res.Result == BulkOK {
+			resp.OK++
+		} else {
+			resp.Failed++
+		}
+		resp.Results = append(resp.Results, res)
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
+// bulkResultOf — код итога и текст ошибки для одной брони (по тем же ошибкам, что и writeBookingError).
+func bulkResultOf(err error) (string, string) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return BulkNotFound, "booking not found"
+	case errors.Is(err, ErrForbidden):
+		return BulkForbidden, "forbidden"
+	case errors.Is(err, ErrConflict), errors.Is(err, ErrWaitlistHold):
+		return BulkConflict, err.Error()
+	case errors.Is(err, ErrInvalidState), errors.Is(err, ErrFrozen), errors.Is(err, item.ErrRentRule):
+		return BulkInvalidState, err.Error()
+	default:
+		log.Println("bulk booking error:", err)
+		return BulkError, "internal error"
+	}
+}
+
+// POST /api/bookings/{id}/decline
+func (h *Handler) Decline(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	ownerID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// тело опционально: пустой body = отклонение без причины
+	var dto reasonRequestDTO
+	if err := httpx.ReadJSON(r, &dto); err != nil && !errors.Is(err, io.EOF) {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	reason, err := normalizeReason(dto.Reason)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	b, err := h.repo.DeclineRequest(r.Context(), bookingID, ownerID, reason)
+	if err != nil {
+		writeBookingError(w, "decline", err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, b)
+}
+
 
 func (h *Handler) Return(w http.ResponseWriter, r *http.Request) {
 	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
@@ -324,7 +548,18 @@ func (h *Handler) Return(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	b, err := h.repo.ReturnRent(r.Context(), bookingID, actorID, time.Now().UTC())
+	code, err := readConfirmCode(r)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+
+	var b Booking
+	if code != "" {
+		b, err = h.repo.ConfirmWithCode(r.Context(), bookingID, actorID, CodeReturn, code, time.Now().UTC())
+	} else {
+		b, err = h.repo.ReturnRent(r.Context(), bookingID, actorID, time.Now().UTC())
+	}
 	if err != nil {
 		writeBookingError(w, "return", err)
 		return
@@ -352,11 +587,19 @@ func (h *Handler) Handover(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	code, err := readConfirmCode(r)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+
 	var b Booking
-	switch b0.Type {
-	case TypeRent:
+	switch {
+	case b0.Type == TypeRent && code != "":
+		b, err = h.repo.ConfirmWithCode(r.Context(), bookingID, actorID, CodeHandover, code, time.Now().UTC())
+	case b0.Type == TypeRent:
 		b, err = h.repo.HandoverRent(r.Context(), bookingID, actorID, time.Now().UTC())
-	case TypeBuy, TypeGive:
+	case b0.Type == TypeBuy, b0.Type == TypeGive:
 		b, err = h.repo.HandoverTransfer(r.Context(), bookingID, actorID, time.Now().UTC())
 	default:
 		httpx.WriteError(w, http.StatusBadRequest, "invalid type")
@@ -371,6 +614,58 @@ func (h *Handler) Handover(w http.ResponseWriter, r *http.Request) {
 }
 
 
+// readConfirmCode — код из тела запроса; пустое тело допустимо (подтверждение без кода).
+func readConfirmCode(r *http.Request) (string, error) {
+	var dto confirmRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimSpace(dto.Code), nil
+}
+
+// POST /api/bookings/{id}/handover/code — владелец получает код для арендатора.
+func (h *Handler) IssueHandoverCode(w http.ResponseWriter, r *http.Request) {
+	h.issueCode(w, r, CodeHandover)
+}
+
+// POST /api/bookings/{id}/return/code — арендатор получает код для владельца.
+func (h *Handler) IssueReturnCode(w http.ResponseWriter, r *http.Request) {
+	h.issueCode(w, r, CodeReturn)
+}
+
+func (h *Handler) issueCode(w http.ResponseWriter, r *http.Request, purpose CodePurpose) {
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	code, err := NewConfirmationCode()
+	if err != nil {
+		log.Println("generate confirmation code error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	expiresAt, err := h.repo.IssueConfirmationCode(r.Context(), bookingID, actorID, purpose, HashConfirmationCode(bookingID, purpose, code), time.Now().UTC())
+	if err != nil {
+		writeBookingError(w, "issue confirmation code", err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusCreated, ConfirmationCode{
+		BookingID: bookingID,
+		Purpose:   purpose,
+		Code:      code,
+		QRPayload: CodeQRPayload(bookingID, purpose, code),
+		ExpiresAt: expiresAt,
+	})
+}
+
 func (h *Handler) Cancel(w http.ResponseWriter, r *http.Request){
 	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil || bookingID <= 0 {
@@ -388,6 +683,10 @@ func (h *Handler) Cancel(w http.ResponseWriter, r *http.Request){
 		writeBookingError(w, "get booking", err)
 		return
 	}
+	if b0.OwnerID == requesterID {
+		h.cancelByOwner(w, r, b0)
+		return
+	}
 	if b0.RequesterID != requesterID {
 		httpx.WriteError(w, http.StatusForbidden, "forbidden")
 		return
@@ -412,6 +711,380 @@ func (h *Handler) Cancel(w http.ResponseWriter, r *http.Request){
 }
 
 
+// cancelByOwner — владелец отменяет одобренную бронь, причина обязательна.
+func (h *Handler) cancelByOwner(w http.ResponseWriter, r *http.Request, b0 Booking) {
+	var dto reasonRequestDTO
+	if err := httpx.ReadJSON(r, &dto); err != nil && !errors.Is(err, io.EOF) {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	reason, err := normalizeReason(dto.Reason)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if reason == nil {
+		httpx.WriteError(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+
+	b, err := h.repo.CancelByOwner(r.Context(), b0.ID, b0.OwnerID, *reason, time.Now().UTC(), h.cfg.OwnerCancelCutoff)
+	if err != nil {
+		writeBookingError(w, "owner cancel", err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, b)
+}
+
+
+// POST /api/bookings/{id}/reschedule
+func (h *Handler) Reschedule(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var dto createRentRequestDTO
+	if err := httpx.ReadJSON(r, &dto); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+
+	b0, err := h.repo.GetByID(r.Context(), bookingID)
+	if err != nil {
+		writeBookingError(w, "get booking", err)
+		return
+	}
+	it, err := h.items.GetByID(r.Context(), b0.ItemID)
+	if err != nil {
+		httpx.WriteError(w, http.StatusNotFound, "item not found")
+		return
+	}
+	start, end, err := item.ParseRentRange(it.BookingGranularity, dto.Start, dto.End)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := time.Now().UTC()
+	earliest := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if it.BookingGranularity == item.GranularityHour {
+		earliest = now.Truncate(time.Hour)
+	}
+	if start.Before(earliest) {
+		httpx.WriteError(w, http.StatusBadRequest, "start must not be in the past")
+		return
+	}
+	if err := it.RentRules.Check(it.BookingGranularity, start, end, now); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	b, err := h.repo.ProposeReschedule(r.Context(), bookingID, actorID, start, end, now)
+	if err != nil {
+		writeBookingError(w, "reschedule propose", err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, b)
+}
+
+// POST /api/bookings/{id}/reschedule/accept
+func (h *Handler) AcceptReschedule(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	b, err := h.repo.AcceptReschedule(r.Context(), bookingID, actorID)
+	if err != nil {
+		writeBookingError(w, "reschedule accept", err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, b)
+}
+
+// POST /api/bookings/{id}/reschedule/reject
+func (h *Handler) RejectReschedule(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	b, err := h.repo.RejectReschedule(r.Context(), bookingID, actorID)
+	if err != nil {
+		writeBookingError(w, "reschedule reject", err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, b)
+}
+
+
+// POST /api/bookings/{id}/extend
+func (h *Handler) Extend(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	requesterID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var dto extendRequestDTO
+	if err := httpx.ReadJSON(r, &dto); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+
+	b0, err := h.repo.GetByID(r.Context(), bookingID)
+	if err != nil {
+		writeBookingError(w, "get booking", err)
+		return
+	}
+	if b0.RequesterID != requesterID {
+		httpx.WriteError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+	it, err := h.items.GetByID(r.Context(), b0.ItemID)
+	if err != nil {
+		httpx.WriteError(w, http.StatusNotFound, "item not found")
+		return
+	}
+	newEnd, err := item.ParseRentEnd(it.BookingGranularity, dto.End)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if b0.Start != nil {
+		if err := it.RentRules.CheckLength(it.BookingGranularity, *b0.Start, newEnd); err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	// сразу отсекаем продление, которое наезжает на следующую одобренную бронь (с учётом зазора между арендами)
+	now := time.Now().UTC()
+	_, upcoming, err := h.repo.ListUpcomingByItem(r.Context(), b0.ItemID, now, 1)
+	if err != nil {
+		writeBookingError(w, "extend upcoming", err)
+		return
+	}
+	if len(upcoming) > 0 && upcoming[0].Start != nil && upcoming[0].Start.Add(-it.RentRules.Gap()).Before(newEnd) {
+		httpx.WriteError(w, http.StatusConflict, ErrConflict.Error())
+		return
+	}
+
+	b, err := h.repo.RequestExtension(r.Context(), bookingID, requesterID, newEnd, now)
+	if err != nil {
+		writeBookingError(w, "extend request", err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, b)
+}
+
+// POST /api/bookings/{id}/extend/approve
+func (h *Handler) ApproveExtension(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	ownerID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	b, err := h.repo.ApproveExtension(r.Context(), bookingID, ownerID)
+	if err != nil {
+		writeBookingError(w, "extend approve", err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, b)
+}
+
+// POST /api/bookings/{id}/extend/reject
+func (h *Handler) RejectExtension(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	ownerID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	b, err := h.repo.RejectExtension(r.Context(), bookingID, ownerID)
+	if err != nil {
+		writeBookingError(w, "extend reject", err)
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, b)
+}
+
+
+// GET /api/bookings/{id}/deposit
+func (h *Handler) Deposit(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	b, err := h.repo.GetByID(r.Context(), bookingID)
+	if err != nil {
+		writeBookingError(w, "get booking", err)
+		return
+	}
+	if b.RequesterID != userID && b.OwnerID != userID {
+		httpx.WriteError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	d, err := h.repo.GetDeposit(r.Context(), bookingID)
+	if err != nil {
+		writeBookingError(w, "get deposit", err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, d)
+}
+
+type actionsResponse struct {
+	BookingID int64        `json:"booking_id"`
+	Status    Status       `json:"status"`
+	Role      fsm.Role     `json:"role"`
+	Actions   []fsm.Action `json:"actions"`
+}
+
+// GET /api/bookings/{id}/actions — что текущий участник может сделать дальше (по таблице fsm).
+func (h *Handler) Actions(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	b, err := h.repo.GetByID(r.Context(), bookingID)
+	if err != nil {
+		writeBookingError(w, "get booking", err)
+		return
+	}
+	role := b.RoleOf(userID)
+	if role == "" {
+		httpx.WriteError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	it, err := h.items.GetByID(r.Context(), b.ItemID)
+	if err != nil {
+		httpx.WriteError(w, http.StatusNotFound, "item not found")
+		return
+	}
+	f := b.Facts(time.Now().UTC(), h.cfg.OwnerCancelCutoff)
+	f.CodeRequired = it.RequireHandoverCode
+
+	httpx.WriteJSON(w, http.StatusOK, actionsResponse{
+		BookingID: b.ID,
+		Status:    b.Status,
+		Role:      role,
+		Actions:   AvailableActions(b, userID, f),
+	})
+}
+
+type cancelPreviewResponse struct {
+	BookingID int64             `json:"booking_id"`
+	Terms     []item.RefundTier `json:"terms,omitempty"` // ступени политики отмены
+	CanCancel bool              `json:"can_cancel"`
+	Reason    string            `json:"reason,omitempty"` // почему отменить нельзя
+	Preview   CancelOutcome     `json:"preview"`
+}
+
+// GET /api/bookings/{id}/cancel-preview — условия отмены и сколько вернётся, если отменить сейчас.
+func (h *Handler) CancelPreview(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	b, err := h.repo.GetByID(r.Context(), bookingID)
+	if err != nil {
+		writeBookingError(w, "get booking", err)
+		return
+	}
+	role := b.RoleOf(userID)
+	if role == "" {
+		httpx.WriteError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	now := time.Now().UTC()
+	action, by := fsm.ActionCancel, "requester"
+	if role == fsm.RoleOwner {
+		action, by = fsm.ActionOwnerCancel, "owner"
+	}
+
+	resp := cancelPreviewResponse{
+		BookingID: b.ID,
+		CanCancel: true,
+		Preview:   ComputeCancelOutcome(b, by, now),
+	}
+	if b.CancellationPolicy != nil {
+		resp.Terms = b.CancellationPolicy.Tiers()
+	}
+	if _, err := CheckAction(b, action, role, b.Facts(now, h.cfg.OwnerCancelCutoff)); err != nil {
+		resp.CanCancel = false
+		resp.Reason = err.Error()
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, resp)
+}
+
 func (h *Handler) ListMyBookings(w http.ResponseWriter, r *http.Request){
 	requesterID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
@@ -469,7 +1142,17 @@ func (h *Handler) ListMyItemsBookings(w http.ResponseWriter, r *http.Request){
 		return
 	}
 
-	out, err := h.repo.ListMyItemsBookings(r.Context(), ownerID, statuses, limit, offset)
+	overdueOnly := false
+	if s := r.URL.Query().Get("overdue"); s != "" {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, "invalid overdue")
+			return
+		}
+		overdueOnly = v
+	}
+
+	out, err := h.repo.ListMyItemsBookings(r.Context(), ownerID, statuses, overdueOnly, limit, offset)
 	if err!= nil{
 		log.Println("list my items bookings error:", err)
 		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
@@ -592,6 +1275,12 @@ func (h *Handler) AvailabilityByItem(w http.ResponseWriter, r *http.Request){
 		return
 	}
 
+	it, err := h.items.GetByID(r.Context(), itemID)
+	if err != nil {
+		httpx.WriteError(w, http.StatusNotFound, "item not found")
+		return
+	}
+
 	busy, inUseNow, err:= h.repo.ListBusyDaysByItem(r.Context(), itemID, fromDay, toDay)
 	if err != nil {
 		log.Println("availability error:", err)
@@ -600,12 +1289,24 @@ func (h *Handler) AvailabilityByItem(w http.ResponseWriter, r *http.Request){
 	}
 
 	resp := availabilityResponse{
-		ItemID:     itemID,
-		From:       fromS,
-		To:         toS,
-		Timezone:   "UTC",
-		IsInUseNow: inUseNow,
-		Busy:       busy,
+		ItemID:      itemID,
+		From:        fromS,
+		To:          toS,
+		Timezone:    "UTC",
+		Granularity: string(it.BookingGranularity),
+		IsInUseNow:  inUseNow,
+		Busy:        busy,
+		Rules:       it.RentRules,
+	}
+	if it.BookingGranularity == item.GranularityHour {
+		// to включительно, как и для дней
+		slots, err := h.repo.ListBusySlotsByItem(r.Context(), itemID, fromDay, toDay.Add(24*time.Hour))
+		if err != nil {
+			log.Println("availability slots error:", err)
+			httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		resp.Slots = slots
 	}
 	httpx.WriteJSON(w, http.StatusOK, resp)
 }
@@ -658,6 +1359,20 @@ func writeBookingError(w http.ResponseWriter, op string, err error) {
 		httpx.WriteError(w, http.StatusForbidden, "forbidden")
 	case errors.Is(err, ErrInvalidState):
 		httpx.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrCancelCutoff):
+		httpx.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrFrozen):
+		httpx.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrConflict), errors.Is(err, ErrWaitlistHold):
+		httpx.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, item.ErrRentRule):
+		httpx.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrCodeRequired):
+		httpx.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrInvalidCode), errors.Is(err, ErrCodeExpired):
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrCodeAttempts), errors.Is(err, ErrCodeTooSoon):
+		httpx.WriteError(w, http.StatusTooManyRequests, err.Error())
 	default:
 		log.Println(op, "error:", err)
 		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
@@ -665,6 +1380,51 @@ func writeBookingError(w http.ResponseWriter, op string, err error) {
 }
 
 
+const maxReasonLen = 500
+
+// normalizeReason обрезает пробелы; пустая строка превращается в nil.
+func normalizeReason(reason *string) (*string, error) {
+	if reason == nil {
+		return nil, nil
+	}
+	v := strings.TrimSpace(*reason)
+	if v == "" {
+		return nil, nil
+	}
+	if len([]rune(v)) > maxReasonLen {
+		return nil, errors.New("reason is too long")
+	}
+	return &v, nil
+}
+
+// snapshotPrice — цена, залог, валюта и условия отмены на момент создания брони.
+func snapshotPrice(b *Booking, it item.Item) error {
+	var total, deposit int64
+	switch b.Type {
+	case TypeRent:
+		rules := it.PricingRules()
+		q, err := pricing.QuoteRent(rules, *b.Start, *b.End)
+		if err != nil {
+			return err
+		}
+		total, deposit = q.Total, q.Deposit
+		b.PriceRules = &rules
+	case TypeBuy:
+		total = it.Price
+	case TypeGive:
+		total = 0
+	}
+	currency := it.Currency
+	b.PriceTotal = &total
+	b.Currency = &currency
+	if b.Type == TypeRent {
+		b.DepositAmount = &deposit
+		policy := it.CancellationPolicy
+		b.CancellationPolicy = &policy
+	}
+	return nil
+}
+
 func parseLimitOffset(r *http.Request)(limit, offset int, err error){
 	q:= r.URL.Query()
 	
@@ -785,4 +1545,106 @@ func isValidStatus(s Status) bool {
 	default:
 		return false
 	}
-}
\ No newline at end of file
+}
+
+// POST /api/items/{id}/waitlist — встать в очередь на занятые даты аренды.
+func (h *Handler) JoinWaitlist(w http.ResponseWriter, r *http.Request) {
+	itemID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || itemID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid item id")
+		return
+	}
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var dto createRentRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+
+	it, err := h.items.GetByID(r.Context(), itemID)
+	if err != nil {
+		httpx.WriteError(w, http.StatusNotFound, "item not found")
+		return
+	}
+	if it.Status != item.StatusActive && it.Status != item.StatusInUse {
+		httpx.WriteError(w, http.StatusConflict, "item is not available")
+		return
+	}
+	if !it.Mode.AllowsRent() {
+		httpx.WriteError(w, http.StatusConflict, "item is not for rent")
+		return
+	}
+	if it.OwnerID == userID {
+		httpx.WriteError(w, http.StatusBadRequest, "cannot book your own item")
+		return
+	}
+
+	start, end, err := item.ParseRentRange(it.BookingGranularity, dto.Start, dto.End)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// те же правила, что и для заявки: иначе после уведомления забронировать всё равно не выйдет
+	if err := it.RentRules.Check(it.BookingGranularity, start, end, time.Now().UTC()); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	e := WaitlistEntry{ItemID: itemID, UserID: userID, Start: start, End: end}
+	if err := h.repo.JoinWaitlist(r.Context(), &e); err != nil {
+		switch {
+		case errors.Is(err, ErrNotBusy), errors.Is(err, ErrAlreadyWaiting):
+			httpx.WriteError(w, http.StatusConflict, err.Error())
+		default:
+			log.Println("join waitlist error:", err)
+			httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+	httpx.WriteJSON(w, http.StatusCreated, e)
+}
+
+// GET /api/my/waitlist — свои записи; status=notified — даты придержаны до priority_until.
+func (h *Handler) ListMyWaitlist(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	out, err := h.repo.ListMyWaitlist(r.Context(), userID)
+	if err != nil {
+		log.Println("list waitlist error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, map[string]any{"items": out})
+}
+
+// DELETE /api/my/waitlist/{id}
+func (h *Handler) LeaveWaitlist(w http.ResponseWriter, r *http.Request) {
+	entryID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || entryID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid waitlist id")
+		return
+	}
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if err := h.repo.LeaveWaitlist(r.Context(), entryID, userID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "waitlist entry not found")
+			return
+		}
+		log.Println("leave waitlist error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,6 +2,9 @@ package booking
 
 import (
 	"time"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/item"
+	"github.com/SHILOP0P/Yardly/backend/internal/pricing"
 )
 
 type Type string
@@ -47,5 +50,42 @@ type Booking struct{
 	ReturnConfirmedByOwnerAt       *time.Time `json:"return_confirmed_by_owner_at,omitempty"`
 	ReturnConfirmedByRequesterAt   *time.Time `json:"return_confirmed_by_requester_at,omitempty"`
 
+	Reschedule *RescheduleProposal `json:"reschedule,omitempty"`
+	Extension  *ExtensionRequest   `json:"extension,omitempty"`
+
+	OverdueSince *time.Time `json:"overdue_since,omitempty"` // end_at, если вещь вовремя не вернули
+	OverdueDays  int        `json:"overdue_days,omitempty"`
+
+	FrozenAt *time.Time `json:"frozen_at,omitempty"` // открыт спор — переходы по брони запрещены
+
+	// снимок цены и залога на момент создания (залог для старых броней — на момент одобрения)
+	PriceTotal    *int64  `json:"price_total,omitempty"`
+	DepositAmount *int64  `json:"deposit_amount,omitempty"`
+	Currency      *string `json:"currency,omitempty"`
+
+	// ценовые правила на момент создания (только аренда): по ним price_total пересчитывается при смене дат
+	PriceRules *pricing.Rules `json:"-"`
+
+	// условия отмены вещи на момент создания (только аренда)
+	CancellationPolicy *item.CancellationPolicy `json:"cancellation_policy,omitempty"`
+
+	DeclineReason *string `json:"decline_reason,omitempty"`
+	CancelledBy   *string `json:"cancelled_by,omitempty"` // "requester" | "owner"
+	CancelReason  *string `json:"cancel_reason,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 }
+
+// RescheduleProposal — ожидающее ответа предложение перенести даты аренды.
+type RescheduleProposal struct {
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	ProposedBy int64     `json:"proposed_by"`
+	ProposedAt time.Time `json:"proposed_at"`
+}
+
+// ExtensionRequest — запрос арендатора продлить in_use аренду до нового end.
+type ExtensionRequest struct {
+	End         time.Time `json:"end"`
+	RequestedAt time.Time `json:"requested_at"`
+}
@@ -0,0 +1,266 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+)
+
+// Тест ходит в настоящий Postgres со схемой из db/init; без TEST_DATABASE_URL пропускается.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL не задан")
+	}
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("pgxpool.New: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func insertTestUser(t *testing.T, ctx context.Context, pool *pgxpool.Pool, tag string) int64 {
+	t.Helper()
+	var id int64
+	email := fmt.Sprintf("approve-race-%s-%d@test.local", tag, time.Now().UnixNano())
+	err := pool.QueryRow(ctx, `INSERT INTO users (email, password_hash) VALUES ($1, '!') RETURNING id`, email).Scan(&id)
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	return id
+}
+
+// TestApproveRentParallelOverlapping — параллельные одобрения пересекающихся заявок одной вещи:
+// ровно одно проходит, остальные получают ErrConflict (не deadlock и не 500).
+func TestApproveRentParallelOverlapping(t *testing.T) {
+	const n = 6
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	pool := testPool(t)
+
+	ownerID := insertTestUser(t, ctx, pool, "owner")
+	var itemID int64
+	err := pool.QueryRow(ctx, `
+	INSERT INTO items (title, status, mode, owner_id)
+	VALUES ('approve race', 'active', 'rent', $1)
+	RETURNING id
+	`, ownerID).Scan(&itemID)
+	if err != nil {
+		t.Fatalf("insert item: %v", err)
+	}
+
+	requesterIDs := make([]int64, n)
+	for i := range requesterIDs {
+		requesterIDs[i] = insertTestUser(t, ctx, pool, fmt.Sprintf("req%d", i))
+	}
+	t.Cleanup(func() {
+		bg := context.Background()
+		if _, err := pool.Exec(bg, `DELETE FROM bookings WHERE item_id = $1`, itemID); err != nil {
+			t.Logf("cleanup bookings: %v", err)
+		}
+		if _, err := pool.Exec(bg, `DELETE FROM items WHERE id = $1`, itemID); err != nil {
+			t.Logf("cleanup item: %v", err)
+		}
+		ids := append([]int64{ownerID}, requesterIDs...)
+		if _, err := pool.Exec(bg, `DELETE FROM users WHERE id = ANY($1)`, ids); err != nil {
+			t.Logf("cleanup users: %v", err)
+		}
+	})
+
+	repo := New(pool, NewEventRepo(), NewDepositRepo(0), NewWaitlistRepo(0))
+
+	// все заявки пересекаются хотя бы по одному дню с каждой другой
+	base := time.Now().UTC().Truncate(24 * time.Hour).Add(10 * 24 * time.Hour)
+	bookingIDs := make([]int64, n)
+	for i := range bookingIDs {
+		start := base.Add(time.Duration(i%2) * 24 * time.Hour)
+		end := base.Add(3 * 24 * time.Hour)
+		b := booking.Booking{
+			ItemID:      itemID,
+			RequesterID: requesterIDs[i],
+			OwnerID:     ownerID,
+			Type:        booking.TypeRent,
+			Status:      booking.StatusRequested,
+			Start:       &start,
+			End:         &end,
+		}
+		if err := repo.Create(ctx, &b); err != nil {
+			t.Fatalf("create booking %d: %v", i, err)
+		}
+		bookingIDs[i] = b.ID
+	}
+
+	errs := make([]error, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, id := range bookingIDs {
+		wg.Add(1)
+		go func(i int, id int64) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = repo.ApproveRent(ctx, id, ownerID)
+		}(i, id)
+	}
+	close(start)
+	wg.Wait()
+
+	won := 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			won++
+		case errors.Is(err, booking.ErrConflict):
+		default:
+			t.Errorf("booking %d: want nil or ErrConflict, got %v", bookingIDs[i], err)
+		}
+	}
+	if won != 1 {
+		t.Fatalf("approved %d bookings, want exactly 1 (errs: %v)", won, errs)
+	}
+
+	var approved int
+	err = pool.QueryRow(ctx, `SELECT count(*) FROM bookings WHERE item_id = $1 AND status = 'approved'`, itemID).Scan(&approved)
+	if err != nil {
+		t.Fatalf("count approved: %v", err)
+	}
+	if approved != 1 {
+		t.Fatalf("approved rows in db = %d, want 1", approved)
+	}
+}
+
+// TestExclusionViolationMapsToConflict — пересекающаяся бронь одобрена в чужой транзакции без
+// блокировки вещи и ещё не закоммичена: SELECT-проверки репозитория её не видят, UPDATE ждёт её
+// и после коммита падает на bookings_rent_no_overlap (23P01), что должно стать ErrConflict.
+func TestExclusionViolationMapsToConflict(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	pool := testPool(t)
+	repo := New(pool, NewEventRepo(), NewDepositRepo(0), NewWaitlistRepo(0))
+
+	ownerID := insertTestUser(t, ctx, pool, "excl-owner")
+	userIDs := []int64{ownerID}
+	var itemID int64
+	err := pool.QueryRow(ctx, `
+	INSERT INTO items (title, status, mode, owner_id)
+	VALUES ('exclusion test', 'active', 'rent', $1)
+	RETURNING id
+	`, ownerID).Scan(&itemID)
+	if err != nil {
+		t.Fatalf("insert item: %v", err)
+	}
+	t.Cleanup(func() {
+		bg := context.Background()
+		if _, err := pool.Exec(bg, `DELETE FROM bookings WHERE item_id = $1`, itemID); err != nil {
+			t.Logf("cleanup bookings: %v", err)
+		}
+		if _, err := pool.Exec(bg, `DELETE FROM items WHERE id = $1`, itemID); err != nil {
+			t.Logf("cleanup item: %v", err)
+		}
+		if _, err := pool.Exec(bg, `DELETE FROM users WHERE id = ANY($1)`, userIDs); err != nil {
+			t.Logf("cleanup users: %v", err)
+		}
+	})
+
+	day := func(n int) time.Time {
+		return time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 40+n)
+	}
+	// create — заявка от нового арендатора: у одного арендатора одна активная аренда вещи (006)
+	create := func(t *testing.T, from, to int) (bookingID, requesterID int64) {
+		t.Helper()
+		requesterID = insertTestUser(t, ctx, pool, "excl-req")
+		userIDs = append(userIDs, requesterID)
+		s, e := day(from), day(to)
+		b := booking.Booking{
+			ItemID:      itemID,
+			RequesterID: requesterID,
+			OwnerID:     ownerID,
+			Type:        booking.TypeRent,
+			Status:      booking.StatusRequested,
+			Start:       &s,
+			End:         &e,
+		}
+		if err := repo.Create(ctx, &b); err != nil {
+			t.Fatalf("create booking: %v", err)
+		}
+		return b.ID, requesterID
+	}
+
+	// raceRawApprove — одобряет blockerID сырым UPDATE в отдельной транзакции, запускает call,
+	// дожидается, пока тот встанет в ожидание блокировки, и коммитит транзакцию.
+	raceRawApprove := func(t *testing.T, blockerID int64, call func() error) error {
+		t.Helper()
+		tx, err := pool.Begin(ctx)
+		if err != nil {
+			t.Fatalf("begin: %v", err)
+		}
+		defer tx.Rollback(ctx)
+		if _, err := tx.Exec(ctx, `UPDATE bookings SET status = 'approved' WHERE id = $1`, blockerID); err != nil {
+			t.Fatalf("raw approve: %v", err)
+		}
+
+		done := make(chan error, 1)
+		go func() { done <- call() }()
+
+		waiting := false
+		for i := 0; i < 100 && !waiting; i++ {
+			const q = `
+			SELECT EXISTS (
+				SELECT 1 FROM pg_stat_activity
+				WHERE datname = current_database() AND wait_event_type = 'Lock'
+			)`
+			if err := pool.QueryRow(ctx, q).Scan(&waiting); err != nil {
+				t.Fatalf("poll locks: %v", err)
+			}
+			if !waiting {
+				time.Sleep(50 * time.Millisecond)
+			}
+		}
+		if !waiting {
+			t.Fatal("repository call did not wait for the uncommitted overlapping booking")
+		}
+		if err := tx.Commit(ctx); err != nil {
+			t.Fatalf("commit raw approve: %v", err)
+		}
+		return <-done
+	}
+
+	t.Run("approve", func(t *testing.T) {
+		blocker, _ := create(t, 0, 3)
+		candidate, _ := create(t, 1, 4)
+		err := raceRawApprove(t, blocker, func() error {
+			_, err := repo.ApproveRent(ctx, candidate, ownerID)
+			return err
+		})
+		if !errors.Is(err, booking.ErrConflict) {
+			t.Fatalf("approve: err = %v, want ErrConflict", err)
+		}
+	})
+
+	t.Run("reschedule accept", func(t *testing.T) {
+		moved, requesterID := create(t, 10, 12)
+		if _, err := repo.ApproveRent(ctx, moved, ownerID); err != nil {
+			t.Fatalf("approve moved: %v", err)
+		}
+		if _, err := repo.ProposeReschedule(ctx, moved, requesterID, day(20), day(22), time.Now().UTC()); err != nil {
+			t.Fatalf("propose: %v", err)
+		}
+		blocker, _ := create(t, 21, 23)
+		err := raceRawApprove(t, blocker, func() error {
+			_, err := repo.AcceptReschedule(ctx, moved, ownerID)
+			return err
+		})
+		if !errors.Is(err, booking.ErrConflict) {
+			t.Fatalf("reschedule accept: err = %v, want ErrConflict", err)
+		}
+	})
+}
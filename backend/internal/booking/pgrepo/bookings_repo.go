@@ -13,17 +13,24 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/booking/fsm"
+	"github.com/SHILOP0P/Yardly/backend/internal/item"
+	"github.com/SHILOP0P/Yardly/backend/internal/pricing"
 )
 
 type Repo struct {
-	pool      *pgxpool.Pool
-	eventRepo *EventRepo
+	pool         *pgxpool.Pool
+	eventRepo    *EventRepo
+	depositRepo  *DepositRepo
+	waitlistRepo *WaitlistRepo
 }
 
-func New(pool *pgxpool.Pool, eventRepo *EventRepo) *Repo {
+func New(pool *pgxpool.Pool, eventRepo *EventRepo, depositRepo *DepositRepo, waitlistRepo *WaitlistRepo) *Repo {
 	return &Repo{
-		pool:      pool,
-		eventRepo: eventRepo,
+		pool:         pool,
+		eventRepo:    eventRepo,
+		depositRepo:  depositRepo,
+		waitlistRepo: waitlistRepo,
 	}
 }
 
@@ -36,6 +43,26 @@ const selectBookingCols = `
 	handover_confirmed_by_requester_at,
 	return_confirmed_by_owner_at,
 	return_confirmed_by_requester_at,
+	decline_reason,
+	cancelled_by,
+	cancel_reason,
+	reschedule_start_at,
+	reschedule_end_at,
+	reschedule_proposed_by,
+	reschedule_proposed_at,
+	extension_end_at,
+	extension_requested_at,
+	overdue_since,
+	overdue_days,
+	frozen_at,
+	price_total,
+	deposit_amount,
+	currency,
+	cancellation_policy,
+	price_unit,
+	price_hourly,
+	price_weekly_discount_pct,
+	price_monthly_discount_pct,
 	created_at
 `
 
@@ -44,7 +71,14 @@ type rowScanrer interface {
 }
 
 func scanBooking(rs rowScanrer, b *booking.Booking) error {
-	return rs.Scan(
+	var rsStart, rsEnd, rsAt *time.Time
+	var rsBy *int64
+	var extEnd, extAt *time.Time
+	var priceUnit *int64
+	var priceHourly *bool
+	var weeklyPct, monthlyPct *int
+
+	err := rs.Scan(
 		&b.ID,
 		&b.ItemID,
 		&b.RequesterID,
@@ -58,8 +92,81 @@ func scanBooking(rs rowScanrer, b *booking.Booking) error {
 		&b.HandoverConfirmedByRequesterAt,
 		&b.ReturnConfirmedByOwnerAt,
 		&b.ReturnConfirmedByRequesterAt,
+		&b.DeclineReason,
+		&b.CancelledBy,
+		&b.CancelReason,
+		&rsStart,
+		&rsEnd,
+		&rsBy,
+		&rsAt,
+		&extEnd,
+		&extAt,
+		&b.OverdueSince,
+		&b.OverdueDays,
+		&b.FrozenAt,
+		&b.PriceTotal,
+		&b.DepositAmount,
+		&b.Currency,
+		&b.CancellationPolicy,
+		&priceUnit,
+		&priceHourly,
+		&weeklyPct,
+		&monthlyPct,
 		&b.CreatedAt,
 	)
+	if err != nil {
+		return err
+	}
+
+	b.Reschedule = nil
+	if rsStart != nil && rsEnd != nil && rsBy != nil && rsAt != nil {
+		b.Reschedule = &booking.RescheduleProposal{
+			Start:      *rsStart,
+			End:        *rsEnd,
+			ProposedBy: *rsBy,
+			ProposedAt: *rsAt,
+		}
+	}
+
+	b.Extension = nil
+	if extEnd != nil && extAt != nil {
+		b.Extension = &booking.ExtensionRequest{End: *extEnd, RequestedAt: *extAt}
+	}
+
+	b.PriceRules = nil
+	if priceUnit != nil {
+		pr := pricing.Rules{UnitPrice: *priceUnit}
+		if priceHourly != nil {
+			pr.Hourly = *priceHourly
+		}
+		if weeklyPct != nil {
+			pr.WeeklyDiscountPct = *weeklyPct
+		}
+		if monthlyPct != nil {
+			pr.MonthlyDiscountPct = *monthlyPct
+		}
+		if b.DepositAmount != nil {
+			pr.Deposit = *b.DepositAmount
+		}
+		if b.Currency != nil {
+			pr.Currency = *b.Currency
+		}
+		b.PriceRules = &pr
+	}
+	return nil
+}
+
+// requoteRent — price_total по снимку правил брони для новых дат [start, end).
+// У броней без снимка (не аренда) сумма не меняется.
+func requoteRent(b booking.Booking, start, end time.Time) (*int64, error) {
+	if b.PriceRules == nil {
+		return b.PriceTotal, nil
+	}
+	q, err := pricing.QuoteRent(*b.PriceRules, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("bookings pgrepo: requote: %w", err)
+	}
+	return &q.Total, nil
 }
 
 func (r *Repo) Create(ctx context.Context, b *booking.Booking) error {
@@ -70,27 +177,78 @@ func (r *Repo) Create(ctx context.Context, b *booking.Booking) error {
 	}
 	defer tx.Rollback(ctx)
 
-	if b.Type == booking.TypeRent {
-		const conflictQ = `
-		SELECT 1
-		FROM bookings
-		WHERE item_id = $1
-		AND type = 'rent'
-		AND status IN ('approved','handover_pending','in_use','return_pending')
-		AND start_at < $3
-		AND end_at   > $2
-		LIMIT 1
-		`
+	if err := insertBookingTx(ctx, tx, b); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return nil
+}
+
+// CreateAndApprove — instant book: заявка создаётся и сразу одобряется в одной транзакции
+// той же логикой, что и ручное одобрение (конфликты, конкуренты, залог). Событие — auto_approve без актора.
+func (r *Repo) CreateAndApprove(ctx context.Context, b *booking.Booking, now time.Time) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-		var one int
-		err := tx.QueryRow(ctx, conflictQ, b.ItemID, *b.Start, *b.End).Scan(&one)
-		if err == nil {
+	// вещь блокируем до вставки: тот же порядок блокировок (items -> bookings), что и у ручного одобрения
+	if err := lockItemTx(ctx, tx, b.ItemID); err != nil {
+		return err
+	}
+	if err := insertBookingTx(ctx, tx, b); err != nil {
+		return err
+	}
+
+	var out booking.Booking
+	switch b.Type {
+	case booking.TypeRent:
+		out, _, err = r.approveRentTx(ctx, tx, *b, nil, "auto_approve")
+	case booking.TypeBuy, booking.TypeGive:
+		out, _, err = r.approveTransferTx(ctx, tx, *b, nil, "auto_approve", now)
+	default:
+		return booking.ErrInvalidState
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	*b = out
+	return nil
+}
+
+// CountCompletedByRequester — сколько сделок пользователь довёл до конца как арендатор/покупатель.
+func (r *Repo) CountCompletedByRequester(ctx context.Context, requesterID int64) (int, error) {
+	const q = `SELECT count(*) FROM bookings WHERE requester_id = $1 AND status = 'completed'`
+	var n int
+	if err := r.pool.QueryRow(ctx, q, requesterID).Scan(&n); err != nil {
+		return 0, fmt.Errorf("bookings pgrepo: count completed: %w", err)
+	}
+	return n, nil
+}
+
+func insertBookingTx(ctx context.Context, tx pgx.Tx, b *booking.Booking) error {
+	if b.Type == booking.TypeRent {
+		busy, err := rentConflictTx(ctx, tx, b.ItemID, *b.Start, *b.End, 0)
+		if err != nil {
+			return fmt.Errorf("create rent conflict check: %w", err)
+		}
+		if busy {
 			return booking.ErrConflict // 409
 		}
-		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("create rent conflict check: %w", err)
+		held, err := waitlistHoldTx(ctx, tx, b.ItemID, b.RequesterID, *b.Start, *b.End)
+		if err != nil {
+			return err
+		}
+		if held {
+			return booking.ErrWaitlistHold // 409
 		}
-
 	}
 
 	// created_at ставится DEFAULT now() в таблице
@@ -99,16 +257,29 @@ func (r *Repo) Create(ctx context.Context, b *booking.Booking) error {
 		item_id, requester_id, owner_id,
 		type, status,
 		start_at, end_at,
-		handover_deadline
+		handover_deadline,
+		price_total, deposit_amount, currency,
+		cancellation_policy,
+		price_unit, price_hourly, price_weekly_discount_pct, price_monthly_discount_pct
 	) VALUES (
 		$1, $2, $3,
 		$4, $5,
 		$6, $7,
-		$8
+		$8,
+		$9, $10, $11,
+		$12,
+		$13, $14, $15, $16
 	)
 	RETURNING id, created_at
 	`
-	err = tx.QueryRow(ctx, q,
+	var priceUnit *int64
+	var priceHourly *bool
+	var weeklyPct, monthlyPct *int
+	if pr := b.PriceRules; pr != nil {
+		priceUnit, priceHourly = &pr.UnitPrice, &pr.Hourly
+		weeklyPct, monthlyPct = &pr.WeeklyDiscountPct, &pr.MonthlyDiscountPct
+	}
+	err := tx.QueryRow(ctx, q,
 		b.ItemID,
 		b.RequesterID,
 		b.OwnerID,
@@ -117,6 +288,14 @@ func (r *Repo) Create(ctx context.Context, b *booking.Booking) error {
 		b.Start,
 		b.End,
 		b.HandoverDeadline,
+		b.PriceTotal,
+		b.DepositAmount,
+		b.Currency,
+		b.CancellationPolicy,
+		priceUnit,
+		priceHourly,
+		weeklyPct,
+		monthlyPct,
 	).Scan(&b.ID, &b.CreatedAt)
 
 	if err != nil {
@@ -124,10 +303,13 @@ func (r *Repo) Create(ctx context.Context, b *booking.Booking) error {
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
 			return booking.ErrDuplicateActiveRequest
 		}
+		if isExclusionViolation(err) {
+			return booking.ErrConflict
+		}
 		return err
 	}
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("bookings pgrepo: commit: %w", err)
+	if b.Type == booking.TypeRent {
+		return markWaitlistBookedTx(ctx, tx, b)
 	}
 	return nil
 }
@@ -139,22 +321,7 @@ FROM bookings
 WHERE id = $1
 `
 	var b booking.Booking
-	err := r.pool.QueryRow(ctx, q, id).Scan(
-		&b.ID,
-		&b.ItemID,
-		&b.RequesterID,
-		&b.OwnerID,
-		&b.Type,
-		&b.Status,
-		&b.Start,
-		&b.End,
-		&b.HandoverDeadline,
-		&b.HandoverConfirmedByOwnerAt,
-		&b.HandoverConfirmedByRequesterAt,
-		&b.ReturnConfirmedByOwnerAt,
-		&b.ReturnConfirmedByRequesterAt,
-		&b.CreatedAt,
-	)
+	err := scanBooking(r.pool.QueryRow(ctx, q, id), &b)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return booking.Booking{}, booking.ErrNotFound
@@ -180,22 +347,7 @@ ORDER BY id DESC
 	out := make([]booking.Booking, 0, 16)
 	for rows.Next() {
 		var b booking.Booking
-		if err := rows.Scan(
-			&b.ID,
-			&b.ItemID,
-			&b.RequesterID,
-			&b.OwnerID,
-			&b.Type,
-			&b.Status,
-			&b.Start,
-			&b.End,
-			&b.HandoverDeadline,
-			&b.HandoverConfirmedByOwnerAt,
-			&b.HandoverConfirmedByRequesterAt,
-			&b.ReturnConfirmedByOwnerAt,
-			&b.ReturnConfirmedByRequesterAt,
-			&b.CreatedAt,
-		); err != nil {
+		if err := scanBooking(rows, &b); err != nil {
 			return nil, fmt.Errorf("bookings pgrepo: list by item scan: %w", err)
 		}
 		out = append(out, b)
@@ -214,46 +366,156 @@ func (r *Repo) ApproveRent(ctx context.Context, bookingID int64, ownerID int64)
 	}
 	defer tx.Rollback(ctx)
 
-	const selectQ = `
+	b, err := lockForApproveTx(ctx, tx, bookingID)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+	if b.OwnerID != ownerID {
+		return booking.Booking{}, booking.ErrForbidden
+	}
+
+	actor := ownerID
+	b, _, err = r.approveRentTx(ctx, tx, b, &actor, "approve")
+	if err != nil {
+		return booking.Booking{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return b, nil
+
+}
+
+// ApproveWithDeclined — одобрение владельцем заявки любого типа в своей транзакции;
+// вместе с одобренной бронью возвращает авто-отклонённые конкурирующие заявки.
+func (r *Repo) ApproveWithDeclined(ctx context.Context, bookingID, ownerID int64, now time.Time) (booking.Booking, []booking.Booking, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return booking.Booking{}, nil, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	b, err := lockForApproveTx(ctx, tx, bookingID)
+	if err != nil {
+		return booking.Booking{}, nil, err
+	}
+	if b.OwnerID != ownerID {
+		return booking.Booking{}, nil, booking.ErrForbidden
+	}
+
+	actor := ownerID
+	var out booking.Booking
+	var declinedIDs []int64
+	switch b.Type {
+	case booking.TypeRent:
+		out, declinedIDs, err = r.approveRentTx(ctx, tx, b, &actor, "approve")
+	case booking.TypeBuy, booking.TypeGive:
+		out, declinedIDs, err = r.approveTransferTx(ctx, tx, b, &actor, "approve_transfer", now)
+	default:
+		return booking.Booking{}, nil, booking.ErrInvalidState
+	}
+	if err != nil {
+		return booking.Booking{}, nil, err
+	}
+
+	declined, err := listByIDsTx(ctx, tx, declinedIDs)
+	if err != nil {
+		return booking.Booking{}, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return booking.Booking{}, nil, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return out, declined, nil
+}
+
+func listByIDsTx(ctx context.Context, tx pgx.Tx, ids []int64) ([]booking.Booking, error) {
+	out := make([]booking.Booking, 0, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	const q = `
 	SELECT ` + selectBookingCols + `
 	FROM bookings
-	WHERE id = $1
-	FOR UPDATE
+	WHERE id = ANY($1)
+	ORDER BY id
 	`
-	var b booking.Booking
-	err = tx.QueryRow(ctx, selectQ, bookingID).Scan(
-		&b.ID,
-		&b.ItemID,
-		&b.RequesterID,
-		&b.OwnerID,
-		&b.Type,
-		&b.Status,
-		&b.Start,
-		&b.End,
-		&b.HandoverDeadline,
-		&b.HandoverConfirmedByOwnerAt,
-		&b.HandoverConfirmedByRequesterAt,
-		&b.ReturnConfirmedByOwnerAt,
-		&b.ReturnConfirmedByRequesterAt,
-		&b.CreatedAt,
-	)
+	rows, err := tx.Query(ctx, q, ids)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return booking.Booking{}, booking.ErrNotFound
+		return nil, fmt.Errorf("bookings pgrepo: list by ids: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b booking.Booking
+		if err := scanBooking(rows, &b); err != nil {
+			return nil, fmt.Errorf("bookings pgrepo: list by ids scan: %w", err)
 		}
-		return booking.Booking{}, fmt.Errorf("bookings pgrepo: approve select: %w", err)
+		out = append(out, b)
 	}
-	if b.OwnerID != ownerID {
-		return booking.Booking{}, booking.ErrForbidden
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("bookings pgrepo: list by ids rows: %w", err)
 	}
-	if b.Type != booking.TypeRent {
-		return booking.Booking{}, booking.ErrInvalidState
+	return out, nil
+}
+
+// approveAction/approveRole — ручное одобрение владельцем или автоматическое (actor = nil).
+func approveAction(actor *int64) fsm.Action {
+	if actor == nil {
+		return fsm.ActionAutoApprove
 	}
-	if b.Status != booking.StatusRequested {
-		return booking.Booking{}, booking.ErrInvalidState
+	return fsm.ActionApprove
+}
+
+func approveRole(actor *int64) fsm.Role {
+	if actor == nil {
+		return fsm.RoleSystem
+	}
+	return fsm.RoleOwner
+}
+
+// approveRentTx — requested -> approved для аренды: правила, конфликты, авто-отклонение конкурентов и события.
+// actor = nil для автоматического одобрения (instant book). Возвращает id авто-отклонённых заявок.
+func (r *Repo) approveRentTx(ctx context.Context, tx pgx.Tx, b booking.Booking, actor *int64, action string) (booking.Booking, []int64, error) {
+	if b.Type != booking.TypeRent {
+		return booking.Booking{}, nil, booking.ErrInvalidState
 	}
 	if b.Start == nil || b.End == nil {
-		return booking.Booking{}, fmt.Errorf("rent booking must have start/end")
+		return booking.Booking{}, nil, fmt.Errorf("rent booking must have start/end")
+	}
+	// конфликт дат проверяем раньше fsm: заявку, которую уже авто-отклонило параллельное
+	// одобрение пересекающейся брони, проигравший должен увидеть как ErrConflict, а не ErrInvalidState
+	if b.Status == booking.StatusRequested || b.Status == booking.StatusDeclined {
+		busy, err := rentConflictTx(ctx, tx, b.ItemID, *b.Start, *b.End, b.ID)
+		if err != nil {
+			return booking.Booking{}, nil, fmt.Errorf("bookings pgrepo: approve conflict check: %w", err)
+		}
+		if busy {
+			return booking.Booking{}, nil, booking.ErrConflict
+		}
+		// заявка могла быть создана до того, как даты придержали за ожидающим;
+		// собственную бронь держателя не блокируем (waitlistHoldTx исключает requester)
+		held, err := waitlistHoldTx(ctx, tx, b.ItemID, b.RequesterID, *b.Start, *b.End)
+		if err != nil {
+			return booking.Booking{}, nil, fmt.Errorf("bookings pgrepo: approve: %w", err)
+		}
+		if held {
+			return booking.Booking{}, nil, booking.ErrWaitlistHold
+		}
+	}
+	t, err := booking.CheckAction(b, approveAction(actor), approveRole(actor), b.Facts(time.Now().UTC(), 0))
+	if err != nil {
+		return booking.Booking{}, nil, err
+	}
+
+	// правила могли поменяться после создания заявки; уведомление/горизонт проверяются только при создании
+	rules, granularity, err := itemRentRulesTx(ctx, tx, b.ItemID)
+	if err != nil {
+		return booking.Booking{}, nil, err
+	}
+	if err := rules.CheckLength(granularity, *b.Start, *b.End); err != nil {
+		return booking.Booking{}, nil, err
 	}
 
 	dedline := b.Start.Add(24 * time.Hour)
@@ -275,11 +537,11 @@ WHERE item_id = $2
 		booking.TypeRent,
 		booking.StatusRequested,
 		b.ID,
-		*b.End,
-		*b.Start,
+		b.End.Add(rules.Gap()),
+		b.Start.Add(-rules.Gap()),
 	)
 	if err != nil {
-		return booking.Booking{}, fmt.Errorf("bookings pgrepo: decline competitors: %w", err)
+		return booking.Booking{}, nil, fmt.Errorf("bookings pgrepo: decline competitors: %w", err)
 	}
 	defer rows.Close()
 
@@ -287,36 +549,44 @@ WHERE item_id = $2
 	for rows.Next() {
 		var id int64
 		if err := rows.Scan(&id); err != nil {
-			return booking.Booking{}, fmt.Errorf("bookings pgrepo: decline competitors scan: %w", err)
+			return booking.Booking{}, nil, fmt.Errorf("bookings pgrepo: decline competitors scan: %w", err)
 		}
 		declinedIDs = append(declinedIDs, id)
 	}
 	if err := rows.Err(); err != nil {
-		return booking.Booking{}, fmt.Errorf("bookings pgrepo: decline competitors rows: %w", err)
+		return booking.Booking{}, nil, fmt.Errorf("bookings pgrepo: decline competitors rows: %w", err)
 	}
 
+	// залог берём из снимка при создании; у броней без снимка — фиксируем на момент одобрения
 	const approveQ = `
 	UPDATE bookings
 	SET status = $1,
-		handover_deadline = $2
+		handover_deadline = $2,
+		deposit_amount = COALESCE(deposit_amount, (SELECT deposit FROM items WHERE items.id = bookings.item_id))
 	WHERE id = $3
-	RETURNING status, handover_deadline
+	RETURNING status, handover_deadline, deposit_amount
 	`
-	err = tx.QueryRow(ctx, approveQ, booking.StatusApproved, dedline, b.ID).Scan(&b.Status, &b.HandoverDeadline)
+	err = tx.QueryRow(ctx, approveQ, booking.StatusApproved, dedline, b.ID).Scan(&b.Status, &b.HandoverDeadline, &b.DepositAmount)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			// теоретически редкий кейс, но пусть будет
-			return booking.Booking{}, booking.ErrNotFound
+			return booking.Booking{}, nil, booking.ErrNotFound
 		}
-		return booking.Booking{}, err
+		// параллельное одобрение пересекающейся брони — ловим exclusion constraint
+		if isExclusionViolation(err) {
+			return booking.Booking{}, nil, booking.ErrConflict
+		}
+		return booking.Booking{}, nil, err
+	}
+	if err := booking.CheckResult(t, b.Status); err != nil {
+		return booking.Booking{}, nil, err
 	}
 
-	actor := ownerID
 	fromApproved := booking.StatusRequested
 	toApproved := booking.StatusApproved
 
-	if err := r.eventRepo.InsertBookingEvent(ctx, tx, b.ID, &actor, "approve", &fromApproved, &toApproved, nil); err != nil {
-		return booking.Booking{}, err
+	if err := r.eventRepo.InsertBookingEvent(ctx, tx, b.ID, actor, action, &fromApproved, &toApproved, nil); err != nil {
+		return booking.Booking{}, nil, err
 	}
 
 	fromDecl := booking.StatusRequested
@@ -324,7 +594,116 @@ WHERE item_id = $2
 
 	for _, id := range declinedIDs {
 		// meta можно не делать, но полезно
-		if err := r.eventRepo.InsertBookingEvent(ctx, tx, id, &actor, "auto_decline_competitor", &fromDecl, &toDecl, nil); err != nil {
+		if err := r.eventRepo.InsertBookingEvent(ctx, tx, id, actor, "auto_decline_competitor", &fromDecl, &toDecl, nil); err != nil {
+			return booking.Booking{}, nil, err
+		}
+	}
+
+	return b, declinedIDs, nil
+}
+
+// lockForApproveTx — блокировки перед одобрением: сначала строка вещи, затем бронь.
+// Одобрение авто-отклоняет конкурентов (UPDATE чужих броней), поэтому без общей блокировки
+// вещи два параллельных одобрения держат каждое свою бронь и ждут чужую — deadlock.
+// С items FOR UPDATE одобрения одной вещи идут строго по очереди.
+func lockForApproveTx(ctx context.Context, tx pgx.Tx, bookingID int64) (booking.Booking, error) {
+	const q = `
+	SELECT i.id
+	FROM bookings b
+	JOIN items i ON i.id = b.item_id
+	WHERE b.id = $1
+	FOR UPDATE OF i
+	`
+	var itemID int64
+	if err := tx.QueryRow(ctx, q, bookingID).Scan(&itemID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return booking.Booking{}, booking.ErrNotFound
+		}
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: lock item for approve: %w", err)
+	}
+	return lockBookingTx(ctx, tx, bookingID)
+}
+
+// lockItemTx — items FOR UPDATE (сериализация одобрений и блокировок одной вещи).
+func lockItemTx(ctx context.Context, tx pgx.Tx, itemID int64) error {
+	const q = `SELECT 1 FROM items WHERE id = $1 FOR UPDATE`
+	var one int
+	if err := tx.QueryRow(ctx, q, itemID).Scan(&one); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return booking.ErrNotFound
+		}
+		return fmt.Errorf("bookings pgrepo: lock item: %w", err)
+	}
+	return nil
+}
+
+// lockBookingTx — бронь FOR UPDATE перед проверкой перехода по fsm.
+func lockBookingTx(ctx context.Context, tx pgx.Tx, bookingID int64) (booking.Booking, error) {
+	const q = `
+	SELECT ` + selectBookingCols + `
+	FROM bookings
+	WHERE id = $1
+	FOR UPDATE
+	`
+	var b booking.Booking
+	if err := scanBooking(tx.QueryRow(ctx, q, bookingID), &b); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return booking.Booking{}, booking.ErrNotFound
+		}
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: lock booking: %w", err)
+	}
+	return b, nil
+}
+
+// DeclineRequest — владелец явно отклоняет заявку в статусе requested (любой тип).
+func (r *Repo) DeclineRequest(ctx context.Context, bookingID int64, ownerID int64, reason *string) (booking.Booking, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	b, err := lockBookingTx(ctx, tx, bookingID)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+	if b.OwnerID != ownerID {
+		return booking.Booking{}, booking.ErrForbidden
+	}
+	now := time.Now().UTC()
+	t, err := booking.CheckAction(b, fsm.ActionDecline, fsm.RoleOwner, b.Facts(now, 0))
+	if err != nil {
+		return booking.Booking{}, err
+	}
+
+	const q = `
+	UPDATE bookings
+	SET status = $2,
+	    decline_reason = $3
+	WHERE id = $1
+	RETURNING ` + selectBookingCols + `
+	`
+	var out booking.Booking
+	if err := scanBooking(tx.QueryRow(ctx, q, b.ID, booking.StatusDeclined, reason), &out); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: decline update: %w", err)
+	}
+	if err := booking.CheckResult(t, out.Status); err != nil {
+		return booking.Booking{}, err
+	}
+
+	var meta []byte
+	if reason != nil {
+		meta, _ = json.Marshal(map[string]string{"reason": *reason})
+	}
+
+	actor := ownerID
+	from := b.Status
+	to := out.Status
+	if err := r.eventRepo.InsertBookingEvent(ctx, tx, out.ID, &actor, "decline", &from, &to, meta); err != nil {
+		return booking.Booking{}, err
+	}
+	if out.Type == booking.TypeRent {
+		if _, err := r.waitlistRepo.promoteTx(ctx, tx, out.ItemID, now); err != nil {
 			return booking.Booking{}, err
 		}
 	}
@@ -332,8 +711,7 @@ WHERE item_id = $2
 	if err := tx.Commit(ctx); err != nil {
 		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
 	}
-	return b, nil
-
+	return out, nil
 }
 
 func (r *Repo) ReturnRent(ctx context.Context, bookingID int64, actorID int64, now time.Time) (booking.Booking, error) {
@@ -366,13 +744,93 @@ func (r *Repo) ReturnRent(ctx context.Context, bookingID int64, actorID int64, n
 	if actorID == b.OwnerID && actorID == b.RequesterID {
 		return booking.Booking{}, booking.ErrInvalidState
 	}
-	if b.Status != booking.StatusInUse && b.Status != booking.StatusReturnPending {
+	required, err := itemRequiresCodeTx(ctx, tx, b.ItemID)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+	f := b.Facts(now, 0)
+	f.CodeRequired = required
+	t, err := booking.CheckAction(b, fsm.ActionReturn, b.RoleOf(actorID), f)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+
+	by := "requester"
+	if actorID == b.OwnerID {
+		by = "owner"
+	}
+	out, err := r.confirmReturnTx(ctx, tx, b, t, actorID, actorID == b.OwnerID, actorID == b.RequesterID, by, now)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return out, nil
+}
+
+
+func (r *Repo) HandoverRent(ctx context.Context, bookingID int64, actorID int64, now time.Time) (booking.Booking, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const selectQ = `
+		SELECT ` + selectBookingCols + `
+		FROM bookings
+		WHERE id = $1
+		FOR UPDATE
+		`
+	var b booking.Booking
+	if err := scanBooking(tx.QueryRow(ctx, selectQ, bookingID), &b); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return booking.Booking{}, booking.ErrNotFound
+		}
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: handover rent select: %w", err)
+	}
+
+	if b.Type != booking.TypeRent {
+		return booking.Booking{}, booking.ErrInvalidState
+	}
+	if actorID != b.OwnerID && actorID != b.RequesterID {
+		return booking.Booking{}, booking.ErrForbidden
+	}
+	if actorID == b.OwnerID && actorID == b.RequesterID {
 		return booking.Booking{}, booking.ErrInvalidState
 	}
+	required, err := itemRequiresCodeTx(ctx, tx, b.ItemID)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+	f := b.Facts(now, 0)
+	f.CodeRequired = required
+	t, err := booking.CheckAction(b, fsm.ActionHandover, b.RoleOf(actorID), f)
+	if err != nil {
+		return booking.Booking{}, err
+	}
 
-	setOwner := actorID == b.OwnerID
-	setRequester := actorID == b.RequesterID
+	by := "requester"
+	if actorID == b.OwnerID {
+		by = "owner"
+	}
+	out, err := r.confirmHandoverTx(ctx, tx, b, t, actorID, actorID == b.OwnerID, actorID == b.RequesterID, by, now)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return out, nil
+}
 
+
+// confirmReturnTx — отмечает возврат сторонами setOwner/setRequester (код подтверждает обе сразу);
+// когда отмечены обе — аренда завершается, вещь освобождается, остаток залога возвращается.
+func (r *Repo) confirmReturnTx(ctx context.Context, tx pgx.Tx, b booking.Booking, t fsm.Transition, actorID int64, setOwner, setRequester bool, by string, now time.Time) (booking.Booking, error) {
 	const updQ = `
 UPDATE bookings
 SET
@@ -401,17 +859,14 @@ RETURNING ` + selectBookingCols + `
 	if err := scanBooking(tx.QueryRow(ctx, updQ, b.ID, setOwner, setRequester, now), &out); err != nil {
 		return booking.Booking{}, fmt.Errorf("bookings pgrepo: return rent update: %w", err)
 	}
-
-	didConfirm := false
-	by := ""
-	if setOwner {
-		by = "owner"
-		didConfirm = (b.ReturnConfirmedByOwnerAt == nil && out.ReturnConfirmedByOwnerAt != nil)
-	} else {
-		by = "requester"
-		didConfirm = (b.ReturnConfirmedByRequesterAt == nil && out.ReturnConfirmedByRequesterAt != nil)
+	if err := booking.CheckResult(t, out.Status); err != nil {
+		return booking.Booking{}, err
 	}
 
+	// идемпотентность: если сторона уже подтверждала раньше — не пишем event и не меняем ничего дополнительно
+	didConfirm := (setOwner && b.ReturnConfirmedByOwnerAt == nil && out.ReturnConfirmedByOwnerAt != nil) ||
+		(setRequester && b.ReturnConfirmedByRequesterAt == nil && out.ReturnConfirmedByRequesterAt != nil)
+
 	if didConfirm {
 		actor := actorID
 		meta, _ := json.Marshal(map[string]string{"by": by})
@@ -428,61 +883,23 @@ RETURNING ` + selectBookingCols + `
 			}
 		}
 
-		// после завершения аренды — синхронизируем item статус обратно
+		// после завершения аренды — синхронизируем item статус обратно; остаток залога вернётся
+		// по окончании окна споров, чтобы спор о повреждениях ещё мог его удержать
 		if out.Status == booking.StatusCompleted {
 			if err := syncItemStatusTx(ctx, tx, out.ItemID); err != nil {
 				return booking.Booking{}, err
 			}
+			if err := r.depositRepo.ScheduleReleaseTx(ctx, tx, out.ID, &actor, now); err != nil {
+				return booking.Booking{}, err
+			}
 		}
 	}
-
-	if err := tx.Commit(ctx); err != nil {
-		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
-	}
 	return out, nil
 }
 
-
-func (r *Repo) HandoverRent(ctx context.Context, bookingID int64, actorID int64, now time.Time) (booking.Booking, error) {
-	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
-	if err != nil {
-		return booking.Booking{}, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
-	}
-	defer tx.Rollback(ctx)
-
-	const selectQ = `
-		SELECT ` + selectBookingCols + `
-		FROM bookings
-		WHERE id = $1
-		FOR UPDATE
-		`
-	var b booking.Booking
-	if err := scanBooking(tx.QueryRow(ctx, selectQ, bookingID), &b); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return booking.Booking{}, booking.ErrNotFound
-		}
-		return booking.Booking{}, fmt.Errorf("bookings pgrepo: handover rent select: %w", err)
-	}
-
-	if b.Type != booking.TypeRent {
-		return booking.Booking{}, booking.ErrInvalidState
-	}
-	if actorID != b.OwnerID && actorID != b.RequesterID {
-		return booking.Booking{}, booking.ErrForbidden
-	}
-	if actorID == b.OwnerID && actorID == b.RequesterID {
-		return booking.Booking{}, booking.ErrInvalidState
-	}
-	if b.Status != booking.StatusApproved && b.Status != booking.StatusHandoverPending {
-		return booking.Booking{}, booking.ErrInvalidState
-	}
-	if b.HandoverDeadline == nil || now.After(*b.HandoverDeadline) {
-		return booking.Booking{}, booking.ErrInvalidState
-	}
-
-	setOwner := actorID == b.OwnerID
-	setRequester := actorID == b.RequesterID
-
+// confirmHandoverTx — отмечает передачу сторонами setOwner/setRequester (код подтверждает обе сразу);
+// когда отмечены обе — аренда in_use и залог удерживается.
+func (r *Repo) confirmHandoverTx(ctx context.Context, tx pgx.Tx, b booking.Booking, t fsm.Transition, actorID int64, setOwner, setRequester bool, by string, now time.Time) (booking.Booking, error) {
 	const updQ = `
 		UPDATE bookings
 		SET
@@ -511,18 +928,14 @@ func (r *Repo) HandoverRent(ctx context.Context, bookingID int64, actorID int64,
 	if err := scanBooking(tx.QueryRow(ctx, updQ, b.ID, setOwner, setRequester, now), &out); err != nil {
 		return booking.Booking{}, fmt.Errorf("bookings pgrepo: handover rent update: %w", err)
 	}
-
-	// идемпотентность: если актор уже подтверждал раньше — не пишем event и не меняем ничего дополнительно
-	didConfirm := false
-	by := ""
-	if setOwner {
-		by = "owner"
-		didConfirm = (b.HandoverConfirmedByOwnerAt == nil && out.HandoverConfirmedByOwnerAt != nil)
-	} else {
-		by = "requester"
-		didConfirm = (b.HandoverConfirmedByRequesterAt == nil && out.HandoverConfirmedByRequesterAt != nil)
+	if err := booking.CheckResult(t, out.Status); err != nil {
+		return booking.Booking{}, err
 	}
 
+	// идемпотентность: если сторона уже подтверждала раньше — не пишем event и не меняем ничего дополнительно
+	didConfirm := (setOwner && b.HandoverConfirmedByOwnerAt == nil && out.HandoverConfirmedByOwnerAt != nil) ||
+		(setRequester && b.HandoverConfirmedByRequesterAt == nil && out.HandoverConfirmedByRequesterAt != nil)
+
 	if didConfirm {
 		actor := actorID
 		meta, _ := json.Marshal(map[string]string{"by": by})
@@ -540,65 +953,161 @@ func (r *Repo) HandoverRent(ctx context.Context, bookingID int64, actorID int64,
 				return booking.Booking{}, err
 			}
 		}
-
-		// синхронизируем item только если дошли до in_use
-		if out.Status == booking.StatusInUse {
-			if err := syncItemStatusTx(ctx, tx, out.ItemID); err != nil {
-				return booking.Booking{}, err
-			}
+
+		// синхронизируем item только если дошли до in_use; тогда же удерживаем залог
+		if out.Status == booking.StatusInUse {
+			if err := syncItemStatusTx(ctx, tx, out.ItemID); err != nil {
+				return booking.Booking{}, err
+			}
+			if err := r.depositRepo.HoldTx(ctx, tx, out.ID, out.DepositAmount, &actor); err != nil {
+				return booking.Booking{}, err
+			}
+		}
+	}
+	return out, nil
+}
+
+
+// ExpireOverdueHandovers — approved -> expired (fsm.ActionExpire от fsm.RoleSystem) для броней с прошедшим дедлайном.
+// Кандидаты отбираются запросом, но каждый переход проверяется по таблице: замороженные спором пропускаются.
+func (r *Repo) ExpireOverdueHandovers(ctx context.Context, now time.Time) (int64, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// SKIP LOCKED — брони, которые прямо сейчас подтверждают, разберём следующим проходом
+	const selQ = `
+	SELECT ` + selectBookingCols + `
+	FROM bookings
+	WHERE status = $1
+	  AND handover_deadline IS NOT NULL
+	  AND handover_deadline < $2
+	ORDER BY id
+	FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(ctx, selQ, booking.StatusApproved, now)
+	if err != nil {
+		return 0, fmt.Errorf("bookings pgrepo: expire select: %w", err)
+	}
+	candidates := make([]booking.Booking, 0, 16)
+	for rows.Next() {
+		var b booking.Booking
+		if err := scanBooking(rows, &b); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("bookings pgrepo: expire scan: %w", err)
+		}
+		candidates = append(candidates, b)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("bookings pgrepo: expire rows: %w", err)
+	}
+	rows.Close()
+
+	const updQ = `
+	UPDATE bookings
+	SET status = $2
+	WHERE id = $1
+	RETURNING status
+	`
+	var expired int64
+	rentItems := make(map[int64]struct{}, 16)
+	for _, b := range candidates {
+		t, err := booking.CheckAction(b, fsm.ActionExpire, fsm.RoleSystem, b.Facts(now, 0))
+		if err != nil {
+			continue
+		}
+		var to booking.Status
+		if err := tx.QueryRow(ctx, updQ, b.ID, booking.StatusExpired).Scan(&to); err != nil {
+			return 0, fmt.Errorf("bookings pgrepo: expire update: %w", err)
+		}
+		if err := booking.CheckResult(t, to); err != nil {
+			return 0, err
+		}
+		from := b.Status
+		if err := r.eventRepo.InsertBookingEvent(ctx, tx, b.ID, nil, "expire", &from, &to, nil); err != nil {
+			return 0, err
+		}
+		if b.Type == booking.TypeRent {
+			rentItems[b.ItemID] = struct{}{}
+		}
+		expired++
+	}
+	// освободившиеся даты — ожидающим в листе
+	for itemID := range rentItems {
+		if _, err := r.waitlistRepo.promoteTx(ctx, tx, itemID, now); err != nil {
+			return 0, err
 		}
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
+		return 0, fmt.Errorf("bookings pgrepo: commit: %w", err)
 	}
-	return out, nil
+	return expired, nil
 }
 
-
-func (r *Repo) ExpireOverdueHandovers(ctx context.Context, now time.Time) (int64, error) {
+// MarkOverdueReturns — аренды, не возвращённые к end_at: помечает просрочку (событие overdue)
+// и пересчитывает накопленные дни просрочки у уже помеченных.
+func (r *Repo) MarkOverdueReturns(ctx context.Context, now time.Time) (int64, error) {
 	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return 0, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
 	}
 	defer tx.Rollback(ctx)
-	types := []string{string(booking.TypeRent), string(booking.TypeBuy), string(booking.TypeGive)}
 
-	q := `
+	// неполный день просрочки считается за целый; GREATEST — на случай, если end_at сдвинули вперёд
+	const markQ = `
 	UPDATE bookings
-	SET status = $1
-	WHERE type = ANY($2::text[])
-	  AND status = $3
-	  AND handover_deadline IS NOT NULL
-	  AND handover_deadline < $4
-	RETURNING id
+	SET overdue_since = end_at,
+	    overdue_days = GREATEST(0, CEIL(EXTRACT(EPOCH FROM ($1::timestamptz - end_at)) / 86400)::int)
+	WHERE type = 'rent'
+	  AND status IN ('in_use','return_pending')
+	  AND end_at IS NOT NULL
+	  AND end_at < $1
+	  AND overdue_since IS NULL
+	RETURNING id, overdue_days
 	`
-
-	rows, err := tx.Query(ctx, q, booking.StatusExpired, types, booking.StatusApproved, now)
+	rows, err := tx.Query(ctx, markQ, now)
 	if err != nil {
-		return 0, fmt.Errorf("bookings pgrepo: expire query: %w", err)
+		return 0, fmt.Errorf("bookings pgrepo: overdue mark: %w", err)
 	}
 
-	ids := make([]int64, 0, 16)
+	type marked struct {
+		id   int64
+		days int
+	}
+	newly := make([]marked, 0, 16)
 	for rows.Next() {
-		var id int64
-		if err := rows.Scan(&id); err != nil {
+		var m marked
+		if err := rows.Scan(&m.id, &m.days); err != nil {
 			rows.Close()
-			return 0, fmt.Errorf("bookings pgrepo: expire scan: %w", err)
+			return 0, fmt.Errorf("bookings pgrepo: overdue scan: %w", err)
 		}
-		ids = append(ids, id)
+		newly = append(newly, m)
 	}
 	if err := rows.Err(); err != nil {
 		rows.Close()
-		return 0, fmt.Errorf("bookings pgrepo: expire rows: %w", err)
+		return 0, fmt.Errorf("bookings pgrepo: overdue rows: %w", err)
 	}
 	rows.Close()
 
-	from := booking.StatusApproved
-	to := booking.StatusExpired
+	const accrueQ = `
+	UPDATE bookings
+	SET overdue_days = GREATEST(0, CEIL(EXTRACT(EPOCH FROM ($1::timestamptz - end_at)) / 86400)::int)
+	WHERE type = 'rent'
+	  AND status IN ('in_use','return_pending')
+	  AND overdue_since IS NOT NULL
+	  AND overdue_days <> GREATEST(0, CEIL(EXTRACT(EPOCH FROM ($1::timestamptz - end_at)) / 86400)::int)
+	`
+	if _, err := tx.Exec(ctx, accrueQ, now); err != nil {
+		return 0, fmt.Errorf("bookings pgrepo: overdue accrue: %w", err)
+	}
 
-	for _, id := range ids {
-		if err := r.eventRepo.InsertBookingEvent(ctx, tx, id, nil, "expire", &from, &to, nil); err != nil {
+	for _, m := range newly {
+		meta, _ := json.Marshal(map[string]int{"overdue_days": m.days})
+		if err := r.eventRepo.InsertBookingEvent(ctx, tx, m.id, nil, "overdue", nil, nil, meta); err != nil {
 			return 0, err
 		}
 	}
@@ -606,40 +1115,148 @@ func (r *Repo) ExpireOverdueHandovers(ctx context.Context, now time.Time) (int64
 	if err := tx.Commit(ctx); err != nil {
 		return 0, fmt.Errorf("bookings pgrepo: commit: %w", err)
 	}
-	return int64(len(ids)), nil
+	return int64(len(newly)), nil
 }
 
+// CancelRent — арендатор отзывает заявку или отменяет аренду до передачи;
+// возврат по политике отмены считается на момент отмены и пишется в meta события.
 func (r *Repo) CancelRent(ctx context.Context, bookingID, requesterID int64) (booking.Booking, error) {
 	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return booking.Booking{}, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
 	}
 	defer tx.Rollback(ctx)
-	const q = `
+
+	const selectQ = `
+	SELECT ` + selectBookingCols + `
+	FROM bookings
+	WHERE id = $1
+	FOR UPDATE
+	`
+	var b booking.Booking
+	if err := scanBooking(tx.QueryRow(ctx, selectQ, bookingID), &b); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return booking.Booking{}, booking.ErrNotFound
+		}
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: cancel select: %w", err)
+	}
+	if b.RequesterID != requesterID {
+		return booking.Booking{}, booking.ErrForbidden
+	}
+	if b.Type != booking.TypeRent {
+		return booking.Booking{}, booking.ErrInvalidState
+	}
+
+	now := time.Now().UTC()
+	t, err := booking.CheckAction(b, fsm.ActionCancel, fsm.RoleRequester, b.Facts(now, 0))
+	if err != nil {
+		return booking.Booking{}, err
+	}
+	outcome := booking.ComputeCancelOutcome(b, "requester", now)
+
+	const updQ = `
 	UPDATE bookings
-	SET status = $3
-	WHERE id = $1 AND requester_id = $2 AND status = $4 AND type = $5
+	SET status = $2,
+	    cancelled_by = 'requester'
+	WHERE id = $1
 	RETURNING ` + selectBookingCols + `
 	`
 	var out booking.Booking
-	err = scanBooking(tx.QueryRow(ctx, q,
-		bookingID,
-		requesterID,
-		booking.StatusCanceled,
-		booking.StatusRequested,
-		booking.TypeRent,
-	), &out)
+	if err := scanBooking(tx.QueryRow(ctx, updQ, b.ID, booking.StatusCanceled), &out); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: cancel update: %w", err)
+	}
+	if err := booking.CheckResult(t, out.Status); err != nil {
+		return booking.Booking{}, err
+	}
+
+	actor := requesterID
+	from := b.Status
+	to := out.Status
+	meta, _ := json.Marshal(map[string]any{"by": "requester", "refund": outcome})
+	if err := r.eventRepo.InsertBookingEvent(ctx, tx, out.ID, &actor, "cancel", &from, &to, meta); err != nil {
+		return booking.Booking{}, err
+	}
+	if _, err := r.waitlistRepo.promoteTx(ctx, tx, out.ItemID, now); err != nil {
+		return booking.Booking{}, err
+	}
+	if from != booking.StatusRequested {
+		if err := syncItemStatusTx(ctx, tx, out.ItemID); err != nil {
+			return booking.Booking{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return out, nil
+}
+
+// CancelByOwner — отмена владельцем уже одобренной брони (approved / handover_pending).
+// Для аренды действует cutoff: отменить можно не позже чем за cutoff до start_at.
+func (r *Repo) CancelByOwner(ctx context.Context, bookingID, ownerID int64, reason string, now time.Time, cutoff time.Duration) (booking.Booking, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const selectQ = `
+	SELECT ` + selectBookingCols + `
+	FROM bookings
+	WHERE id = $1
+	FOR UPDATE
+	`
+	var b booking.Booking
+	if err := scanBooking(tx.QueryRow(ctx, selectQ, bookingID), &b); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return booking.Booking{}, r.explainCancelNoRows(ctx, bookingID, requesterID)
+			return booking.Booking{}, booking.ErrNotFound
 		}
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: owner cancel select: %w", err)
+	}
+
+	if b.OwnerID != ownerID {
+		return booking.Booking{}, booking.ErrForbidden
+	}
+	// для аренды guard проверяет cutoff до start_at, для buy/give его нет
+	t, err := booking.CheckAction(b, fsm.ActionOwnerCancel, fsm.RoleOwner, b.Facts(now, cutoff))
+	if err != nil {
 		return booking.Booking{}, err
 	}
-	actor := requesterID
-	from := booking.StatusRequested
-	to := booking.StatusCanceled
 
-	if err := r.eventRepo.InsertBookingEvent(ctx, tx, out.ID, &actor, "cancel", &from, &to, nil); err != nil {
+	const updQ = `
+	UPDATE bookings
+	SET status = $2,
+	    cancelled_by = 'owner',
+	    cancel_reason = $3
+	WHERE id = $1
+	RETURNING ` + selectBookingCols + `
+	`
+	var out booking.Booking
+	if err := scanBooking(tx.QueryRow(ctx, updQ, b.ID, booking.StatusCanceled, reason), &out); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: owner cancel update: %w", err)
+	}
+	if err := booking.CheckResult(t, out.Status); err != nil {
+		return booking.Booking{}, err
+	}
+
+	actor := ownerID
+	from := b.Status
+	to := out.Status
+	meta, _ := json.Marshal(map[string]any{
+		"by":     "owner",
+		"reason": reason,
+		"refund": booking.ComputeCancelOutcome(b, "owner", now),
+	})
+	if err := r.eventRepo.InsertBookingEvent(ctx, tx, out.ID, &actor, "owner_cancel", &from, &to, meta); err != nil {
+		return booking.Booking{}, err
+	}
+	if out.Type == booking.TypeRent {
+		if _, err := r.waitlistRepo.promoteTx(ctx, tx, out.ItemID, now); err != nil {
+			return booking.Booking{}, err
+		}
+	}
+
+	if err := syncItemStatusTx(ctx, tx, out.ItemID); err != nil {
 		return booking.Booking{}, err
 	}
 
@@ -704,7 +1321,7 @@ func (r *Repo) ListMyBookings(ctx context.Context, requesterID int64, statuses [
 	return out, nil
 }
 
-func (r *Repo) ListMyItemsBookings(ctx context.Context, ownerID int64, statuses []booking.Status, limit, offset int) ([]booking.Booking, error) {
+func (r *Repo) ListMyItemsBookings(ctx context.Context, ownerID int64, statuses []booking.Status, overdueOnly bool, limit, offset int) ([]booking.Booking, error) {
 	if limit <= 0 {
 		limit = 20
 	}
@@ -719,26 +1336,28 @@ func (r *Repo) ListMyItemsBookings(ctx context.Context, ownerID int64, statuses
 		st = append(st, string(s))
 	}
 
+	// $2 — только текущие просрочки (вещь всё ещё не возвращена)
 	const base = `
 	SELECT ` + selectBookingCols + `
 	FROM bookings
 	WHERE owner_id = $1
+	  AND ($2::bool = false OR (overdue_since IS NOT NULL AND status IN ('in_use','return_pending')))
 	`
 	var rows pgx.Rows
 	var err error
 
 	if len(st) == 0 {
 		const q = base + `ORDER BY created_at DESC, id DESC
-		LIMIT $2 OFFSET $3
+		LIMIT $3 OFFSET $4
 		`
-		rows, err = r.pool.Query(ctx, q, ownerID, limit, offset)
+		rows, err = r.pool.Query(ctx, q, ownerID, overdueOnly, limit, offset)
 	} else {
 		const q = base + `
-			AND status = ANY($2::text[])
+			AND status = ANY($3::text[])
 			ORDER BY created_at DESC, id DESC
-			LIMIT $3 OFFSET $4
+			LIMIT $4 OFFSET $5
 			`
-		rows, err = r.pool.Query(ctx, q, ownerID, st, limit, offset)
+		rows, err = r.pool.Query(ctx, q, ownerID, overdueOnly, st, limit, offset)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("bookings pgrepo: list my bookings: %w", err)
@@ -768,32 +1387,39 @@ func (r *Repo) ApproveTransfer(ctx context.Context, bookingID int64, ownerID int
 	}
 	defer tx.Rollback(ctx)
 
-	const selectQ = `
-	SELECT ` + selectBookingCols + `
-	FROM bookings
-	WHERE id = $1
-	FOR UPDATE
-	`
-	var b booking.Booking
-	if err := scanBooking(tx.QueryRow(ctx, selectQ, bookingID), &b); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return booking.Booking{}, booking.ErrNotFound
-		}
-		return booking.Booking{}, fmt.Errorf("bookings pgrepo: approve transfer select: %w", err)
+	b, err := lockForApproveTx(ctx, tx, bookingID)
+	if err != nil {
+		return booking.Booking{}, err
 	}
 
 	if b.OwnerID != ownerID {
 		return booking.Booking{}, booking.ErrForbidden
 	}
+
+	actor := ownerID
+	out, _, err := r.approveTransferTx(ctx, tx, b, &actor, "approve_transfer", now)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return out, nil
+}
+
+// approveTransferTx — requested -> approved для buy/give с авто-отклонением остальных заявок на передачу.
+func (r *Repo) approveTransferTx(ctx context.Context, tx pgx.Tx, b booking.Booking, actor *int64, action string, now time.Time) (booking.Booking, []int64, error) {
 	if b.Type != booking.TypeBuy && b.Type != booking.TypeGive {
-		return booking.Booking{}, booking.ErrInvalidState
+		return booking.Booking{}, nil, booking.ErrInvalidState
 	}
-	if b.Status != booking.StatusRequested {
-		return booking.Booking{}, booking.ErrInvalidState
+	t, err := booking.CheckAction(b, approveAction(actor), approveRole(actor), b.Facts(now, 0))
+	if err != nil {
+		return booking.Booking{}, nil, err
 	}
 	// у transfer не должно быть дат
 	if b.Start != nil || b.End != nil {
-		return booking.Booking{}, booking.ErrInvalidState
+		return booking.Booking{}, nil, booking.ErrInvalidState
 	}
 
 	// дедлайн на забрать/встретиться (можешь поменять TTL)
@@ -814,9 +1440,12 @@ func (r *Repo) ApproveTransfer(ctx context.Context, bookingID int64, ownerID int
 		booking.StatusRequested,
 	), &out); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return booking.Booking{}, booking.ErrInvalidState
+			return booking.Booking{}, nil, booking.ErrInvalidState
 		}
-		return booking.Booking{}, fmt.Errorf("bookings pgrepo: approve transfer update: %w", err)
+		return booking.Booking{}, nil, fmt.Errorf("bookings pgrepo: approve transfer update: %w", err)
+	}
+	if err := booking.CheckResult(t, out.Status); err != nil {
+		return booking.Booking{}, nil, err
 	}
 
 	// авто-отклоняем всех конкурентов requested по этому item для buy/give
@@ -836,7 +1465,7 @@ func (r *Repo) ApproveTransfer(ctx context.Context, bookingID int64, ownerID int
 		out.ID,
 	)
 	if err != nil {
-		return booking.Booking{}, fmt.Errorf("bookings pgrepo: decline transfer competitors: %w", err)
+		return booking.Booking{}, nil, fmt.Errorf("bookings pgrepo: decline transfer competitors: %w", err)
 	}
 
 	defer rows.Close()
@@ -845,34 +1474,30 @@ func (r *Repo) ApproveTransfer(ctx context.Context, bookingID int64, ownerID int
 	for rows.Next() {
 		var id int64
 		if err := rows.Scan(&id); err != nil {
-			return booking.Booking{}, fmt.Errorf("bookings pgrepo: decline transfer scan: %w", err)
+			return booking.Booking{}, nil, fmt.Errorf("bookings pgrepo: decline transfer scan: %w", err)
 		}
 		declinedIDs = append(declinedIDs, id)
 	}
 	if err := rows.Err(); err != nil {
-		return booking.Booking{}, fmt.Errorf("bookings pgrepo: decline transfer rows: %w", err)
+		return booking.Booking{}, nil, fmt.Errorf("bookings pgrepo: decline transfer rows: %w", err)
 	}
 
-	actor := ownerID
 	from := booking.StatusRequested
 	to := booking.StatusApproved
 
-	if err := r.eventRepo.InsertBookingEvent(ctx, tx, bookingID, &actor, "approve_transfer", &from, &to, nil); err != nil {
-		return booking.Booking{}, err
+	if err := r.eventRepo.InsertBookingEvent(ctx, tx, out.ID, actor, action, &from, &to, nil); err != nil {
+		return booking.Booking{}, nil, err
 	}
 
 	df := booking.StatusRequested
 	dt := booking.StatusDeclined
 	for _, id := range declinedIDs {
-		if err := r.eventRepo.InsertBookingEvent(ctx, tx, id, &actor, "auto_decline_transfer", &df, &dt, nil); err != nil {
-			return booking.Booking{}, err
+		if err := r.eventRepo.InsertBookingEvent(ctx, tx, id, actor, "auto_decline_transfer", &df, &dt, nil); err != nil {
+			return booking.Booking{}, nil, err
 		}
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
-	}
-	return out, nil
+	return out, declinedIDs, nil
 }
 
 func (r *Repo) HandoverTransfer(ctx context.Context, bookingID, actorID int64, now time.Time) (booking.Booking, error) {
@@ -899,15 +1524,16 @@ FOR UPDATE
 	if b.Type != booking.TypeBuy && b.Type != booking.TypeGive {
 		return booking.Booking{}, booking.ErrInvalidState
 	}
-	if b.Status != booking.StatusApproved && b.Status != booking.StatusHandoverPending {
-		return booking.Booking{}, booking.ErrInvalidState
-	}
 	if actorID != b.OwnerID && actorID != b.RequesterID {
 		return booking.Booking{}, booking.ErrForbidden
 	}
 	if actorID == b.OwnerID && actorID == b.RequesterID {
 		return booking.Booking{}, booking.ErrInvalidState
 	}
+	t, err := booking.CheckAction(b, fsm.ActionHandover, b.RoleOf(actorID), b.Facts(now, 0))
+	if err != nil {
+		return booking.Booking{}, err
+	}
 
 	setOwner := actorID == b.OwnerID
 	setRequester := actorID == b.RequesterID
@@ -941,6 +1567,9 @@ RETURNING ` + selectBookingCols + `
 	if err := scanBooking(tx.QueryRow(ctx, updQ, b.ID, setOwner, setRequester, now), &out); err != nil {
 		return booking.Booking{}, fmt.Errorf("bookings pgrepo: handover transfer update: %w", err)
 	}
+	if err := booking.CheckResult(t, out.Status); err != nil {
+		return booking.Booking{}, err
+	}
 
 	didConfirm := false
 	if setOwner {
@@ -988,33 +1617,39 @@ func (r *Repo) CancelTransfer(ctx context.Context, bookingID, requesterID int64)
 	}
 	defer tx.Rollback(ctx)
 
+	b, err := lockBookingTx(ctx, tx, bookingID)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+	if b.RequesterID != requesterID {
+		return booking.Booking{}, booking.ErrForbidden
+	}
+	if b.Type != booking.TypeBuy && b.Type != booking.TypeGive {
+		return booking.Booking{}, booking.ErrInvalidState
+	}
+	t, err := booking.CheckAction(b, fsm.ActionCancel, fsm.RoleRequester, b.Facts(time.Now().UTC(), 0))
+	if err != nil {
+		return booking.Booking{}, err
+	}
+
 	const q = `
 	UPDATE bookings
-	SET status = $3
+	SET status = $2,
+	    cancelled_by = 'requester'
 	WHERE id = $1
-	  AND requester_id = $2
-	  AND status = $4
-	  AND type IN ('buy','give')
 	RETURNING ` + selectBookingCols + `
 	`
-
 	var out booking.Booking
-	err = scanBooking(tx.QueryRow(ctx, q,
-		bookingID,
-		requesterID,
-		booking.StatusCanceled,
-		booking.StatusRequested,
-	), &out)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return booking.Booking{}, r.explainCancelNoRows(ctx, bookingID, requesterID)
-		}
+	if err := scanBooking(tx.QueryRow(ctx, q, b.ID, booking.StatusCanceled), &out); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: cancel transfer update: %w", err)
+	}
+	if err := booking.CheckResult(t, out.Status); err != nil {
 		return booking.Booking{}, err
 	}
 
 	actor := requesterID
-	from := booking.StatusRequested
-	to := booking.StatusCanceled
+	from := b.Status
+	to := out.Status
 
 	if err := r.eventRepo.InsertBookingEvent(ctx, tx, out.ID, &actor, "cancel_transfer", &from, &to, nil); err != nil {
 		return booking.Booking{}, err
@@ -1038,7 +1673,7 @@ func (r *Repo) ListUpcomingByItem(ctx context.Context, itemID int64, now time.Ti
 	`
 	var cur booking.Booking
 	err := scanBooking(r.pool.QueryRow(ctx, inUseQ, itemID), &cur)
-	if err != nil && errors.Is(err, pgx.ErrNoRows) {
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil, fmt.Errorf("bookings pgrepo: list upcoming in_use: %w", err)
 	}
 
@@ -1096,16 +1731,35 @@ func (r *Repo) ListBusyDaysByItem(ctx context.Context, itemID int64, fromDay, to
 		return nil, false, fmt.Errorf("busy days inUse: %w", err)
 	}
 
+	// буферные дни вокруг брони и блокировки владельца тоже недоступны (без подробностей);
+	// зазор — max(before, after) с обеих сторон, как в rentConflictTx
 	const q = `
+	WITH busy AS (
+		SELECT
+		  b.start_at - g.gap AS s,
+		  b.end_at   + g.gap AS e
+		FROM bookings b
+		JOIN items i ON i.id = b.item_id
+		CROSS JOIN LATERAL (
+		  SELECT GREATEST(
+		    make_interval(days => i.buffer_before_days, hours => i.buffer_before_hours),
+		    make_interval(days => i.buffer_after_days, hours => i.buffer_after_hours)
+		  ) AS gap
+		) g
+		WHERE b.item_id = $1
+		  AND b.type = 'rent'
+		  AND b.status IN ('approved','handover_pending','in_use','return_pending')
+		UNION ALL
+		SELECT start_at, end_at
+		FROM item_blocks
+		WHERE item_id = $1
+	)
 	SELECT
-	  GREATEST(start_at::date, $2::date)::text AS start_day,
-	  LEAST(end_at::date, $3::date)::text      AS end_day
-	FROM bookings
-	WHERE item_id = $1
-	  AND type = 'rent'
-	  AND status IN ('approved','handover_pending','in_use','return_pending')
-	  AND start_at < ($3::date + INTERVAL '1 day')
-	  AND end_at   > ($2::date)
+	  GREATEST(s::date, $2::date)::text AS start_day,
+	  LEAST((e - INTERVAL '1 microsecond')::date, $3::date)::text AS end_day
+	FROM busy
+	WHERE s < ($3::date + INTERVAL '1 day')
+	  AND e > ($2::date)
 	ORDER BY start_day
 	`
 
@@ -1129,6 +1783,58 @@ func (r *Repo) ListBusyDaysByItem(ctx context.Context, itemID int64, fromDay, to
 	return out, isInUseNow, nil
 }
 
+// ListBusySlotsByItem — занятые интервалы (для почасовых вещей) вместе с буфером и блокировками, обрезанные по [from, to).
+// Буфер — max(before, after) с обеих сторон, как в rentConflictTx.
+func (r *Repo) ListBusySlotsByItem(ctx context.Context, itemID int64, from, to time.Time) ([]booking.TimeRange, error) {
+	const q = `
+	WITH busy AS (
+		SELECT
+		  b.start_at - g.gap AS s,
+		  b.end_at   + g.gap AS e
+		FROM bookings b
+		JOIN items i ON i.id = b.item_id
+		CROSS JOIN LATERAL (
+		  SELECT GREATEST(
+		    make_interval(days => i.buffer_before_days, hours => i.buffer_before_hours),
+		    make_interval(days => i.buffer_after_days, hours => i.buffer_after_hours)
+		  ) AS gap
+		) g
+		WHERE b.item_id = $1
+		  AND b.type = 'rent'
+		  AND b.status IN ('approved','handover_pending','in_use','return_pending')
+		UNION ALL
+		SELECT start_at, end_at
+		FROM item_blocks
+		WHERE item_id = $1
+	)
+	SELECT GREATEST(s, $2), LEAST(e, $3)
+	FROM busy
+	WHERE s < $3
+	  AND e > $2
+	ORDER BY s
+	`
+	rows, err := r.pool.Query(ctx, q, itemID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("busy slots query: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]booking.TimeRange, 0, 32)
+	for rows.Next() {
+		var tr booking.TimeRange
+		if err := rows.Scan(&tr.Start, &tr.End); err != nil {
+			return nil, fmt.Errorf("busy slots scan: %w", err)
+		}
+		tr.Start = tr.Start.UTC()
+		tr.End = tr.End.UTC()
+		out = append(out, tr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("busy slots rows: %w", err)
+	}
+	return out, nil
+}
+
 func syncItemStatusTx(ctx context.Context, tx pgx.Tx, itemID int64) error {
 	const lockItemQ = `SELECT status FROM items WHERE id = $1 FOR UPDATE`
 	var cur string
@@ -1169,63 +1875,115 @@ func syncItemStatusTx(ctx context.Context, tx pgx.Tx, itemID int64) error {
 	return nil
 }
 
-func (r *Repo) explainNoRows(ctx context.Context, bookingID, ownerID int64, expectedStatus booking.Status) error {
-	const q = `
-	SELECT owner_id, status
-	FROM bookings
-	WHERE id = $1
-	`
+// FOR EVENT_REPO
+func (r *Repo) ListEvents(ctx context.Context, bookingID int64, limit, offset int) ([]booking.Event, error) {
+	return r.eventRepo.ListBookingEvents(ctx, r.pool, bookingID, limit, offset)
+}
+
+// ListUserEventsSince — лента событий пользователя для SSE (догоняем после Last-Event-ID).
+func (r *Repo) ListUserEventsSince(ctx context.Context, userID int64, after booking.EventCursor, limit int) ([]booking.Event, error) {
+	return r.eventRepo.ListUserEventsSince(ctx, r.pool, userID, after, limit)
+}
 
-	var dbOwnerID int64
-	var dbStatus booking.Status
+// CurrentEventCursor — с него начинают новые подключения без Last-Event-ID:
+// всё, что закоммитится дальше, получит txid >= xmin.
+func (r *Repo) CurrentEventCursor(ctx context.Context) (booking.EventCursor, error) {
+	var xmin int64
+	if err := r.pool.QueryRow(ctx, `SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint`).Scan(&xmin); err != nil {
+		return booking.EventCursor{}, fmt.Errorf("bookings pgrepo: current event cursor: %w", err)
+	}
+	return booking.EventCursor{TxID: xmin}, nil
+}
 
-	err := r.pool.QueryRow(ctx, q, bookingID).Scan(&dbOwnerID, &dbStatus)
+// EventCursorByID — курсор события по его id (Last-Event-ID старого формата — только id).
+func (r *Repo) EventCursorByID(ctx context.Context, eventID int64) (booking.EventCursor, error) {
+	c := booking.EventCursor{ID: eventID}
+	err := r.pool.QueryRow(ctx, `SELECT txid FROM booking_events WHERE id = $1`, eventID).Scan(&c.TxID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return booking.ErrNotFound
+			return booking.EventCursor{}, booking.ErrNotFound
 		}
-		return err
-	}
-	if dbOwnerID != ownerID {
-		return booking.ErrForbidden
-	}
-	if dbStatus != expectedStatus {
-		return booking.ErrInvalidState
+		return booking.EventCursor{}, fmt.Errorf("bookings pgrepo: event cursor: %w", err)
 	}
-	return booking.ErrInvalidState
+	return c, nil
 }
 
-func (r *Repo) explainCancelNoRows(ctx context.Context, bookingID, requesterID int64) error {
+//HELPERS
+
+// rentConflictTx — пересекается ли [start, end) с другой занимающей бронью аренды этого item.
+// excludeID — бронь, которую не учитываем (сама себя при переносе/продлении), 0 = никакую.
+// Буферы вещи учитываются для обеих сторон пары (см. item.RentRules.Gap): новая бронь с буферами
+// не пересекает занятую, а занятая с буферами — новую. Ручные блокировки владельца — без буфера.
+func rentConflictTx(ctx context.Context, tx pgx.Tx, itemID int64, start, end time.Time, excludeID int64) (bool, error) {
 	const q = `
-		SELECT requester_id, status, type
-		FROM bookings
-		WHERE id = $1
-		`
-	var dbRequesterID int64
-	var dbStatus booking.Status
-	var dbType booking.Type
+	SELECT EXISTS (
+		SELECT 1
+		FROM bookings b
+		JOIN items i ON i.id = b.item_id
+		WHERE b.item_id = $1
+		  AND b.type = 'rent'
+		  AND b.status IN ('approved','handover_pending','in_use','return_pending')
+		  AND b.id <> $4
+		  AND (
+		        (b.start_at - make_interval(days => i.buffer_before_days, hours => i.buffer_before_hours) < $3::timestamptz
+		     AND b.end_at   + make_interval(days => i.buffer_after_days, hours => i.buffer_after_hours) > $2::timestamptz)
+		     OR ($2::timestamptz - make_interval(days => i.buffer_before_days, hours => i.buffer_before_hours) < b.end_at
+		     AND $3::timestamptz + make_interval(days => i.buffer_after_days, hours => i.buffer_after_hours) > b.start_at)
+		  )
+	) OR EXISTS (
+		SELECT 1
+		FROM item_blocks
+		WHERE item_id = $1
+		  AND start_at < $3
+		  AND end_at   > $2
+	)
+	`
+	var busy bool
+	if err := tx.QueryRow(ctx, q, itemID, start, end, excludeID).Scan(&busy); err != nil {
+		return false, err
+	}
+	return busy, nil
+}
 
-	err := r.pool.QueryRow(ctx, q, bookingID).Scan(&dbRequesterID, &dbStatus, &dbType)
+// itemRentRulesTx — правила аренды и шаг вещи (для проверок при одобрении).
+// Строка items блокируется: одобрения и ручные блокировки одной вещи идут по очереди.
+func itemRentRulesTx(ctx context.Context, tx pgx.Tx, itemID int64) (item.RentRules, item.Granularity, error) {
+	const q = `
+	SELECT buffer_before_days, buffer_after_days, min_rental_days, max_rental_days,
+	       buffer_before_hours, buffer_after_hours, min_rental_hours, max_rental_hours,
+	       min_notice_hours, max_horizon_days,
+	       booking_granularity
+	FROM items
+	WHERE id = $1
+	FOR UPDATE
+	`
+	var rr item.RentRules
+	var g item.Granularity
+	err := tx.QueryRow(ctx, q, itemID).Scan(
+		&rr.BufferBeforeDays,
+		&rr.BufferAfterDays,
+		&rr.MinRentalDays,
+		&rr.MaxRentalDays,
+		&rr.BufferBeforeHours,
+		&rr.BufferAfterHours,
+		&rr.MinRentalHours,
+		&rr.MaxRentalHours,
+		&rr.MinNoticeHours,
+		&rr.MaxHorizonDays,
+		&g,
+	)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return booking.ErrNotFound
-		}
-		return err
+		return item.RentRules{}, "", fmt.Errorf("bookings pgrepo: item rent rules: %w", err)
 	}
-	if dbRequesterID != requesterID {
-		return booking.ErrForbidden
-	}
-	// если это не rent или статус не requested — это конфликт состояния
-	return booking.ErrInvalidState
+	return rr, g, nil
 }
 
-// FOR EVENT_REPO
-func (r *Repo) ListEvents(ctx context.Context, bookingID int64, limit, offset int) ([]booking.Event, error) {
-	return r.eventRepo.ListBookingEvents(ctx, r.pool, bookingID, limit, offset)
+// isExclusionViolation — сработал bookings_rent_no_overlap (пересечение занятых интервалов аренды).
+func isExclusionViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23P01"
 }
 
-//HELPERS
-
 func markItemTransferredTx(ctx context.Context, tx pgx.Tx, itemID int64) error {
 	const lockItemQ = `SELECT status FROM items WHERE id = $1 FOR UPDATE`
 	var cur string
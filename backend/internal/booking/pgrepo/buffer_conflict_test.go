@@ -0,0 +1,105 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+)
+
+// TestRentBufferBothOrders — буфер «до» в 1 день: соседние аренды встык конфликтуют
+// независимо от того, какая из них была одобрена первой; занятые дни показывают тот же зазор.
+func TestRentBufferBothOrders(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	pool := testPool(t)
+	repo := New(pool, NewEventRepo(), NewDepositRepo(0), NewWaitlistRepo(0))
+
+	ownerID := insertTestUser(t, ctx, pool, "buf-owner")
+	requesterID := insertTestUser(t, ctx, pool, "buf-req")
+	var itemIDs []int64
+	t.Cleanup(func() {
+		bg := context.Background()
+		for _, id := range itemIDs {
+			if _, err := pool.Exec(bg, `DELETE FROM bookings WHERE item_id = $1`, id); err != nil {
+				t.Logf("cleanup bookings: %v", err)
+			}
+			if _, err := pool.Exec(bg, `DELETE FROM items WHERE id = $1`, id); err != nil {
+				t.Logf("cleanup item: %v", err)
+			}
+		}
+		if _, err := pool.Exec(bg, `DELETE FROM users WHERE id = ANY($1)`, []int64{ownerID, requesterID}); err != nil {
+			t.Logf("cleanup users: %v", err)
+		}
+	})
+
+	day := func(n int) time.Time {
+		return time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 30+n)
+	}
+	rent := func(itemID int64, from, to int) booking.Booking {
+		s, e := day(from), day(to)
+		return booking.Booking{
+			ItemID:      itemID,
+			RequesterID: requesterID,
+			OwnerID:     ownerID,
+			Type:        booking.TypeRent,
+			Status:      booking.StatusRequested,
+			Start:       &s,
+			End:         &e,
+		}
+	}
+
+	cases := []struct {
+		name             string
+		first, candidate [2]int
+	}{
+		{"candidate after existing", [2]int{10, 12}, [2]int{12, 14}},
+		{"candidate before existing", [2]int{12, 14}, [2]int{10, 12}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var itemID int64
+			err := pool.QueryRow(ctx, `
+			INSERT INTO items (title, status, mode, owner_id, buffer_before_days, buffer_after_days)
+			VALUES ('buffer test', 'active', 'rent', $1, 1, 0)
+			RETURNING id
+			`, ownerID).Scan(&itemID)
+			if err != nil {
+				t.Fatalf("insert item: %v", err)
+			}
+			itemIDs = append(itemIDs, itemID)
+
+			first := rent(itemID, c.first[0], c.first[1])
+			if err := repo.Create(ctx, &first); err != nil {
+				t.Fatalf("create first: %v", err)
+			}
+			if _, err := repo.ApproveRent(ctx, first.ID, ownerID); err != nil {
+				t.Fatalf("approve first: %v", err)
+			}
+
+			candidate := rent(itemID, c.candidate[0], c.candidate[1])
+			if err := repo.Create(ctx, &candidate); !errors.Is(err, booking.ErrConflict) {
+				t.Fatalf("create candidate: err = %v, want ErrConflict", err)
+			}
+
+			// candidate целиком должен быть виден занятым
+			from, to := day(c.candidate[0]), day(c.candidate[1]-1)
+			busy, _, err := repo.ListBusyDaysByItem(ctx, itemID, from, to)
+			if err != nil {
+				t.Fatalf("busy days: %v", err)
+			}
+			if len(busy) == 0 {
+				t.Fatal("busy days: want the buffer day adjacent to the existing rental")
+			}
+			slots, err := repo.ListBusySlotsByItem(ctx, itemID, *candidate.Start, *candidate.End)
+			if err != nil {
+				t.Fatalf("busy slots: %v", err)
+			}
+			if len(slots) == 0 {
+				t.Fatal("busy slots: want the buffer adjacent to the existing rental")
+			}
+		})
+	}
+}
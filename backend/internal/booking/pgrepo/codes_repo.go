@@ -0,0 +1,201 @@
+package pgrepo
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/booking/fsm"
+)
+
+// itemRequiresCodeTx — вещь принимает передачу/возврат только по коду.
+func itemRequiresCodeTx(ctx context.Context, tx pgx.Tx, itemID int64) (bool, error) {
+	const q = `SELECT require_handover_code FROM items WHERE id = $1`
+	var required bool
+	if err := tx.QueryRow(ctx, q, itemID).Scan(&required); err != nil {
+		return false, fmt.Errorf("bookings pgrepo: item require code: %w", err)
+	}
+	return required, nil
+}
+
+// lockRentForCode — бронь аренды под блокировкой; actor должен быть участником в нужной роли.
+func lockRentForCode(ctx context.Context, tx pgx.Tx, bookingID, actorID int64, purpose booking.CodePurpose, issuer bool, now time.Time) (booking.Booking, fsm.Transition, error) {
+	const q = `
+	SELECT ` + selectBookingCols + `
+	FROM bookings
+	WHERE id = $1
+	FOR UPDATE
+	`
+	var b booking.Booking
+	if err := scanBooking(tx.QueryRow(ctx, q, bookingID), &b); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return booking.Booking{}, fsm.Transition{}, booking.ErrNotFound
+		}
+		return booking.Booking{}, fsm.Transition{}, fmt.Errorf("bookings pgrepo: code select booking: %w", err)
+	}
+	if b.Type != booking.TypeRent {
+		return booking.Booking{}, fsm.Transition{}, booking.ErrInvalidState
+	}
+	role := b.RoleOf(actorID)
+	if role == "" {
+		return booking.Booking{}, fsm.Transition{}, booking.ErrForbidden
+	}
+	if (role == purpose.Issuer()) != issuer {
+		return booking.Booking{}, fsm.Transition{}, booking.ErrForbidden
+	}
+	t, err := booking.CheckAction(b, purpose.Action(), role, b.Facts(now, 0))
+	if err != nil {
+		return booking.Booking{}, fsm.Transition{}, err
+	}
+	return b, t, nil
+}
+
+// IssueConfirmationCode — сохраняет хэш нового кода вместо прежнего; попытки обнуляются.
+func (r *Repo) IssueConfirmationCode(ctx context.Context, bookingID, actorID int64, purpose booking.CodePurpose, codeHash string, now time.Time) (time.Time, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, _, err := lockRentForCode(ctx, tx, bookingID, actorID, purpose, true, now); err != nil {
+		return time.Time{}, err
+	}
+
+	const prevQ = `
+	SELECT created_at
+	FROM booking_confirmation_codes
+	WHERE booking_id = $1 AND purpose = $2 AND consumed_at IS NULL
+	FOR UPDATE
+	`
+	var prevAt time.Time
+	err = tx.QueryRow(ctx, prevQ, bookingID, purpose).Scan(&prevAt)
+	switch {
+	case err == nil:
+		if now.Sub(prevAt) < booking.ConfirmationCodeReissueAfter {
+			return time.Time{}, booking.ErrCodeTooSoon
+		}
+	case errors.Is(err, pgx.ErrNoRows):
+	default:
+		return time.Time{}, fmt.Errorf("bookings pgrepo: code select prev: %w", err)
+	}
+
+	expiresAt := now.Add(booking.ConfirmationCodeTTL)
+	const upsertQ = `
+	INSERT INTO booking_confirmation_codes (booking_id, purpose, code_hash, issued_by, expires_at, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (booking_id, purpose) DO UPDATE
+	SET code_hash = EXCLUDED.code_hash,
+	    issued_by = EXCLUDED.issued_by,
+	    expires_at = EXCLUDED.expires_at,
+	    attempts = 0,
+	    consumed_at = NULL,
+	    created_at = EXCLUDED.created_at
+	`
+	if _, err := tx.Exec(ctx, upsertQ, bookingID, purpose, codeHash, actorID, expiresAt, now); err != nil {
+		return time.Time{}, fmt.Errorf("bookings pgrepo: code upsert: %w", err)
+	}
+
+	actor := actorID
+	meta, _ := json.Marshal(map[string]any{"expires_at": expiresAt})
+	if err := r.eventRepo.InsertBookingEvent(ctx, tx, bookingID, &actor, string(purpose)+"_code_issued", nil, nil, meta); err != nil {
+		return time.Time{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return time.Time{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return expiresAt, nil
+}
+
+// ConfirmWithCode — вторая сторона вводит код: проверка, погашение кода и подтверждение
+// передачи/возврата за обе стороны идут в одной транзакции. Неудачная попытка тоже фиксируется
+// (счётчик и событие), поэтому в этом случае транзакция коммитится с ошибкой в ответе.
+func (r *Repo) ConfirmWithCode(ctx context.Context, bookingID, actorID int64, purpose booking.CodePurpose, code string, now time.Time) (booking.Booking, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	b, t, err := lockRentForCode(ctx, tx, bookingID, actorID, purpose, false, now)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+
+	const codeQ = `
+	SELECT code_hash, expires_at, attempts
+	FROM booking_confirmation_codes
+	WHERE booking_id = $1 AND purpose = $2 AND consumed_at IS NULL
+	FOR UPDATE
+	`
+	var (
+		hash      string
+		expiresAt time.Time
+		attempts  int
+	)
+	if err := tx.QueryRow(ctx, codeQ, bookingID, purpose).Scan(&hash, &expiresAt, &attempts); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return booking.Booking{}, booking.ErrInvalidCode
+		}
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: code select: %w", err)
+	}
+	if attempts >= booking.ConfirmationCodeMaxAttempts {
+		return booking.Booking{}, booking.ErrCodeAttempts
+	}
+	if !now.Before(expiresAt) {
+		return booking.Booking{}, booking.ErrCodeExpired
+	}
+
+	actor := actorID
+	got := booking.HashConfirmationCode(bookingID, purpose, code)
+	if subtle.ConstantTimeCompare([]byte(got), []byte(hash)) != 1 {
+		attempts++
+		const failQ = `UPDATE booking_confirmation_codes SET attempts = $3 WHERE booking_id = $1 AND purpose = $2`
+		if _, err := tx.Exec(ctx, failQ, bookingID, purpose, attempts); err != nil {
+			return booking.Booking{}, fmt.Errorf("bookings pgrepo: code fail update: %w", err)
+		}
+		left := booking.ConfirmationCodeMaxAttempts - attempts
+		meta, _ := json.Marshal(map[string]int{"attempts_left": left})
+		if err := r.eventRepo.InsertBookingEvent(ctx, tx, bookingID, &actor, string(purpose)+"_code_failed", nil, nil, meta); err != nil {
+			return booking.Booking{}, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
+		}
+		if left <= 0 {
+			return booking.Booking{}, booking.ErrCodeAttempts
+		}
+		return booking.Booking{}, booking.ErrInvalidCode
+	}
+
+	const consumeQ = `UPDATE booking_confirmation_codes SET consumed_at = $3 WHERE booking_id = $1 AND purpose = $2`
+	if _, err := tx.Exec(ctx, consumeQ, bookingID, purpose, now); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: code consume: %w", err)
+	}
+	if err := r.eventRepo.InsertBookingEvent(ctx, tx, bookingID, &actor, string(purpose)+"_code_verified", nil, nil, nil); err != nil {
+		return booking.Booking{}, err
+	}
+
+	var out booking.Booking
+	switch purpose {
+	case booking.CodeHandover:
+		out, err = r.confirmHandoverTx(ctx, tx, b, t, actorID, true, true, "code", now)
+	default:
+		out, err = r.confirmReturnTx(ctx, tx, b, t, actorID, true, true, "code", now)
+	}
+	if err != nil {
+		return booking.Booking{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return out, nil
+}
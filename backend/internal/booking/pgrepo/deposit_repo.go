@@ -0,0 +1,237 @@
+package pgrepo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+)
+
+// DepositRepo — журнал залога; все записи пишутся внутри транзакций переходов брони.
+// disputeWindow — сколько после завершения аренды залог ещё удерживается под возможный спор.
+type DepositRepo struct {
+	disputeWindow time.Duration
+}
+
+func NewDepositRepo(disputeWindow time.Duration) *DepositRepo {
+	return &DepositRepo{disputeWindow: disputeWindow}
+}
+
+type querier interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+	QueryRow(context.Context, string, ...any) pgx.Row
+}
+
+func (r *DepositRepo) insertEntry(ctx context.Context, tx pgx.Tx, bookingID int64, kind booking.DepositEntryKind, amount int64, actorID *int64, disputeID *int64) error {
+	const q = `
+	INSERT INTO deposit_entries (booking_id, kind, amount, actor_user_id, dispute_id)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, q, bookingID, kind, amount, actorID, disputeID); err != nil {
+		return fmt.Errorf("deposit repo: insert %s: %w", kind, err)
+	}
+	return nil
+}
+
+// BalanceTx — сколько залога сейчас удерживается.
+func (r *DepositRepo) BalanceTx(ctx context.Context, q querier, bookingID int64) (int64, error) {
+	const sq = `
+	SELECT COALESCE(SUM(CASE WHEN kind = 'hold' THEN amount ELSE -amount END), 0)
+	FROM deposit_entries
+	WHERE booking_id = $1
+	`
+	var bal int64
+	if err := q.QueryRow(ctx, sq, bookingID).Scan(&bal); err != nil {
+		return 0, fmt.Errorf("deposit repo: balance: %w", err)
+	}
+	return bal, nil
+}
+
+// HoldTx — удерживает снимок залога при переходе аренды в in_use (повторный вызов ничего не делает).
+func (r *DepositRepo) HoldTx(ctx context.Context, tx pgx.Tx, bookingID int64, amount *int64, actorID *int64) error {
+	if amount == nil || *amount <= 0 {
+		return nil
+	}
+	return r.insertEntry(ctx, tx, bookingID, booking.DepositHold, *amount, actorID, nil)
+}
+
+// ReleaseRemainingTx — возвращает арендатору весь оставшийся залог.
+func (r *DepositRepo) ReleaseRemainingTx(ctx context.Context, tx pgx.Tx, bookingID int64, actorID *int64) (int64, error) {
+	bal, err := r.BalanceTx(ctx, tx, bookingID)
+	if err != nil {
+		return 0, err
+	}
+	if bal <= 0 {
+		return 0, nil
+	}
+	if err := r.insertEntry(ctx, tx, bookingID, booking.DepositRelease, bal, actorID, nil); err != nil {
+		return 0, err
+	}
+	return bal, nil
+}
+
+// ScheduleReleaseTx — при завершении аренды: остаток залога вернётся по окончании окна споров
+// (ReleaseDueDeposits). Без окна — возвращается сразу.
+func (r *DepositRepo) ScheduleReleaseTx(ctx context.Context, tx pgx.Tx, bookingID int64, actorID *int64, now time.Time) error {
+	if r.disputeWindow <= 0 {
+		_, err := r.ReleaseRemainingTx(ctx, tx, bookingID, actorID)
+		return err
+	}
+	bal, err := r.BalanceTx(ctx, tx, bookingID)
+	if err != nil {
+		return err
+	}
+	if bal <= 0 {
+		return nil
+	}
+	const q = `UPDATE bookings SET deposit_release_at = $2 WHERE id = $1`
+	if _, err := tx.Exec(ctx, q, bookingID, now.Add(r.disputeWindow)); err != nil {
+		return fmt.Errorf("deposit repo: schedule release: %w", err)
+	}
+	return nil
+}
+
+// ForfeitTx — списывает в пользу владельца не больше, чем удерживается; возвращает фактическую сумму.
+func (r *DepositRepo) ForfeitTx(ctx context.Context, tx pgx.Tx, bookingID int64, amount int64, actorID *int64, disputeID *int64) (int64, error) {
+	if amount <= 0 {
+		return 0, nil
+	}
+	bal, err := r.BalanceTx(ctx, tx, bookingID)
+	if err != nil {
+		return 0, err
+	}
+	if amount > bal {
+		amount = bal
+	}
+	if amount <= 0 {
+		return 0, nil
+	}
+	if err := r.insertEntry(ctx, tx, bookingID, booking.DepositForfeit, amount, actorID, disputeID); err != nil {
+		return 0, err
+	}
+	return amount, nil
+}
+
+func (r *DepositRepo) ListEntries(ctx context.Context, q querier, bookingID int64) ([]booking.DepositEntry, error) {
+	const sq = `
+	SELECT id, booking_id, kind, amount, actor_user_id, dispute_id, created_at
+	FROM deposit_entries
+	WHERE booking_id = $1
+	ORDER BY created_at ASC, id ASC
+	`
+	rows, err := q.Query(ctx, sq, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("deposit repo: list entries: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]booking.DepositEntry, 0, 4)
+	for rows.Next() {
+		var e booking.DepositEntry
+		if err := rows.Scan(&e.ID, &e.BookingID, &e.Kind, &e.Amount, &e.ActorUserID, &e.DisputeID, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("deposit repo: list entries scan: %w", err)
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("deposit repo: list entries rows: %w", err)
+	}
+	return out, nil
+}
+
+// ReleaseDueDeposits — возвращает залог завершённых аренд, у которых закончилось окно споров.
+// Замороженные брони (открыт спор) пропускаются: их залог вернётся после решения спора.
+func (r *Repo) ReleaseDueDeposits(ctx context.Context, now time.Time) (int64, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const selQ = `
+	SELECT id
+	FROM bookings
+	WHERE deposit_release_at IS NOT NULL
+	  AND deposit_release_at <= $1
+	  AND frozen_at IS NULL
+	ORDER BY deposit_release_at
+	LIMIT 100
+	FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(ctx, selQ, now)
+	if err != nil {
+		return 0, fmt.Errorf("bookings pgrepo: due deposits: %w", err)
+	}
+	ids := make([]int64, 0, 16)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("bookings pgrepo: due deposits scan: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("bookings pgrepo: due deposits rows: %w", err)
+	}
+	rows.Close()
+
+	const clearQ = `UPDATE bookings SET deposit_release_at = NULL WHERE id = $1`
+	for _, id := range ids {
+		amount, err := r.depositRepo.ReleaseRemainingTx(ctx, tx, id, nil)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(ctx, clearQ, id); err != nil {
+			return 0, fmt.Errorf("bookings pgrepo: clear deposit release: %w", err)
+		}
+		if amount > 0 {
+			meta, _ := json.Marshal(map[string]int64{"amount": amount})
+			if err := r.eventRepo.InsertBookingEvent(ctx, tx, id, nil, "deposit_release", nil, nil, meta); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return int64(len(ids)), nil
+}
+
+// GetDeposit — сводка по залогу брони для обеих сторон.
+func (r *Repo) GetDeposit(ctx context.Context, bookingID int64) (booking.Deposit, error) {
+	const q = `SELECT deposit_amount, deposit_release_at FROM bookings WHERE id = $1`
+	d := booking.Deposit{BookingID: bookingID}
+	if err := r.pool.QueryRow(ctx, q, bookingID).Scan(&d.Amount, &d.ReleaseAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return booking.Deposit{}, booking.ErrNotFound
+		}
+		return booking.Deposit{}, fmt.Errorf("bookings pgrepo: get deposit: %w", err)
+	}
+
+	entries, err := r.depositRepo.ListEntries(ctx, r.pool, bookingID)
+	if err != nil {
+		return booking.Deposit{}, err
+	}
+	d.Entries = entries
+	for _, e := range entries {
+		switch e.Kind {
+		case booking.DepositHold:
+			d.Held += e.Amount
+		case booking.DepositRelease:
+			d.Released += e.Amount
+		case booking.DepositForfeit:
+			d.Forfeited += e.Amount
+		}
+	}
+	d.Balance = d.Held - d.Released - d.Forfeited
+	return d, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/jackc/pgx/v5"
 
 	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	outboxpg "github.com/SHILOP0P/Yardly/backend/internal/outbox/pgrepo"
 )
 
 type EventRepo struct{}
@@ -24,17 +25,119 @@ FROM booking_events
 `
 
 
+// InsertBookingEvent — вместе со вставкой шлёт pg_notify участникам брони; уведомление уходит при commit.
+// Событие заодно пишется в outbox в той же транзакции.
 func (r *EventRepo) InsertBookingEvent(ctx context.Context, tx pgx.Tx, bookingID int64, actorID *int64, action string, from *booking.Status, to *booking.Status, meta []byte)error{
 	const q = `
-	INSERT INTO booking_events (booking_id, actor_user_id, action, from_status, to_status, meta)
-	VALUES ($1, $2, $3, $4, $5, $6)
+	WITH ev AS (
+		INSERT INTO booking_events (booking_id, actor_user_id, action, from_status, to_status, meta)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, booking_id
+	)
+	SELECT ev.id, pg_notify($7, json_build_object(
+		'id', ev.id,
+		'booking_id', ev.booking_id,
+		'owner_id', b.owner_id,
+		'requester_id', b.requester_id
+	)::text)
+	FROM ev
+	JOIN bookings b ON b.id = ev.booking_id
 	`
-	_, err := tx.Exec(ctx, q, bookingID,
-		actorID, action, from, to, meta,)
+	var eventID int64
+	err := tx.QueryRow(ctx, q, bookingID,
+		actorID, action, from, to, meta, booking.EventsChannel).Scan(&eventID, nil)
 	
 	if err != nil{
 		return fmt.Errorf("bookings pgrepo: insert booking event: %w", err)
 	}
+	return outboxpg.AppendBookingEventTx(ctx, tx, eventID)
+}
+
+// InsertUserEvent — событие для одного пользователя без брони (например, предложение из листа ожидания).
+// В SSE оно уходит только адресату; pg_notify будит его подключения при commit.
+func (r *EventRepo) InsertUserEvent(ctx context.Context, tx pgx.Tx, userID int64, action string, meta []byte) error {
+	const q = `
+	WITH ev AS (
+		INSERT INTO booking_events (recipient_user_id, action, meta)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	)
+	SELECT pg_notify($4, json_build_object(
+		'id', ev.id,
+		'owner_id', $1::bigint,
+		'requester_id', $1::bigint
+	)::text)
+	FROM ev
+	`
+	if _, err := tx.Exec(ctx, q, userID, action, meta, booking.EventsChannel); err != nil {
+		return fmt.Errorf("bookings pgrepo: insert user event: %w", err)
+	}
+	return nil
+}
+
+// ListUserEventsSince — события по броням, где пользователь владелец или арендатор, и адресованные ему, после курсора after
+// в порядке (txid, id). Берутся только транзакции старше xmin текущего снимка: все они уже завершены,
+// а любая ещё не закоммиченная получит txid >= xmin, т.е. окажется после выданных событий.
+// Цена — задержка выдачи, пока в базе висит долгая транзакция.
+func (r *EventRepo) ListUserEventsSince(ctx context.Context, q interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+}, userID int64, after booking.EventCursor, limit int) ([]booking.Event, error) {
+	const sqlQ = `
+	SELECT e.id, COALESCE(e.booking_id, 0), e.actor_user_id,
+	       e.action, e.from_status, e.to_status,
+	       e.meta, e.created_at, e.txid
+	FROM booking_events e
+	LEFT JOIN bookings b ON b.id = e.booking_id
+	WHERE (e.txid, e.id) > ($2, $3)
+	  AND e.txid < pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+	  AND (b.owner_id = $1 OR b.requester_id = $1 OR e.recipient_user_id = $1)
+	ORDER BY e.txid, e.id
+	LIMIT $4
+	`
+	rows, err := q.Query(ctx, sqlQ, userID, after.TxID, after.ID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("event repo: list user events: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]booking.Event, 0, limit)
+	for rows.Next() {
+		var e booking.Event
+		if err := scanEvent(rows, &e, &e.TxID); err != nil {
+			return nil, fmt.Errorf("event repo: scan user event: %w", err)
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("event repo: rows user events: %w", err)
+	}
+	return out, nil
+}
+
+// scanEvent — meta отдаём объектом, если это JSON-объект, иначе строкой.
+// extra — колонки, выбранные после стандартных.
+func scanEvent(rs rowScanrer, e *booking.Event, extra ...any) error {
+	var metaBytes []byte
+	dest := append([]any{&e.ID,
+		&e.BookingID,
+		&e.ActorUserID,
+		&e.Action,
+		&e.FromStatus,
+		&e.ToStatus,
+		&metaBytes,
+		&e.CreatedAt,
+	}, extra...)
+	if err := rs.Scan(dest...); err != nil {
+		return err
+	}
+	if len(metaBytes) > 0 {
+		var m map[string]any
+		if err := json.Unmarshal(metaBytes, &m); err == nil {
+			e.Meta = m
+		} else {
+			e.Meta = string(metaBytes)
+		}
+	}
 	return nil
 }
 
@@ -65,26 +168,9 @@ func (r *EventRepo) ListBookingEvents(ctx context.Context, pool interface{
 		out := make([]booking.Event, 0, limit)
 		for rows.Next(){
 			var e booking.Event
-			var metaBytes []byte
-
-			if err:= rows.Scan(&e.ID,
-			&e.BookingID,
-			&e.ActorUserID,
-			&e.Action,
-			&e.FromStatus,
-			&e.ToStatus,
-			&metaBytes,
-			&e.CreatedAt,); err != nil{
+			if err := scanEvent(rows, &e); err != nil{
 				return nil, fmt.Errorf("event repo: scan booking event: %w", err)
 			}
-			if len(metaBytes)>0{
-				var m map[string]any
-				if err := json.Unmarshal(metaBytes, &m); err == nil{
-					e.Meta = m
-				} else {
-					e.Meta = string(metaBytes)
-				}
-			}
 			out = append(out, e)
 		}
 		if err = rows.Err(); err != nil{
@@ -0,0 +1,219 @@
+package pgrepo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/booking/fsm"
+)
+
+// lockRentForExtension — берёт аренду FOR UPDATE и проверяет действие продления по fsm.
+func lockRentForExtension(ctx context.Context, tx pgx.Tx, bookingID, actorID int64, action fsm.Action, role fsm.Role, now time.Time) (booking.Booking, fsm.Transition, error) {
+	const selectQ = `
+	SELECT ` + selectBookingCols + `
+	FROM bookings
+	WHERE id = $1
+	FOR UPDATE
+	`
+	var b booking.Booking
+	if err := scanBooking(tx.QueryRow(ctx, selectQ, bookingID), &b); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return booking.Booking{}, fsm.Transition{}, booking.ErrNotFound
+		}
+		return booking.Booking{}, fsm.Transition{}, fmt.Errorf("bookings pgrepo: extension select: %w", err)
+	}
+	if b.RoleOf(actorID) != role {
+		return booking.Booking{}, fsm.Transition{}, booking.ErrForbidden
+	}
+	if b.Type != booking.TypeRent {
+		return booking.Booking{}, fsm.Transition{}, booking.ErrInvalidState
+	}
+	t, err := booking.CheckAction(b, action, role, b.Facts(now, 0))
+	if err != nil {
+		return booking.Booking{}, fsm.Transition{}, err
+	}
+	return b, t, nil
+}
+
+// RequestExtension — арендатор просит продлить in_use аренду; пока прежний запрос ждёт ответа,
+// новый не принимается (guard fsm.ActionExtend).
+func (r *Repo) RequestExtension(ctx context.Context, bookingID, requesterID int64, end time.Time, now time.Time) (booking.Booking, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	b, t, err := lockRentForExtension(ctx, tx, bookingID, requesterID, fsm.ActionExtend, fsm.RoleRequester, now)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+	if b.End == nil || !end.After(*b.End) {
+		return booking.Booking{}, booking.ErrInvalidState
+	}
+
+	const updQ = `
+	UPDATE bookings
+	SET extension_end_at = $2,
+	    extension_requested_at = $3
+	WHERE id = $1
+	RETURNING ` + selectBookingCols + `
+	`
+	var out booking.Booking
+	if err := scanBooking(tx.QueryRow(ctx, updQ, b.ID, end, now), &out); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: extension request update: %w", err)
+	}
+	if err := booking.CheckResult(t, out.Status); err != nil {
+		return booking.Booking{}, err
+	}
+
+	meta, _ := json.Marshal(map[string]any{
+		"old_end": b.End,
+		"new_end": end,
+	})
+	actor := requesterID
+	if err := r.eventRepo.InsertBookingEvent(ctx, tx, out.ID, &actor, "extend_request", nil, nil, meta); err != nil {
+		return booking.Booking{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return out, nil
+}
+
+// ApproveExtension — владелец подтверждает продление; end_at сдвигается, если интервал свободен,
+// price_total пересчитывается по снимку цены на весь новый срок.
+func (r *Repo) ApproveExtension(ctx context.Context, bookingID, ownerID int64) (booking.Booking, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now().UTC()
+	b, t, err := lockRentForExtension(ctx, tx, bookingID, ownerID, fsm.ActionExtendRespond, fsm.RoleOwner, now)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+	if b.End == nil {
+		return booking.Booking{}, booking.ErrInvalidState
+	}
+
+	newEnd := b.Extension.End
+	// проверяем только добавляемый хвост [end, newEnd)
+	busy, err := rentConflictTx(ctx, tx, b.ItemID, *b.End, newEnd, b.ID)
+	if err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: extension conflict check: %w", err)
+	}
+	if busy {
+		return booking.Booking{}, booking.ErrConflict
+	}
+	held, err := waitlistHoldTx(ctx, tx, b.ItemID, b.RequesterID, *b.End, newEnd)
+	if err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: extension: %w", err)
+	}
+	if held {
+		return booking.Booking{}, booking.ErrWaitlistHold
+	}
+	total, err := requoteRent(b, *b.Start, newEnd)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+
+	// продление уже просроченной аренды: если новый конец впереди, просрочки больше нет
+	// (MarkOverdueReturns отметит заново, если не вернут и к новому сроку); иначе просрочка
+	// отсчитывается от нового end_at и пересчитается при следующем проходе
+	const updQ = `
+	UPDATE bookings
+	SET end_at = $2,
+	    extension_end_at = NULL,
+	    extension_requested_at = NULL,
+	    overdue_since = CASE
+	        WHEN $2::timestamptz > $3::timestamptz OR overdue_since IS NULL THEN NULL
+	        ELSE $2::timestamptz
+	    END,
+	    overdue_days = CASE
+	        WHEN $2::timestamptz > $3::timestamptz OR overdue_since IS NULL THEN 0
+	        ELSE GREATEST(0, CEIL(EXTRACT(EPOCH FROM ($3::timestamptz - $2::timestamptz)) / 86400)::int)
+	    END,
+	    price_total = $4
+	WHERE id = $1
+	RETURNING ` + selectBookingCols + `
+	`
+	var out booking.Booking
+	if err := scanBooking(tx.QueryRow(ctx, updQ, b.ID, newEnd, now, total), &out); err != nil {
+		if isExclusionViolation(err) {
+			return booking.Booking{}, booking.ErrConflict
+		}
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: extension approve update: %w", err)
+	}
+	if err := booking.CheckResult(t, out.Status); err != nil {
+		return booking.Booking{}, err
+	}
+
+	meta, _ := json.Marshal(map[string]any{
+		"old_end":         b.End,
+		"new_end":         out.End,
+		"old_price_total": b.PriceTotal,
+		"new_price_total": out.PriceTotal,
+	})
+	actor := ownerID
+	if err := r.eventRepo.InsertBookingEvent(ctx, tx, out.ID, &actor, "extend_approve", nil, nil, meta); err != nil {
+		return booking.Booking{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return out, nil
+}
+
+// RejectExtension — владелец отклоняет продление, end_at не меняется.
+func (r *Repo) RejectExtension(ctx context.Context, bookingID, ownerID int64) (booking.Booking, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	b, t, err := lockRentForExtension(ctx, tx, bookingID, ownerID, fsm.ActionExtendRespond, fsm.RoleOwner, time.Now().UTC())
+	if err != nil {
+		return booking.Booking{}, err
+	}
+
+	const updQ = `
+	UPDATE bookings
+	SET extension_end_at = NULL,
+	    extension_requested_at = NULL
+	WHERE id = $1
+	RETURNING ` + selectBookingCols + `
+	`
+	var out booking.Booking
+	if err := scanBooking(tx.QueryRow(ctx, updQ, b.ID), &out); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: extension reject update: %w", err)
+	}
+	if err := booking.CheckResult(t, out.Status); err != nil {
+		return booking.Booking{}, err
+	}
+
+	meta, _ := json.Marshal(map[string]any{
+		"old_end": b.End,
+		"new_end": b.Extension.End,
+	})
+	actor := ownerID
+	if err := r.eventRepo.InsertBookingEvent(ctx, tx, out.ID, &actor, "extend_reject", nil, nil, meta); err != nil {
+		return booking.Booking{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,239 @@
+package pgrepo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/booking/fsm"
+)
+
+type rangeMeta struct {
+	Start *time.Time `json:"start"`
+	End   *time.Time `json:"end"`
+}
+
+// lockRentForReschedule — берёт бронь FOR UPDATE и проверяет действие переноса по fsm:
+// guard'ы таблицы учитывают, чьё предложение ожидает ответа.
+func lockRentForReschedule(ctx context.Context, tx pgx.Tx, bookingID, actorID int64, action fsm.Action, now time.Time) (booking.Booking, fsm.Transition, error) {
+	const selectQ = `
+	SELECT ` + selectBookingCols + `
+	FROM bookings
+	WHERE id = $1
+	FOR UPDATE
+	`
+	var b booking.Booking
+	if err := scanBooking(tx.QueryRow(ctx, selectQ, bookingID), &b); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return booking.Booking{}, fsm.Transition{}, booking.ErrNotFound
+		}
+		return booking.Booking{}, fsm.Transition{}, fmt.Errorf("bookings pgrepo: reschedule select: %w", err)
+	}
+
+	if actorID != b.OwnerID && actorID != b.RequesterID {
+		return booking.Booking{}, fsm.Transition{}, booking.ErrForbidden
+	}
+	if b.Type != booking.TypeRent {
+		return booking.Booking{}, fsm.Transition{}, booking.ErrInvalidState
+	}
+	t, err := booking.CheckAction(b, action, b.RoleOf(actorID), b.Facts(now, 0))
+	if err != nil {
+		return booking.Booking{}, fsm.Transition{}, err
+	}
+	return b, t, nil
+}
+
+func rescheduleActorRole(b booking.Booking, actorID int64) string {
+	if actorID == b.OwnerID {
+		return "owner"
+	}
+	return "requester"
+}
+
+// ProposeReschedule — requester или owner предлагает новые даты; новое предложение заменяет
+// только своё же, пока ожидает ответа предложение второй стороны — ErrInvalidState.
+func (r *Repo) ProposeReschedule(ctx context.Context, bookingID, actorID int64, start, end time.Time, now time.Time) (booking.Booking, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	b, t, err := lockRentForReschedule(ctx, tx, bookingID, actorID, fsm.ActionReschedule, now)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+	if b.Start != nil && b.End != nil && b.Start.Equal(start) && b.End.Equal(end) {
+		return booking.Booking{}, booking.ErrInvalidState
+	}
+
+	const updQ = `
+	UPDATE bookings
+	SET reschedule_start_at = $2,
+	    reschedule_end_at = $3,
+	    reschedule_proposed_by = $4,
+	    reschedule_proposed_at = $5
+	WHERE id = $1
+	RETURNING ` + selectBookingCols + `
+	`
+	var out booking.Booking
+	if err := scanBooking(tx.QueryRow(ctx, updQ, b.ID, start, end, actorID, now), &out); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: reschedule propose update: %w", err)
+	}
+	if err := booking.CheckResult(t, out.Status); err != nil {
+		return booking.Booking{}, err
+	}
+
+	meta, _ := json.Marshal(map[string]any{
+		"by":  rescheduleActorRole(b, actorID),
+		"old": rangeMeta{Start: b.Start, End: b.End},
+		"new": rangeMeta{Start: &start, End: &end},
+	})
+	actor := actorID
+	if err := r.eventRepo.InsertBookingEvent(ctx, tx, out.ID, &actor, "reschedule_propose", nil, nil, meta); err != nil {
+		return booking.Booking{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return out, nil
+}
+
+// AcceptReschedule — вторая сторона принимает перенос; пересечения проверяются на момент принятия,
+// price_total пересчитывается по снимку цены на новые даты.
+func (r *Repo) AcceptReschedule(ctx context.Context, bookingID, actorID int64) (booking.Booking, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// принять может только тот, кто не предлагал (guard fsm.ActionRescheduleRespond)
+	b, t, err := lockRentForReschedule(ctx, tx, bookingID, actorID, fsm.ActionRescheduleRespond, time.Now().UTC())
+	if err != nil {
+		return booking.Booking{}, err
+	}
+
+	start := b.Reschedule.Start
+	end := b.Reschedule.End
+
+	busy, err := rentConflictTx(ctx, tx, b.ItemID, start, end, b.ID)
+	if err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: reschedule conflict check: %w", err)
+	}
+	if busy {
+		return booking.Booking{}, booking.ErrConflict
+	}
+	held, err := waitlistHoldTx(ctx, tx, b.ItemID, b.RequesterID, start, end)
+	if err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: reschedule: %w", err)
+	}
+	if held {
+		return booking.Booking{}, booking.ErrWaitlistHold
+	}
+
+	total, err := requoteRent(b, start, end)
+	if err != nil {
+		return booking.Booking{}, err
+	}
+
+	// у одобренной брони дедлайн передачи считается от start_at (как в ApproveRent)
+	deadline := b.HandoverDeadline
+	if b.Status == booking.StatusApproved {
+		d := start.Add(24 * time.Hour)
+		deadline = &d
+	}
+
+	const updQ = `
+	UPDATE bookings
+	SET start_at = $2,
+	    end_at = $3,
+	    handover_deadline = $4,
+	    price_total = $5,
+	    reschedule_start_at = NULL,
+	    reschedule_end_at = NULL,
+	    reschedule_proposed_by = NULL,
+	    reschedule_proposed_at = NULL
+	WHERE id = $1
+	RETURNING ` + selectBookingCols + `
+	`
+	var out booking.Booking
+	if err := scanBooking(tx.QueryRow(ctx, updQ, b.ID, start, end, deadline, total), &out); err != nil {
+		if isExclusionViolation(err) {
+			return booking.Booking{}, booking.ErrConflict
+		}
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: reschedule accept update: %w", err)
+	}
+	if err := booking.CheckResult(t, out.Status); err != nil {
+		return booking.Booking{}, err
+	}
+
+	meta, _ := json.Marshal(map[string]any{
+		"by":              rescheduleActorRole(b, actorID),
+		"old":             rangeMeta{Start: b.Start, End: b.End},
+		"new":             rangeMeta{Start: out.Start, End: out.End},
+		"old_price_total": b.PriceTotal,
+		"new_price_total": out.PriceTotal,
+	})
+	actor := actorID
+	if err := r.eventRepo.InsertBookingEvent(ctx, tx, out.ID, &actor, "reschedule_accept", nil, nil, meta); err != nil {
+		return booking.Booking{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return out, nil
+}
+
+// RejectReschedule — вторая сторона отклоняет перенос, даты остаются прежними.
+func (r *Repo) RejectReschedule(ctx context.Context, bookingID, actorID int64) (booking.Booking, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	b, t, err := lockRentForReschedule(ctx, tx, bookingID, actorID, fsm.ActionRescheduleRespond, time.Now().UTC())
+	if err != nil {
+		return booking.Booking{}, err
+	}
+
+	const updQ = `
+	UPDATE bookings
+	SET reschedule_start_at = NULL,
+	    reschedule_end_at = NULL,
+	    reschedule_proposed_by = NULL,
+	    reschedule_proposed_at = NULL
+	WHERE id = $1
+	RETURNING ` + selectBookingCols + `
+	`
+	var out booking.Booking
+	if err := scanBooking(tx.QueryRow(ctx, updQ, b.ID), &out); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: reschedule reject update: %w", err)
+	}
+	if err := booking.CheckResult(t, out.Status); err != nil {
+		return booking.Booking{}, err
+	}
+
+	meta, _ := json.Marshal(map[string]any{
+		"by":  rescheduleActorRole(b, actorID),
+		"old": rangeMeta{Start: b.Start, End: b.End},
+		"new": rangeMeta{Start: &b.Reschedule.Start, End: &b.Reschedule.End},
+	})
+	actor := actorID
+	if err := r.eventRepo.InsertBookingEvent(ctx, tx, out.ID, &actor, "reschedule_reject", nil, nil, meta); err != nil {
+		return booking.Booking{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return booking.Booking{}, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,349 @@
+package pgrepo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/outbox"
+	outboxpg "github.com/SHILOP0P/Yardly/backend/internal/outbox/pgrepo"
+)
+
+// WaitlistRepo — очередь ожидания; продвигается внутри транзакций, которые освобождают даты.
+type WaitlistRepo struct {
+	window time.Duration // сколько даты придержаны за уведомлённым
+	events *EventRepo
+}
+
+func NewWaitlistRepo(window time.Duration) *WaitlistRepo {
+	if window <= 0 {
+		window = 24 * time.Hour
+	}
+	return &WaitlistRepo{window: window, events: NewEventRepo()}
+}
+
+const selectWaitlistCols = `
+	id, item_id, user_id, start_at, end_at, status,
+	notified_at, priority_until, booking_id, created_at
+`
+
+func scanWaitlist(rs rowScanrer, e *booking.WaitlistEntry) error {
+	return rs.Scan(
+		&e.ID,
+		&e.ItemID,
+		&e.UserID,
+		&e.Start,
+		&e.End,
+		&e.Status,
+		&e.NotifiedAt,
+		&e.PriorityUntil,
+		&e.BookingID,
+		&e.CreatedAt,
+	)
+}
+
+// promoteTx — после освобождения дат вещи выдаёт приоритетное окно ожидающим в порядке очереди.
+// Запись пропускается, если её даты всё ещё заняты или пересекаются с окном того, кто стоит раньше.
+func (w *WaitlistRepo) promoteTx(ctx context.Context, tx pgx.Tx, itemID int64, now time.Time) (int, error) {
+	const q = `
+	SELECT ` + selectWaitlistCols + `
+	FROM booking_waitlist
+	WHERE item_id = $1
+	  AND status IN ('waiting','notified')
+	ORDER BY id
+	FOR UPDATE
+	`
+	rows, err := tx.Query(ctx, q, itemID)
+	if err != nil {
+		return 0, fmt.Errorf("waitlist promote select: %w", err)
+	}
+	entries := make([]booking.WaitlistEntry, 0, 8)
+	for rows.Next() {
+		var e booking.WaitlistEntry
+		if err := scanWaitlist(rows, &e); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("waitlist promote scan: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("waitlist promote rows: %w", err)
+	}
+	rows.Close()
+
+	held := make([]booking.WaitlistEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.Status == booking.WaitlistNotified && e.PriorityUntil != nil && e.PriorityUntil.After(now) {
+			held = append(held, e)
+		}
+	}
+
+	const notifyQ = `
+	UPDATE booking_waitlist
+	SET status = 'notified', notified_at = $2, priority_until = $3
+	WHERE id = $1
+	`
+	n := 0
+	for _, e := range entries {
+		if e.Status != booking.WaitlistWaiting || !e.Start.After(now) {
+			continue
+		}
+		if overlapsAny(e, held) {
+			continue
+		}
+		busy, err := rentConflictTx(ctx, tx, itemID, e.Start, e.End, 0)
+		if err != nil {
+			return 0, fmt.Errorf("waitlist promote conflict check: %w", err)
+		}
+		if busy {
+			continue
+		}
+		until := now.Add(w.window)
+		if _, err := tx.Exec(ctx, notifyQ, e.ID, now, until); err != nil {
+			return 0, fmt.Errorf("waitlist promote notify: %w", err)
+		}
+		// уведомление в той же транзакции: SSE адресату и outbox (вебхуки и другие потребители)
+		meta, _ := json.Marshal(map[string]any{
+			"waitlist_id":    e.ID,
+			"item_id":        e.ItemID,
+			"start":          e.Start,
+			"end":            e.End,
+			"priority_until": until,
+		})
+		if err := w.events.InsertUserEvent(ctx, tx, e.UserID, "waitlist_offer", meta); err != nil {
+			return 0, err
+		}
+		if err := outboxpg.AppendWaitlistEventTx(ctx, tx, e.ID, outbox.EventWaitlistOffered); err != nil {
+			return 0, err
+		}
+		e.PriorityUntil = &until
+		held = append(held, e)
+		n++
+	}
+	return n, nil
+}
+
+func overlapsAny(e booking.WaitlistEntry, list []booking.WaitlistEntry) bool {
+	for _, o := range list {
+		if o.Start.Before(e.End) && o.End.After(e.Start) {
+			return true
+		}
+	}
+	return false
+}
+
+// waitlistHoldTx — даты придержаны за другим уведомлённым пользователем.
+func waitlistHoldTx(ctx context.Context, tx pgx.Tx, itemID, requesterID int64, start, end time.Time) (bool, error) {
+	const q = `
+	SELECT EXISTS (
+		SELECT 1
+		FROM booking_waitlist
+		WHERE item_id = $1
+		  AND user_id <> $2
+		  AND status = 'notified'
+		  AND priority_until > now()
+		  AND start_at < $4
+		  AND end_at   > $3
+	)
+	`
+	var held bool
+	if err := tx.QueryRow(ctx, q, itemID, requesterID, start, end).Scan(&held); err != nil {
+		return false, fmt.Errorf("waitlist hold check: %w", err)
+	}
+	return held, nil
+}
+
+// markWaitlistBookedTx — пользователь забронировал даты, на которые ждал.
+func markWaitlistBookedTx(ctx context.Context, tx pgx.Tx, b *booking.Booking) error {
+	const q = `
+	UPDATE booking_waitlist
+	SET status = 'booked', booking_id = $3
+	WHERE item_id = $1
+	  AND user_id = $2
+	  AND status IN ('waiting','notified')
+	  AND start_at < $5
+	  AND end_at   > $4
+	`
+	if _, err := tx.Exec(ctx, q, b.ItemID, b.RequesterID, b.ID, b.Start, b.End); err != nil {
+		return fmt.Errorf("waitlist mark booked: %w", err)
+	}
+	return nil
+}
+
+// JoinWaitlist — встать в очередь можно только на действительно занятые даты.
+func (r *Repo) JoinWaitlist(ctx context.Context, e *booking.WaitlistEntry) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	busy, err := rentConflictTx(ctx, tx, e.ItemID, e.Start, e.End, 0)
+	if err != nil {
+		return fmt.Errorf("bookings pgrepo: waitlist conflict check: %w", err)
+	}
+	if !busy {
+		busy, err = waitlistHoldTx(ctx, tx, e.ItemID, e.UserID, e.Start, e.End)
+		if err != nil {
+			return err
+		}
+	}
+	if !busy {
+		return booking.ErrNotBusy
+	}
+
+	const q = `
+	INSERT INTO booking_waitlist (item_id, user_id, start_at, end_at)
+	VALUES ($1, $2, $3, $4)
+	RETURNING ` + selectWaitlistCols + `
+	`
+	if err := scanWaitlist(tx.QueryRow(ctx, q, e.ItemID, e.UserID, e.Start, e.End), e); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return booking.ErrAlreadyWaiting
+		}
+		return fmt.Errorf("bookings pgrepo: waitlist insert: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) ListMyWaitlist(ctx context.Context, userID int64) ([]booking.WaitlistEntry, error) {
+	const q = `
+	SELECT ` + selectWaitlistCols + `
+	FROM booking_waitlist
+	WHERE user_id = $1
+	ORDER BY created_at DESC, id DESC
+	LIMIT 100
+	`
+	rows, err := r.pool.Query(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("bookings pgrepo: list waitlist: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]booking.WaitlistEntry, 0, 8)
+	for rows.Next() {
+		var e booking.WaitlistEntry
+		if err := scanWaitlist(rows, &e); err != nil {
+			return nil, fmt.Errorf("bookings pgrepo: list waitlist scan: %w", err)
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("bookings pgrepo: list waitlist rows: %w", err)
+	}
+	return out, nil
+}
+
+// LeaveWaitlist — выход из очереди; если у пользователя было окно, оно переходит следующим.
+func (r *Repo) LeaveWaitlist(ctx context.Context, entryID, userID int64) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const q = `
+	WITH prev AS (
+		SELECT id, status
+		FROM booking_waitlist
+		WHERE id = $1 AND user_id = $2 AND status IN ('waiting','notified')
+		FOR UPDATE
+	)
+	UPDATE booking_waitlist w
+	SET status = 'cancelled'
+	FROM prev
+	WHERE w.id = prev.id
+	RETURNING w.item_id, prev.status = 'notified'
+	`
+	var itemID int64
+	var wasNotified bool
+	if err := tx.QueryRow(ctx, q, entryID, userID).Scan(&itemID, &wasNotified); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return booking.ErrNotFound
+		}
+		return fmt.Errorf("bookings pgrepo: leave waitlist: %w", err)
+	}
+	if wasNotified {
+		if _, err := r.waitlistRepo.promoteTx(ctx, tx, itemID, time.Now().UTC()); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return nil
+}
+
+// ExpireWaitlistOffers — закрывает просроченные окна (даты переходят следующему в очереди)
+// и записи, чьи даты уже начались.
+func (r *Repo) ExpireWaitlistOffers(ctx context.Context, now time.Time) (int64, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("bookings pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const offersQ = `
+	UPDATE booking_waitlist
+	SET status = 'expired'
+	WHERE status = 'notified'
+	  AND priority_until <= $1
+	RETURNING item_id
+	`
+	rows, err := tx.Query(ctx, offersQ, now)
+	if err != nil {
+		return 0, fmt.Errorf("bookings pgrepo: waitlist expire offers: %w", err)
+	}
+	items := make(map[int64]struct{}, 8)
+	var n int64
+	for rows.Next() {
+		var itemID int64
+		if err := rows.Scan(&itemID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("bookings pgrepo: waitlist expire scan: %w", err)
+		}
+		items[itemID] = struct{}{}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("bookings pgrepo: waitlist expire rows: %w", err)
+	}
+	rows.Close()
+
+	const staleQ = `
+	UPDATE booking_waitlist
+	SET status = 'expired'
+	WHERE status = 'waiting'
+	  AND start_at <= $1
+	`
+	ct, err := tx.Exec(ctx, staleQ, now)
+	if err != nil {
+		return 0, fmt.Errorf("bookings pgrepo: waitlist expire stale: %w", err)
+	}
+	n += ct.RowsAffected()
+
+	for itemID := range items {
+		if _, err := r.waitlistRepo.promoteTx(ctx, tx, itemID, now); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("bookings pgrepo: commit: %w", err)
+	}
+	return n, nil
+}
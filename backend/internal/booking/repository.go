@@ -6,31 +6,60 @@ import ("context"
 
 type Repo interface{
 	Create(ctx context.Context, b *Booking) error
+	CreateAndApprove(ctx context.Context, b *Booking, now time.Time) error
+	CountCompletedByRequester(ctx context.Context, requesterID int64) (int, error)
 
 	GetByID(ctx context.Context, id int64) (Booking, error)
 
 	ListByItem(ctx context.Context, itemID int64) ([]Booking, error)
 	ListMyBookings(ctx context.Context, requesterID int64, statuses[]Status, limit, offset int)([]Booking, error)
-	ListMyItemsBookings(ctx context.Context, ownerID int64, statuses[]Status, limit, offset int)([]Booking, error)
+	ListMyItemsBookings(ctx context.Context, ownerID int64, statuses[]Status, overdueOnly bool, limit, offset int)([]Booking, error)
 	ListMyItemsBookingRequests(ctx context.Context, ownerID int64, types []Type, limit, offset int) ([]Booking, error)
 
 
 	ListUpcomingByItem(ctx context.Context, itemID int64, now time.Time, limit int) (inUse *Booking, upcoming []Booking, err error)
 	ListBusyDaysByItem(ctx context.Context, itemID int64, fromDay, toDay time.Time) ([]DayRange, bool, error)
+	ListBusySlotsByItem(ctx context.Context, itemID int64, from, to time.Time) ([]TimeRange, error)
 
 	ApproveRent(ctx context.Context, bookingID int64, ownerID int64)(Booking, error)
+	DeclineRequest(ctx context.Context, bookingID int64, ownerID int64, reason *string) (Booking, error)
+	// ApproveWithDeclined — одобрение любого типа вместе со списком авто-отклонённых конкурентов (для массовой обработки).
+	ApproveWithDeclined(ctx context.Context, bookingID, ownerID int64, now time.Time) (Booking, []Booking, error)
 	ReturnRent(ctx context.Context, bookingID int64, actorID int64, now time.Time)(Booking, error)
 	HandoverRent(ctx context.Context, bookingID int64, actorID int64, now time.Time) (Booking, error)
 	
 	ExpireOverdueHandovers(ctx context.Context, now time.Time) (int64, error)
+	MarkOverdueReturns(ctx context.Context, now time.Time) (int64, error)
 	CancelRent(ctx context.Context, bookingID, requesterID int64) (Booking, error)
+	CancelByOwner(ctx context.Context, bookingID, ownerID int64, reason string, now time.Time, cutoff time.Duration) (Booking, error)
 
 	ListEvents(ctx context.Context, bookingID int64, limit, offset int) ([]Event, error)
+	GetDeposit(ctx context.Context, bookingID int64) (Deposit, error)
+
+	//Reschedule
+	ProposeReschedule(ctx context.Context, bookingID, actorID int64, start, end time.Time, now time.Time) (Booking, error)
+	AcceptReschedule(ctx context.Context, bookingID, actorID int64) (Booking, error)
+	RejectReschedule(ctx context.Context, bookingID, actorID int64) (Booking, error)
+
+	//Extension
+	RequestExtension(ctx context.Context, bookingID, requesterID int64, end time.Time, now time.Time) (Booking, error)
+	ApproveExtension(ctx context.Context, bookingID, ownerID int64) (Booking, error)
+	RejectExtension(ctx context.Context, bookingID, ownerID int64) (Booking, error)
 
 	//Transfer
 	ApproveTransfer(ctx context.Context, bookingID int64, ownerID int64, now time.Time) (Booking, error)
 	HandoverTransfer(ctx context.Context, bookingID int64, actorID int64, now time.Time) (Booking, error)
 	CancelTransfer(ctx context.Context, bookingID, requesterID int64) (Booking, error)
 
+	//Confirmation codes
+	IssueConfirmationCode(ctx context.Context, bookingID, actorID int64, purpose CodePurpose, codeHash string, now time.Time) (time.Time, error)
+	ConfirmWithCode(ctx context.Context, bookingID, actorID int64, purpose CodePurpose, code string, now time.Time) (Booking, error)
+
+	//Waitlist
+	JoinWaitlist(ctx context.Context, e *WaitlistEntry) error
+	ListMyWaitlist(ctx context.Context, userID int64) ([]WaitlistEntry, error)
+	LeaveWaitlist(ctx context.Context, entryID, userID int64) error
+	ExpireWaitlistOffers(ctx context.Context, now time.Time) (int64, error)
+
 
 }
\ No newline at end of file
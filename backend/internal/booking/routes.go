@@ -6,8 +6,8 @@ import (
 
 type Middleware func(http.Handler) http.Handler
 
-func RegisterRoutes(mux *http.ServeMux, repo Repo, items ItemGetter, authMw Middleware){//тут остановился
-	h := NewHandler(repo, items)
+func RegisterRoutes(mux *http.ServeMux, repo Repo, items ItemGetter, cfg Config, authMw Middleware){//тут остановился
+	h := NewHandler(repo, items, cfg)
 
 	mux.Handle("POST /api/items/{id}/bookings", authMw(http.HandlerFunc(h.Create)))
 	mux.HandleFunc("GET /api/items/{id}/bookings", h.ListBusyForItem)
@@ -20,15 +20,34 @@ func RegisterRoutes(mux *http.ServeMux, repo Repo, items ItemGetter, authMw Midd
 	mux.Handle("GET /api/items/{id}/bookings/upcoming", http.HandlerFunc(h.UpcomingByItem))
 	mux.Handle("GET /api/items/{id}/availability", http.HandlerFunc(h.AvailabilityByItem))
 
+	mux.Handle("POST /api/items/{id}/waitlist", authMw(http.HandlerFunc(h.JoinWaitlist)))
+	mux.Handle("GET /api/my/waitlist", authMw(http.HandlerFunc(h.ListMyWaitlist)))
+	mux.Handle("DELETE /api/my/waitlist/{id}", authMw(http.HandlerFunc(h.LeaveWaitlist)))
 
 
+
+	mux.Handle("POST /api/bookings/bulk", authMw(http.HandlerFunc(h.Bulk)))
 	mux.Handle("POST /api/bookings/{id}/approve", authMw(http.HandlerFunc(h.Approve)))
+	mux.Handle("POST /api/bookings/{id}/decline", authMw(http.HandlerFunc(h.Decline)))
 	mux.Handle("POST /api/bookings/{id}/return", authMw(http.HandlerFunc(h.Return)))
 	mux.Handle("POST /api/bookings/{id}/handover", authMw(http.HandlerFunc(h.Handover)))
+	mux.Handle("POST /api/bookings/{id}/handover/code", authMw(http.HandlerFunc(h.IssueHandoverCode)))
+	mux.Handle("POST /api/bookings/{id}/return/code", authMw(http.HandlerFunc(h.IssueReturnCode)))
 
 	mux.Handle("POST /api/bookings/{id}/cancel", authMw(http.HandlerFunc(h.Cancel)))
+	mux.Handle("GET /api/bookings/{id}/cancel-preview", authMw(http.HandlerFunc(h.CancelPreview)))
+
+	mux.Handle("POST /api/bookings/{id}/reschedule", authMw(http.HandlerFunc(h.Reschedule)))
+	mux.Handle("POST /api/bookings/{id}/reschedule/accept", authMw(http.HandlerFunc(h.AcceptReschedule)))
+	mux.Handle("POST /api/bookings/{id}/reschedule/reject", authMw(http.HandlerFunc(h.RejectReschedule)))
+
+	mux.Handle("POST /api/bookings/{id}/extend", authMw(http.HandlerFunc(h.Extend)))
+	mux.Handle("POST /api/bookings/{id}/extend/approve", authMw(http.HandlerFunc(h.ApproveExtension)))
+	mux.Handle("POST /api/bookings/{id}/extend/reject", authMw(http.HandlerFunc(h.RejectExtension)))
 
 	
 	mux.Handle("GET /api/bookings/{id}/events", authMw(http.HandlerFunc(h.ListEvents)))
+	mux.Handle("GET /api/bookings/{id}/deposit", authMw(http.HandlerFunc(h.Deposit)))
+	mux.Handle("GET /api/bookings/{id}/actions", authMw(http.HandlerFunc(h.Actions)))
 
 }
\ No newline at end of file
@@ -0,0 +1,81 @@
+package booking
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/booking/fsm"
+)
+
+// RoleOf — роль пользователя в брони; пустая строка, если он не участник.
+func (b Booking) RoleOf(userID int64) fsm.Role {
+	switch userID {
+	case b.OwnerID:
+		return fsm.RoleOwner
+	case b.RequesterID:
+		return fsm.RoleRequester
+	default:
+		return ""
+	}
+}
+
+// Facts — данные брони для guard'ов таблицы переходов. CodeRequired берётся из вещи,
+// его заполняет вызывающий там, где это влияет на ответ (передача, возврат, список действий).
+func (b Booking) Facts(now time.Time, ownerCancelCutoff time.Duration) fsm.Facts {
+	f := fsm.Facts{
+		Now:               now,
+		Start:             b.Start,
+		HandoverDeadline:  b.HandoverDeadline,
+		Frozen:            b.FrozenAt != nil,
+		OwnerCancelCutoff: ownerCancelCutoff,
+		ExtensionPending:  b.Extension != nil,
+	}
+	if b.Reschedule != nil {
+		f.RescheduleBy = b.RoleOf(b.Reschedule.ProposedBy)
+	}
+	return f
+}
+
+// CheckAction — проверка действия по fsm с ошибками пакета booking (их понимает writeBookingError).
+func CheckAction(b Booking, action fsm.Action, role fsm.Role, f fsm.Facts) (fsm.Transition, error) {
+	t, err := fsm.Check(fsm.Kind(b.Type), fsm.State(b.Status), action, role, f)
+	if err != nil {
+		return fsm.Transition{}, fromFSMError(err)
+	}
+	return t, nil
+}
+
+// CheckResult — итоговый статус после UPDATE должен быть одним из разрешённых переходом.
+func CheckResult(t fsm.Transition, to Status) error {
+	if !t.Leads(fsm.State(to)) {
+		return fmt.Errorf("booking fsm: %s %s -> %s is not in transition table", t.Kind, t.From, to)
+	}
+	return nil
+}
+
+// AvailableActions — что пользователь может сделать с бронью прямо сейчас при фактах f.
+func AvailableActions(b Booking, userID int64, f fsm.Facts) []fsm.Action {
+	role := b.RoleOf(userID)
+	if role == "" {
+		return []fsm.Action{}
+	}
+	return fsm.Available(fsm.Kind(b.Type), fsm.State(b.Status), role, f)
+}
+
+func fromFSMError(err error) error {
+	switch {
+	case errors.Is(err, fsm.ErrRole):
+		return ErrForbidden
+	case errors.Is(err, fsm.ErrFrozen):
+		return ErrFrozen
+	case errors.Is(err, fsm.ErrCancelCutoff):
+		return ErrCancelCutoff
+	case errors.Is(err, fsm.ErrCodeRequired):
+		return ErrCodeRequired
+	case errors.Is(err, fsm.ErrNotAllowed), errors.Is(err, fsm.ErrPending), errors.Is(err, fsm.ErrNothingPending):
+		return ErrInvalidState
+	default:
+		return fmt.Errorf("%w: %v", ErrInvalidState, err)
+	}
+}
@@ -0,0 +1,27 @@
+package booking
+
+import "time"
+
+type WaitlistStatus string
+
+const (
+	WaitlistWaiting   WaitlistStatus = "waiting"   // даты заняты, ждём
+	WaitlistNotified  WaitlistStatus = "notified"  // даты освободились, до priority_until придержаны для пользователя
+	WaitlistBooked    WaitlistStatus = "booked"    // пользователь создал бронь на эти даты
+	WaitlistExpired   WaitlistStatus = "expired"   // окно прошло или даты уже в прошлом
+	WaitlistCancelled WaitlistStatus = "cancelled" // пользователь сам вышел из очереди
+)
+
+// WaitlistEntry — запись в листе ожидания на [Start, End) вещи.
+type WaitlistEntry struct {
+	ID            int64          `json:"id"`
+	ItemID        int64          `json:"item_id"`
+	UserID        int64          `json:"user_id"`
+	Start         time.Time      `json:"start"`
+	End           time.Time      `json:"end"`
+	Status        WaitlistStatus `json:"status"`
+	NotifiedAt    *time.Time     `json:"notified_at,omitempty"`
+	PriorityUntil *time.Time     `json:"priority_until,omitempty"`
+	BookingID     *int64         `json:"booking_id,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
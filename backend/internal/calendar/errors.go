@@ -0,0 +1,17 @@
+package calendar
+
+import "errors"
+
+var (
+	ErrNotFound  = errors.New("calendar feed not found")
+	ErrForbidden = errors.New("forbidden")
+
+	ErrInvalidICS = errors.New("invalid ics")
+	ErrFetch      = errors.New("failed to fetch calendar")
+	ErrTooLarge   = errors.New("calendar is too large")
+
+	ErrUnsupportedRecurrence = errors.New("some recurring events use unsupported rules: only their listed dates are blocked")
+
+	ErrInvalidURL = errors.New("url must be http or https")
+	ErrBlockedURL = errors.New("url points to a private or reserved address")
+)
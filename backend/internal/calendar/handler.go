@@ -0,0 +1,358 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/auth"
+	"github.com/SHILOP0P/Yardly/backend/internal/httpx"
+	"github.com/SHILOP0P/Yardly/backend/internal/item"
+	"github.com/SHILOP0P/Yardly/backend/internal/netguard"
+)
+
+type Handler struct {
+	repo   Repo
+	items  ItemGetter
+	guard  *netguard.Guard
+	syncer *Syncer
+}
+
+type ItemGetter interface {
+	GetByID(ctx context.Context, id int64) (item.Item, error)
+}
+
+func NewHandler(repo Repo, items ItemGetter, guard *netguard.Guard) *Handler {
+	return &Handler{repo: repo, items: items, guard: guard, syncer: NewSyncer(repo, guard)}
+}
+
+type feedResponse struct {
+	Feed
+	URL string `json:"url"`
+}
+
+func feedURL(r *http.Request, token string) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/api/calendar/%s.ics", scheme, r.Host, token)
+}
+
+// ownedItemID — {id} из пути, если текущий пользователь владелец вещи.
+func (h *Handler) ownedItemID(w http.ResponseWriter, r *http.Request, userID int64) (int64, bool) {
+	itemID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || itemID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid item id")
+		return 0, false
+	}
+	it, err := h.items.GetByID(r.Context(), itemID)
+	if err != nil {
+		if errors.Is(err, item.ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "item not found")
+			return 0, false
+		}
+		log.Println("calendar get item error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return 0, false
+	}
+	if it.OwnerID != userID {
+		httpx.WriteError(w, http.StatusForbidden, "forbidden")
+		return 0, false
+	}
+	return itemID, true
+}
+
+func (h *Handler) feed(w http.ResponseWriter, r *http.Request, perItem, regenerate bool) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var itemID *int64
+	if perItem {
+		id, ok := h.ownedItemID(w, r, userID)
+		if !ok {
+			return
+		}
+		itemID = &id
+	}
+
+	var f Feed
+	var err error
+	if regenerate {
+		f, err = h.repo.Regenerate(r.Context(), userID, itemID)
+	} else {
+		f, err = h.repo.GetOrCreate(r.Context(), userID, itemID)
+	}
+	if err != nil {
+		log.Println("calendar feed error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, feedResponse{Feed: f, URL: feedURL(r, f.Token)})
+}
+
+// GET /api/items/{id}/calendar-feed
+func (h *Handler) ItemFeed(w http.ResponseWriter, r *http.Request) {
+	h.feed(w, r, true, false)
+}
+
+// POST /api/items/{id}/calendar-feed/regenerate
+func (h *Handler) RegenerateItemFeed(w http.ResponseWriter, r *http.Request) {
+	h.feed(w, r, true, true)
+}
+
+// GET /api/my/calendar-feed — все вещи владельца
+func (h *Handler) OwnerFeed(w http.ResponseWriter, r *http.Request) {
+	h.feed(w, r, false, false)
+}
+
+// POST /api/my/calendar-feed/regenerate
+func (h *Handler) RegenerateOwnerFeed(w http.ResponseWriter, r *http.Request) {
+	h.feed(w, r, false, true)
+}
+
+// GET /api/calendar/{token}.ics — публичная (по секретному токену) лента только для чтения.
+func (h *Handler) ICS(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimSuffix(r.PathValue("file"), ".ics")
+	if token == "" {
+		httpx.WriteError(w, http.StatusNotFound, "not found")
+		return
+	}
+	f, err := h.repo.GetByToken(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "not found")
+			return
+		}
+		log.Println("calendar get by token error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	entries, err := h.repo.ListEntries(r.Context(), f)
+	if err != nil {
+		log.Println("calendar list entries error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	name := "Yardly: мои вещи"
+	if f.ItemID != nil {
+		if it, err := h.items.GetByID(r.Context(), *f.ItemID); err == nil {
+			name = "Yardly: " + it.Title
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	if err := WriteICS(w, name, entries, time.Now().UTC()); err != nil {
+		log.Println("calendar write ics error:", err)
+	}
+}
+
+type createImportRequest struct {
+	URL  string  `json:"url"`
+	Name *string `json:"name,omitempty"`
+}
+
+type importResponse struct {
+	Import Import      `json:"import"`
+	Sync   *SyncResult `json:"sync,omitempty"`
+}
+
+// writeSyncError — детали (тело/статус чужого сервера, адреса) клиенту не отдаём, только PublicSyncError.
+func writeSyncError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidICS), errors.Is(err, ErrTooLarge):
+		httpx.WriteError(w, http.StatusBadRequest, PublicSyncError(err))
+	case errors.Is(err, ErrFetch):
+		httpx.WriteError(w, http.StatusBadGateway, PublicSyncError(err))
+	case errors.Is(err, ErrNotFound):
+		httpx.WriteError(w, http.StatusNotFound, "import not found")
+	default:
+		log.Println("calendar import sync error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// POST /api/items/{id}/calendar-imports — multipart (поле file) или JSON {"url","name"}.
+func (h *Handler) CreateImport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	itemID, ok := h.ownedItemID(w, r, userID)
+	if !ok {
+		return
+	}
+	now := time.Now().UTC()
+	imp := Import{ItemID: itemID, OwnerID: userID}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(maxICSSize); err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, "invalid multipart form")
+			return
+		}
+		file, hdr, err := r.FormFile("file")
+		if err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, "file is required")
+			return
+		}
+		defer file.Close()
+
+		imp.Source = ImportFile
+		name := hdr.Filename
+		if v := strings.TrimSpace(r.FormValue("name")); v != "" {
+			name = v
+		}
+		imp.Name = &name
+
+		// сначала разбираем, чтобы не создавать импорт из мусора
+		events, err := ParseICS(io.LimitReader(file, maxICSSize))
+		if err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, "invalid ics: "+err.Error())
+			return
+		}
+		if err := h.repo.CreateImport(r.Context(), &imp); err != nil {
+			log.Println("calendar create import error:", err)
+			httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		res, err := applyEvents(r.Context(), h.repo, imp, events, now)
+		if err != nil {
+			writeSyncError(w, err)
+			return
+		}
+		h.respondImport(w, r, http.StatusCreated, imp, &res)
+		return
+	}
+
+	var req createImportRequest
+	if err := httpx.ReadJSON(r, &req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	req.URL = strings.TrimSpace(req.URL)
+	if err := ValidateFeedURL(r.Context(), h.guard, req.URL); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	imp.Source = ImportURL
+	imp.URL = &req.URL
+	imp.Name = req.Name
+	if err := h.repo.CreateImport(r.Context(), &imp); err != nil {
+		log.Println("calendar create import error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	// ошибка первой синхронизации не отменяет подписку: она сохранена в last_error и повторится по расписанию
+	res, err := h.syncer.SyncURL(r.Context(), imp, now)
+	if err != nil {
+		h.respondImport(w, r, http.StatusCreated, imp, nil)
+		return
+	}
+	h.respondImport(w, r, http.StatusCreated, imp, &res)
+}
+
+// respondImport — отдаёт импорт в актуальном виде (last_synced_at/last_error).
+func (h *Handler) respondImport(w http.ResponseWriter, r *http.Request, status int, imp Import, res *SyncResult) {
+	if fresh, err := h.repo.GetImport(r.Context(), imp.ItemID, imp.ID); err == nil {
+		imp = fresh
+	}
+	httpx.WriteJSON(w, status, importResponse{Import: imp, Sync: res})
+}
+
+// GET /api/items/{id}/calendar-imports
+func (h *Handler) ListImports(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	itemID, ok := h.ownedItemID(w, r, userID)
+	if !ok {
+		return
+	}
+	out, err := h.repo.ListImports(r.Context(), itemID)
+	if err != nil {
+		log.Println("calendar list imports error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, out)
+}
+
+// ownedImport — импорт из пути, если вещь принадлежит текущему пользователю.
+func (h *Handler) ownedImport(w http.ResponseWriter, r *http.Request) (Import, bool) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return Import{}, false
+	}
+	itemID, ok := h.ownedItemID(w, r, userID)
+	if !ok {
+		return Import{}, false
+	}
+	importID, err := strconv.ParseInt(r.PathValue("importId"), 10, 64)
+	if err != nil || importID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid import id")
+		return Import{}, false
+	}
+	imp, err := h.repo.GetImport(r.Context(), itemID, importID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "import not found")
+			return Import{}, false
+		}
+		log.Println("calendar get import error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return Import{}, false
+	}
+	return imp, true
+}
+
+// POST /api/items/{id}/calendar-imports/{importId}/sync — только для url-импортов; файл загружается заново через create.
+func (h *Handler) SyncImport(w http.ResponseWriter, r *http.Request) {
+	imp, ok := h.ownedImport(w, r)
+	if !ok {
+		return
+	}
+	if imp.Source != ImportURL {
+		httpx.WriteError(w, http.StatusConflict, "file import cannot be re-synced, upload the file again")
+		return
+	}
+	res, err := h.syncer.SyncURL(r.Context(), imp, time.Now().UTC())
+	if err != nil {
+		writeSyncError(w, err)
+		return
+	}
+	h.respondImport(w, r, http.StatusOK, imp, &res)
+}
+
+// DELETE /api/items/{id}/calendar-imports/{importId} — вместе с импортированными блокировками.
+func (h *Handler) DeleteImport(w http.ResponseWriter, r *http.Request) {
+	imp, ok := h.ownedImport(w, r)
+	if !ok {
+		return
+	}
+	if err := h.repo.DeleteImport(r.Context(), imp.ItemID, imp.ID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "import not found")
+			return
+		}
+		log.Println("calendar delete import error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
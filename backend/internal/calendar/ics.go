@@ -0,0 +1,110 @@
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const icsTimeLayout = "20060102T150405Z"
+
+// UID — стабильный идентификатор события для брони: клиенты обновляют событие, а не дублируют его.
+func UID(bookingID int64) string {
+	return fmt.Sprintf("booking-%d@yardly", bookingID)
+}
+
+var statusTitles = map[string]string{
+	"approved":         "одобрено",
+	"handover_pending": "ожидает передачи",
+	"in_use":           "в аренде",
+	"return_pending":   "ожидает возврата",
+}
+
+// WriteICS — VCALENDAR (RFC 5545) с событием на каждую бронь.
+func WriteICS(w io.Writer, name string, entries []Entry, now time.Time) error {
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(fold(s))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Yardly//Bookings//RU")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escapeText(name))
+
+	for _, e := range entries {
+		status := statusTitles[e.Status]
+		if status == "" {
+			status = e.Status
+		}
+		line("BEGIN:VEVENT")
+		line("UID:" + UID(e.BookingID))
+		line("DTSTAMP:" + now.UTC().Format(icsTimeLayout))
+		line("LAST-MODIFIED:" + e.UpdatedAt.UTC().Format(icsTimeLayout))
+		line(fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+		writeRange(line, e.Start, e.End)
+		line("SUMMARY:" + escapeText(e.ItemTitle+" — "+status))
+		line("DESCRIPTION:" + escapeText(fmt.Sprintf("Бронь #%d", e.BookingID)))
+		line("STATUS:CONFIRMED")
+		line("TRANSP:OPAQUE")
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeRange — посуточные брони (границы на полуночи UTC) выводим как события на весь день.
+func writeRange(line func(string), start, end time.Time) {
+	start, end = start.UTC(), end.UTC()
+	if isMidnight(start) && isMidnight(end) {
+		line("DTSTART;VALUE=DATE:" + start.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + end.Format("20060102"))
+		return
+	}
+	line("DTSTART:" + start.Format(icsTimeLayout))
+	line("DTEND:" + end.Format(icsTimeLayout))
+}
+
+func isMidnight(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// fold — строки длиннее 75 октетов переносятся (CRLF + пробел), не разрывая UTF-8 символы.
+func fold(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+	var b strings.Builder
+	n := 0
+	lim := limit
+	for _, r := range s {
+		rl := len(string(r))
+		if n+rl > lim {
+			b.WriteString("\r\n ")
+			n = 0
+			lim = limit - 1 // ведущий пробел тоже считается
+		}
+		b.WriteRune(r)
+		n += rl
+	}
+	return b.String()
+}
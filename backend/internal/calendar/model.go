@@ -0,0 +1,55 @@
+package calendar
+
+import "time"
+
+// Feed — секретная ссылка на ICS-ленту владельца: по одной вещи (ItemID != nil) или по всем его вещам.
+type Feed struct {
+	ID        int64     `json:"id"`
+	OwnerID   int64     `json:"owner_id"`
+	ItemID    *int64    `json:"item_id,omitempty"`
+	Token     string    `json:"token"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Entry — занятая бронь в ленте.
+type Entry struct {
+	BookingID int64
+	ItemID    int64
+	ItemTitle string
+	Status    string
+	Start     time.Time
+	End       time.Time
+	Sequence  int       // число событий брони: растёт при каждом изменении
+	UpdatedAt time.Time // время последнего события
+}
+
+type ImportSource string
+
+const (
+	ImportFile ImportSource = "file"
+	ImportURL  ImportSource = "url"
+)
+
+// Import — внешний календарь, события которого становятся блокировками вещи.
+type Import struct {
+	ID           int64        `json:"id"`
+	ItemID       int64        `json:"item_id"`
+	OwnerID      int64        `json:"owner_id"`
+	Source       ImportSource `json:"source"`
+	URL          *string      `json:"url,omitempty"`
+	Name         *string      `json:"name,omitempty"`
+	LastSyncedAt *time.Time   `json:"last_synced_at,omitempty"`
+	LastError    *string      `json:"last_error,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// SyncResult — итог синхронизации одного импорта.
+type SyncResult struct {
+	ImportID  int64 `json:"import_id"`
+	Upserted  int   `json:"upserted"`
+	Removed   int   `json:"removed"`
+	Conflicts int   `json:"conflicts"` // блокировки, пересекающиеся с уже одобренными арендами
+
+	// повторяющиеся события, правило которых не развернуть: заблокированы только их явные даты
+	Unsupported int `json:"unsupported,omitempty"`
+}
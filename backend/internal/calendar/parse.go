@@ -0,0 +1,275 @@
+package calendar
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ExternalEvent — VEVENT из чужого календаря, который станет блокировкой вещи.
+// У вхождения повторяющегося события RecurrenceID — его исходное начало (RECURRENCE-ID).
+type ExternalEvent struct {
+	UID          string
+	RecurrenceID *time.Time
+	Start        time.Time
+	End          time.Time
+
+	// только у разобранных VEVENT: правило повторения и отмена (для переопределений вхождений)
+	rrule       string
+	rdates      []icsTime
+	exdates     []icsTime
+	loc         *time.Location // зона DTSTART: вхождения считаются в местном времени
+	allDay      bool
+	cancelled   bool
+	unsupported bool // RDATE;VALUE=PERIOD, RECURRENCE-ID;RANGE=THISANDFUTURE
+}
+
+// icsTime — значение DATE или DATE-TIME.
+type icsTime struct {
+	t    time.Time
+	date bool
+}
+
+// Key — идентификатор блокировки: UID, у вхождения повторяющегося события — UID и RECURRENCE-ID.
+func (e ExternalEvent) Key() string {
+	if e.RecurrenceID == nil {
+		return e.UID
+	}
+	return e.UID + "/" + e.RecurrenceID.UTC().Format("20060102T150405Z")
+}
+
+const maxICSLine = 1 << 20
+
+// ParseICS — разбирает VEVENT'ы (DTSTART/DTEND как DATE или DATE-TIME, в т.ч. с TZID).
+// Отменённые события пропускаются, кроме отмен отдельных вхождений (с RECURRENCE-ID).
+// RRULE/RDATE/EXDATE только запоминаются — вхождения разворачивает expandEvents в пределах горизонта.
+func ParseICS(r io.Reader) ([]ExternalEvent, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]ExternalEvent, 0, 16)
+	var (
+		inEvent   bool
+		cancelled bool
+		cur       ExternalEvent
+		endSet    bool
+		allDay    bool
+		seenCal   bool
+		nested    int // VALARM и прочие компоненты внутри VEVENT
+	)
+	for _, raw := range lines {
+		name, params, value := splitProp(raw)
+		switch {
+		case name == "BEGIN" && value == "VCALENDAR":
+			seenCal = true
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent, cancelled, endSet, allDay = true, false, false, false
+			nested = 0
+			cur = ExternalEvent{}
+		case name == "END" && value == "VEVENT":
+			inEvent = false
+			if cur.UID == "" || cur.Start.IsZero() || (cancelled && cur.RecurrenceID == nil) {
+				continue
+			}
+			cur.cancelled = cancelled
+			if !endSet {
+				// RFC 5545: без DTEND событие на дату длится сутки, на время — ноль; ноль нам не нужен
+				if allDay {
+					cur.End = cur.Start.Add(24 * time.Hour)
+				} else {
+					cur.End = cur.Start.Add(time.Hour)
+				}
+			}
+			if !cur.End.After(cur.Start) {
+				continue
+			}
+			out = append(out, cur)
+		case !inEvent:
+			continue
+		case name == "BEGIN":
+			nested++
+		case name == "END" && nested > 0:
+			nested--
+		case nested > 0:
+			continue
+		case name == "UID":
+			cur.UID = value
+		case name == "STATUS":
+			cancelled = strings.EqualFold(value, "CANCELLED")
+		case name == "DTSTART":
+			t, date, err := parseICSTime(params, value)
+			if err != nil {
+				return nil, err
+			}
+			cur.Start, allDay = t, date
+			cur.allDay, cur.loc = date, icsLocation(params)
+		case name == "DTEND":
+			t, _, err := parseICSTime(params, value)
+			if err != nil {
+				return nil, err
+			}
+			cur.End, endSet = t, true
+		case name == "DURATION":
+			if d, ok := parseICSDuration(value); ok && !cur.Start.IsZero() {
+				cur.End, endSet = cur.Start.Add(d), true
+			}
+		case name == "RRULE":
+			cur.rrule = value
+		case name == "RDATE":
+			if params["VALUE"] == "PERIOD" {
+				cur.unsupported = true
+				continue
+			}
+			ts, err := parseICSTimes(params, value)
+			if err != nil {
+				return nil, err
+			}
+			cur.rdates = append(cur.rdates, ts...)
+		case name == "EXDATE":
+			ts, err := parseICSTimes(params, value)
+			if err != nil {
+				return nil, err
+			}
+			cur.exdates = append(cur.exdates, ts...)
+		case name == "RECURRENCE-ID":
+			t, _, err := parseICSTime(params, value)
+			if err != nil {
+				return nil, err
+			}
+			cur.RecurrenceID = &t
+			if strings.EqualFold(params["RANGE"], "THISANDFUTURE") {
+				cur.unsupported = true
+			}
+		}
+	}
+	if !seenCal {
+		return nil, errors.New("not an iCalendar file")
+	}
+	return out, nil
+}
+
+// unfoldLines — склеивает перенесённые строки (CRLF + пробел/таб).
+func unfoldLines(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxICSLine)
+	out := make([]string, 0, 64)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(out) > 0 {
+			out[len(out)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// splitProp — "DTSTART;TZID=Europe/Moscow:20260501T100000" -> имя, параметры, значение.
+func splitProp(line string) (string, map[string]string, string) {
+	i := strings.IndexByte(line, ':')
+	if i < 0 {
+		return strings.ToUpper(line), nil, ""
+	}
+	head, value := line[:i], line[i+1:]
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+// parseICSTimes — список значений через запятую (RDATE, EXDATE).
+func parseICSTimes(params map[string]string, value string) ([]icsTime, error) {
+	parts := strings.Split(value, ",")
+	out := make([]icsTime, 0, len(parts))
+	for _, v := range parts {
+		t, date, err := parseICSTime(params, strings.TrimSpace(v))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, icsTime{t: t, date: date})
+	}
+	return out, nil
+}
+
+// icsLocation — зона TZID; «плавающее» время и неизвестную зону считаем UTC.
+func icsLocation(params map[string]string) *time.Location {
+	if tz := params["TZID"]; tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			return l
+		}
+	}
+	return time.UTC
+}
+
+func parseICSTime(params map[string]string, value string) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.Parse("20060102", value)
+		if err != nil {
+			return time.Time{}, false, errors.New("invalid DATE value: " + value)
+		}
+		return t.UTC(), true, nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return time.Time{}, false, errors.New("invalid DATE-TIME value: " + value)
+		}
+		return t.UTC(), false, nil
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, icsLocation(params))
+	if err != nil {
+		return time.Time{}, false, errors.New("invalid DATE-TIME value: " + value)
+	}
+	return t.UTC(), false, nil
+}
+
+// parseICSDuration — подмножество RFC 5545: P[n]W | P[n]D[T[n]H[n]M[n]S].
+func parseICSDuration(v string) (time.Duration, bool) {
+	v = strings.TrimPrefix(v, "+")
+	if !strings.HasPrefix(v, "P") {
+		return 0, false
+	}
+	v = v[1:]
+	var d time.Duration
+	n := 0
+	inTime := false
+	for _, r := range v {
+		switch {
+		case r >= '0' && r <= '9':
+			n = n*10 + int(r-'0')
+		case r == 'T':
+			inTime = true
+		case r == 'W' && !inTime:
+			d += time.Duration(n) * 7 * 24 * time.Hour
+			n = 0
+		case r == 'D' && !inTime:
+			d += time.Duration(n) * 24 * time.Hour
+			n = 0
+		case r == 'H' && inTime:
+			d += time.Duration(n) * time.Hour
+			n = 0
+		case r == 'M' && inTime:
+			d += time.Duration(n) * time.Minute
+			n = 0
+		case r == 'S' && inTime:
+			d += time.Duration(n) * time.Second
+			n = 0
+		default:
+			return 0, false
+		}
+	}
+	return d, d > 0
+}
@@ -0,0 +1,152 @@
+package pgrepo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/calendar"
+)
+
+type Repo struct {
+	pool *pgxpool.Pool
+}
+
+func New(pool *pgxpool.Pool) *Repo {
+	return &Repo{pool: pool}
+}
+
+const selectFeedCols = `id, owner_id, item_id, token, created_at`
+
+func scanFeed(rs interface{ Scan(...any) error }, f *calendar.Feed) error {
+	return rs.Scan(&f.ID, &f.OwnerID, &f.ItemID, &f.Token, &f.CreatedAt)
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (r *Repo) get(ctx context.Context, ownerID int64, itemID *int64) (calendar.Feed, error) {
+	const q = `
+	SELECT ` + selectFeedCols + `
+	FROM calendar_feeds
+	WHERE owner_id = $1
+	  AND item_id IS NOT DISTINCT FROM $2
+	`
+	var f calendar.Feed
+	if err := scanFeed(r.pool.QueryRow(ctx, q, ownerID, itemID), &f); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return calendar.Feed{}, calendar.ErrNotFound
+		}
+		return calendar.Feed{}, fmt.Errorf("calendar pgrepo: get feed: %w", err)
+	}
+	return f, nil
+}
+
+func (r *Repo) GetOrCreate(ctx context.Context, ownerID int64, itemID *int64) (calendar.Feed, error) {
+	f, err := r.get(ctx, ownerID, itemID)
+	if err == nil || !errors.Is(err, calendar.ErrNotFound) {
+		return f, err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return calendar.Feed{}, fmt.Errorf("calendar pgrepo: token: %w", err)
+	}
+	// параллельный запрос мог создать ленту раньше — тогда просто перечитываем
+	const insQ = `
+	INSERT INTO calendar_feeds (owner_id, item_id, token)
+	VALUES ($1, $2, $3)
+	ON CONFLICT DO NOTHING
+	`
+	if _, err := r.pool.Exec(ctx, insQ, ownerID, itemID, token); err != nil {
+		return calendar.Feed{}, fmt.Errorf("calendar pgrepo: create feed: %w", err)
+	}
+	return r.get(ctx, ownerID, itemID)
+}
+
+func (r *Repo) Regenerate(ctx context.Context, ownerID int64, itemID *int64) (calendar.Feed, error) {
+	token, err := newToken()
+	if err != nil {
+		return calendar.Feed{}, fmt.Errorf("calendar pgrepo: token: %w", err)
+	}
+	const updQ = `
+	UPDATE calendar_feeds
+	SET token = $3,
+	    created_at = now()
+	WHERE owner_id = $1
+	  AND item_id IS NOT DISTINCT FROM $2
+	RETURNING ` + selectFeedCols + `
+	`
+	var f calendar.Feed
+	err = scanFeed(r.pool.QueryRow(ctx, updQ, ownerID, itemID, token), &f)
+	if err == nil {
+		return f, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return calendar.Feed{}, fmt.Errorf("calendar pgrepo: regenerate feed: %w", err)
+	}
+	return r.GetOrCreate(ctx, ownerID, itemID)
+}
+
+func (r *Repo) GetByToken(ctx context.Context, token string) (calendar.Feed, error) {
+	const q = `
+	SELECT ` + selectFeedCols + `
+	FROM calendar_feeds
+	WHERE token = $1
+	`
+	var f calendar.Feed
+	if err := scanFeed(r.pool.QueryRow(ctx, q, token), &f); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return calendar.Feed{}, calendar.ErrNotFound
+		}
+		return calendar.Feed{}, fmt.Errorf("calendar pgrepo: get by token: %w", err)
+	}
+	return f, nil
+}
+
+// ListEntries — занятые аренды (те же статусы, что и в availability) владельца или одной вещи.
+// Завершившиеся больше 30 дней назад в ленту не попадают.
+func (r *Repo) ListEntries(ctx context.Context, f calendar.Feed) ([]calendar.Entry, error) {
+	const q = `
+	SELECT
+	  b.id, b.item_id, i.title, b.status, b.start_at, b.end_at,
+	  (SELECT count(*) FROM booking_events e WHERE e.booking_id = b.id)::int,
+	  COALESCE((SELECT max(e.created_at) FROM booking_events e WHERE e.booking_id = b.id), b.created_at)
+	FROM bookings b
+	JOIN items i ON i.id = b.item_id
+	WHERE b.owner_id = $1
+	  AND ($2::bigint IS NULL OR b.item_id = $2)
+	  AND b.type = 'rent'
+	  AND b.status IN ('approved','handover_pending','in_use','return_pending')
+	  AND b.end_at > now() - INTERVAL '30 days'
+	ORDER BY b.start_at
+	`
+	rows, err := r.pool.Query(ctx, q, f.OwnerID, f.ItemID)
+	if err != nil {
+		return nil, fmt.Errorf("calendar pgrepo: list entries: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]calendar.Entry, 0, 32)
+	for rows.Next() {
+		var e calendar.Entry
+		if err := rows.Scan(&e.BookingID, &e.ItemID, &e.ItemTitle, &e.Status, &e.Start, &e.End, &e.Sequence, &e.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("calendar pgrepo: list entries scan: %w", err)
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("calendar pgrepo: list entries rows: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,202 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/calendar"
+)
+
+const selectImportCols = `id, item_id, owner_id, source, url, name, last_synced_at, last_error, created_at`
+
+func scanImport(rs interface{ Scan(...any) error }, imp *calendar.Import) error {
+	return rs.Scan(
+		&imp.ID,
+		&imp.ItemID,
+		&imp.OwnerID,
+		&imp.Source,
+		&imp.URL,
+		&imp.Name,
+		&imp.LastSyncedAt,
+		&imp.LastError,
+		&imp.CreatedAt,
+	)
+}
+
+func (r *Repo) CreateImport(ctx context.Context, imp *calendar.Import) error {
+	const q = `
+	INSERT INTO calendar_imports (item_id, owner_id, source, url, name)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING ` + selectImportCols + `
+	`
+	if err := scanImport(r.pool.QueryRow(ctx, q, imp.ItemID, imp.OwnerID, imp.Source, imp.URL, imp.Name), imp); err != nil {
+		return fmt.Errorf("calendar pgrepo: create import: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) GetImport(ctx context.Context, itemID, importID int64) (calendar.Import, error) {
+	const q = `
+	SELECT ` + selectImportCols + `
+	FROM calendar_imports
+	WHERE id = $1 AND item_id = $2
+	`
+	var imp calendar.Import
+	if err := scanImport(r.pool.QueryRow(ctx, q, importID, itemID), &imp); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return calendar.Import{}, calendar.ErrNotFound
+		}
+		return calendar.Import{}, fmt.Errorf("calendar pgrepo: get import: %w", err)
+	}
+	return imp, nil
+}
+
+func (r *Repo) ListImports(ctx context.Context, itemID int64) ([]calendar.Import, error) {
+	const q = `
+	SELECT ` + selectImportCols + `
+	FROM calendar_imports
+	WHERE item_id = $1
+	ORDER BY id
+	`
+	return r.queryImports(ctx, q, itemID)
+}
+
+func (r *Repo) ListDueImports(ctx context.Context, before time.Time, limit int) ([]calendar.Import, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	const q = `
+	SELECT ` + selectImportCols + `
+	FROM calendar_imports
+	WHERE source = 'url'
+	  AND (last_synced_at IS NULL OR last_synced_at < $1)
+	ORDER BY last_synced_at NULLS FIRST, id
+	LIMIT $2
+	`
+	return r.queryImports(ctx, q, before, limit)
+}
+
+func (r *Repo) queryImports(ctx context.Context, q string, args ...any) ([]calendar.Import, error) {
+	rows, err := r.pool.Query(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("calendar pgrepo: list imports: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]calendar.Import, 0, 8)
+	for rows.Next() {
+		var imp calendar.Import
+		if err := scanImport(rows, &imp); err != nil {
+			return nil, fmt.Errorf("calendar pgrepo: list imports scan: %w", err)
+		}
+		out = append(out, imp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("calendar pgrepo: list imports rows: %w", err)
+	}
+	return out, nil
+}
+
+// DeleteImport — вместе с импортом каскадом удаляются его блокировки.
+func (r *Repo) DeleteImport(ctx context.Context, itemID, importID int64) error {
+	const q = `DELETE FROM calendar_imports WHERE id = $1 AND item_id = $2`
+	ct, err := r.pool.Exec(ctx, q, importID, itemID)
+	if err != nil {
+		return fmt.Errorf("calendar pgrepo: delete import: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return calendar.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repo) ReplaceImportedBlocks(ctx context.Context, imp calendar.Import, events []calendar.ExternalEvent, now time.Time) (calendar.SyncResult, error) {
+	res := calendar.SyncResult{ImportID: imp.ID}
+
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return res, fmt.Errorf("calendar pgrepo: sync begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// импорт мог быть удалён, пока качали файл
+	const lockQ = `SELECT 1 FROM calendar_imports WHERE id = $1 FOR UPDATE`
+	var one int
+	if err := tx.QueryRow(ctx, lockQ, imp.ID).Scan(&one); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return res, calendar.ErrNotFound
+		}
+		return res, fmt.Errorf("calendar pgrepo: sync lock import: %w", err)
+	}
+
+	uids := make([]string, 0, len(events))
+	for _, e := range events {
+		uids = append(uids, e.Key())
+	}
+	const delQ = `
+	DELETE FROM item_blocks
+	WHERE import_id = $1
+	  AND NOT (external_uid = ANY($2::text[]))
+	`
+	ct, err := tx.Exec(ctx, delQ, imp.ID, uids)
+	if err != nil {
+		return res, fmt.Errorf("calendar pgrepo: sync delete stale: %w", err)
+	}
+	res.Removed = int(ct.RowsAffected())
+
+	const upsertQ = `
+	INSERT INTO item_blocks (item_id, start_at, end_at, note, created_by, import_id, external_uid)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (import_id, external_uid) WHERE import_id IS NOT NULL
+	DO UPDATE SET start_at = EXCLUDED.start_at,
+	              end_at = EXCLUDED.end_at,
+	              note = EXCLUDED.note
+	`
+	note := "import"
+	if imp.Name != nil {
+		note = *imp.Name
+	}
+	for _, e := range events {
+		if _, err := tx.Exec(ctx, upsertQ, imp.ItemID, e.Start, e.End, note, imp.OwnerID, imp.ID, e.Key()); err != nil {
+			return res, fmt.Errorf("calendar pgrepo: sync upsert: %w", err)
+		}
+		res.Upserted++
+	}
+
+	const conflictsQ = `
+	SELECT count(DISTINCT ib.id)::int
+	FROM item_blocks ib
+	JOIN bookings b ON b.item_id = ib.item_id
+	WHERE ib.import_id = $1
+	  AND b.type = 'rent'
+	  AND b.status IN ('approved','handover_pending','in_use','return_pending')
+	  AND b.start_at < ib.end_at
+	  AND b.end_at   > ib.start_at
+	`
+	if err := tx.QueryRow(ctx, conflictsQ, imp.ID).Scan(&res.Conflicts); err != nil {
+		return res, fmt.Errorf("calendar pgrepo: sync conflicts: %w", err)
+	}
+
+	const doneQ = `UPDATE calendar_imports SET last_synced_at = $2, last_error = NULL WHERE id = $1`
+	if _, err := tx.Exec(ctx, doneQ, imp.ID, now); err != nil {
+		return res, fmt.Errorf("calendar pgrepo: sync mark done: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return res, fmt.Errorf("calendar pgrepo: sync commit: %w", err)
+	}
+	return res, nil
+}
+
+// MarkImportError — last_synced_at тоже сдвигается, чтобы битая ссылка не дёргалась каждую минуту.
+func (r *Repo) MarkImportError(ctx context.Context, importID int64, msg string, now time.Time) error {
+	const q = `UPDATE calendar_imports SET last_synced_at = $2, last_error = $3 WHERE id = $1`
+	if _, err := r.pool.Exec(ctx, q, importID, now, msg); err != nil {
+		return fmt.Errorf("calendar pgrepo: mark import error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package calendar
+
+import (
+	"context"
+	"time"
+)
+
+type Repo interface {
+	// GetOrCreate — лента владельца; itemID = nil — по всем вещам.
+	GetOrCreate(ctx context.Context, ownerID int64, itemID *int64) (Feed, error)
+	// Regenerate — новый токен, старая ссылка перестаёт работать.
+	Regenerate(ctx context.Context, ownerID int64, itemID *int64) (Feed, error)
+	GetByToken(ctx context.Context, token string) (Feed, error)
+	ListEntries(ctx context.Context, f Feed) ([]Entry, error)
+
+	// Imports
+	CreateImport(ctx context.Context, imp *Import) error
+	GetImport(ctx context.Context, itemID, importID int64) (Import, error)
+	ListImports(ctx context.Context, itemID int64) ([]Import, error)
+	DeleteImport(ctx context.Context, itemID, importID int64) error
+	// ListDueImports — url-импорты, которые не синхронизировались с before.
+	ListDueImports(ctx context.Context, before time.Time, limit int) ([]Import, error)
+	// ReplaceImportedBlocks — идемпотентно приводит блокировки импорта к списку событий (по ExternalEvent.Key).
+	ReplaceImportedBlocks(ctx context.Context, imp Import, events []ExternalEvent, now time.Time) (SyncResult, error)
+	MarkImportError(ctx context.Context, importID int64, msg string, now time.Time) error
+}
@@ -0,0 +1,26 @@
+package calendar
+
+import (
+	"net/http"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/netguard"
+)
+
+type Middleware func(http.Handler) http.Handler
+
+func RegisterRoutes(mux *http.ServeMux, repo Repo, items ItemGetter, guard *netguard.Guard, authMw Middleware) {
+	h := NewHandler(repo, items, guard)
+
+	mux.Handle("GET /api/items/{id}/calendar-feed", authMw(http.HandlerFunc(h.ItemFeed)))
+	mux.Handle("POST /api/items/{id}/calendar-feed/regenerate", authMw(http.HandlerFunc(h.RegenerateItemFeed)))
+	mux.Handle("GET /api/my/calendar-feed", authMw(http.HandlerFunc(h.OwnerFeed)))
+	mux.Handle("POST /api/my/calendar-feed/regenerate", authMw(http.HandlerFunc(h.RegenerateOwnerFeed)))
+
+	mux.Handle("POST /api/items/{id}/calendar-imports", authMw(http.HandlerFunc(h.CreateImport)))
+	mux.Handle("GET /api/items/{id}/calendar-imports", authMw(http.HandlerFunc(h.ListImports)))
+	mux.Handle("POST /api/items/{id}/calendar-imports/{importId}/sync", authMw(http.HandlerFunc(h.SyncImport)))
+	mux.Handle("DELETE /api/items/{id}/calendar-imports/{importId}", authMw(http.HandlerFunc(h.DeleteImport)))
+
+	// без авторизации: календарные клиенты ходят по секретной ссылке
+	mux.HandleFunc("GET /api/calendar/{file}", h.ICS)
+}
@@ -0,0 +1,437 @@
+package calendar
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRRulePeriods — сколько периодов (дней/недель/месяцев/лет) правила перебираем от DTSTART;
+// ежедневное событие с 1970 года укладывается с запасом.
+const maxRRulePeriods = 100000
+
+var errUnsupportedRRule = errors.New("unsupported RRULE")
+
+type weekdayNum struct {
+	n   int // 0 — каждый такой день; 1, 2, -1... — n-й с начала или с конца месяца
+	day time.Weekday
+}
+
+// rrule — подмножество RFC 5545: FREQ=DAILY|WEEKLY|MONTHLY|YEARLY, INTERVAL, COUNT, UNTIL,
+// BYDAY, BYMONTHDAY, BYMONTH, WKST. Остальное (BYSETPOS, BYHOUR, почасовые частоты...) — errUnsupportedRRule.
+type rrule struct {
+	freq       string
+	interval   int
+	count      int
+	until      *icsTime
+	byDay      []weekdayNum
+	byMonthDay []int
+	byMonth    []time.Month
+	wkst       time.Weekday
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRRule — UNTIL без Z считается в зоне DTSTART.
+func parseRRule(v string, loc *time.Location) (rrule, error) {
+	r := rrule{interval: 1, wkst: time.Monday}
+	for _, part := range strings.Split(v, ";") {
+		k, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return rrule{}, errUnsupportedRRule
+		}
+		switch strings.ToUpper(k) {
+		case "FREQ":
+			r.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return rrule{}, errUnsupportedRRule
+			}
+			r.interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return rrule{}, errUnsupportedRRule
+			}
+			r.count = n
+		case "UNTIL":
+			t, date, err := parseICSTime(map[string]string{"TZID": loc.String()}, val)
+			if err != nil {
+				return rrule{}, errUnsupportedRRule
+			}
+			r.until = &icsTime{t: t, date: date}
+		case "BYDAY":
+			for _, s := range strings.Split(val, ",") {
+				s = strings.ToUpper(strings.TrimSpace(s))
+				if len(s) < 2 {
+					return rrule{}, errUnsupportedRRule
+				}
+				wd, ok := icsWeekdays[s[len(s)-2:]]
+				if !ok {
+					return rrule{}, errUnsupportedRRule
+				}
+				n := 0
+				if num := s[:len(s)-2]; num != "" {
+					var err error
+					if n, err = strconv.Atoi(num); err != nil || n == 0 || n > 5 || n < -5 {
+						return rrule{}, errUnsupportedRRule
+					}
+				}
+				r.byDay = append(r.byDay, weekdayNum{n: n, day: wd})
+			}
+		case "BYMONTHDAY":
+			for _, s := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(strings.TrimSpace(s))
+				if err != nil || n == 0 || n > 31 || n < -31 {
+					return rrule{}, errUnsupportedRRule
+				}
+				r.byMonthDay = append(r.byMonthDay, n)
+			}
+		case "BYMONTH":
+			for _, s := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(strings.TrimSpace(s))
+				if err != nil || n < 1 || n > 12 {
+					return rrule{}, errUnsupportedRRule
+				}
+				r.byMonth = append(r.byMonth, time.Month(n))
+			}
+		case "WKST":
+			wd, ok := icsWeekdays[strings.ToUpper(val)]
+			if !ok {
+				return rrule{}, errUnsupportedRRule
+			}
+			r.wkst = wd
+		default:
+			return rrule{}, errUnsupportedRRule
+		}
+	}
+
+	ordinal := false
+	for _, wd := range r.byDay {
+		ordinal = ordinal || wd.n != 0
+	}
+	switch r.freq {
+	case "DAILY":
+		if ordinal {
+			return rrule{}, errUnsupportedRRule
+		}
+	case "WEEKLY":
+		if ordinal || len(r.byMonthDay) > 0 {
+			return rrule{}, errUnsupportedRRule
+		}
+	case "MONTHLY":
+	case "YEARLY":
+		// BYDAY/BYMONTHDAY без BYMONTH означают дни года — не поддерживаем
+		if (len(r.byDay) > 0 || len(r.byMonthDay) > 0) && len(r.byMonth) == 0 {
+			return rrule{}, errUnsupportedRRule
+		}
+	default:
+		return rrule{}, errUnsupportedRRule
+	}
+	return r, nil
+}
+
+// between — начала вхождений в [after, before). COUNT и UNTIL отсчитываются от dtstart,
+// поэтому вхождения до after тоже перебираются; DTSTART всегда первое вхождение.
+func (r rrule) between(dtstart time.Time, loc *time.Location, after, before time.Time, limit int) []time.Time {
+	local := dtstart.In(loc)
+	y, m, d := local.Date()
+	hh, mm, ss := local.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hh, mm, ss, 0, loc).UTC()
+	}
+
+	out := make([]time.Time, 0, 16)
+	n := 0
+	// emit — false, когда правило исчерпано или дальше горизонта
+	emit := func(t time.Time) bool {
+		if r.until != nil && r.afterUntil(t, loc) {
+			return false
+		}
+		if !t.Before(before) || len(out) >= limit {
+			return false
+		}
+		n++
+		if !t.Before(after) {
+			out = append(out, t)
+		}
+		return r.count == 0 || n < r.count
+	}
+	if !emit(dtstart.UTC()) {
+		return out
+	}
+
+	// запас на смещение зоны: период целиком позже горизонта — дальше вхождений нет
+	stop := before.Add(48 * time.Hour)
+	for i := 0; i < maxRRulePeriods; i++ {
+		var (
+			cands       []time.Time
+			periodStart time.Time
+		)
+		switch r.freq {
+		case "DAILY":
+			day := time.Date(y, m, d+i*r.interval, 0, 0, 0, 0, time.UTC)
+			periodStart = day
+			if r.matchMonth(day.Month()) && r.matchMonthDay(day) && r.matchWeekday(day.Weekday()) {
+				cands = append(cands, at(day.Date()))
+			}
+		case "WEEKLY":
+			back := (int(local.Weekday()) - int(r.wkst) + 7) % 7
+			weekStart := time.Date(y, m, d-back+i*7*r.interval, 0, 0, 0, 0, time.UTC)
+			periodStart = weekStart
+			days := r.byDay
+			if len(days) == 0 {
+				days = []weekdayNum{{day: local.Weekday()}}
+			}
+			for _, wd := range days {
+				day := weekStart.AddDate(0, 0, (int(wd.day)-int(r.wkst)+7)%7)
+				if r.matchMonth(day.Month()) {
+					cands = append(cands, at(day.Date()))
+				}
+			}
+		case "MONTHLY":
+			first := time.Date(y, m+time.Month(i*r.interval), 1, 0, 0, 0, 0, time.UTC)
+			periodStart = first
+			if r.matchMonth(first.Month()) {
+				for _, dd := range r.monthDays(first.Year(), first.Month(), d) {
+					cands = append(cands, at(first.Year(), first.Month(), dd))
+				}
+			}
+		case "YEARLY":
+			year := y + i*r.interval
+			periodStart = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+			months := r.byMonth
+			if len(months) == 0 {
+				months = []time.Month{m}
+			}
+			for _, mon := range months {
+				for _, dd := range r.monthDays(year, mon, d) {
+					cands = append(cands, at(year, mon, dd))
+				}
+			}
+		}
+		if periodStart.After(stop) {
+			return out
+		}
+		sort.Slice(cands, func(a, b int) bool { return cands[a].Before(cands[b]) })
+
+		for _, c := range cands {
+			if !c.After(dtstart) {
+				continue
+			}
+			if !emit(c) {
+				return out
+			}
+		}
+	}
+	return out
+}
+
+func (r rrule) afterUntil(t time.Time, loc *time.Location) bool {
+	if !r.until.date {
+		return t.After(r.until.t)
+	}
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).After(r.until.t)
+}
+
+func (r rrule) matchMonth(m time.Month) bool {
+	if len(r.byMonth) == 0 {
+		return true
+	}
+	for _, bm := range r.byMonth {
+		if bm == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (r rrule) matchMonthDay(day time.Time) bool {
+	if len(r.byMonthDay) == 0 {
+		return true
+	}
+	last := daysIn(day.Year(), day.Month())
+	for _, md := range r.byMonthDay {
+		if md == day.Day() || (md < 0 && last+md+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r rrule) matchWeekday(wd time.Weekday) bool {
+	if len(r.byDay) == 0 {
+		return true
+	}
+	for _, bd := range r.byDay {
+		if bd.day == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// monthDays — дни месяца по BYMONTHDAY и BYDAY (оба заданы — пересечение); без них — день DTSTART.
+func (r rrule) monthDays(y int, m time.Month, dtstartDay int) []int {
+	last := daysIn(y, m)
+	if len(r.byMonthDay) == 0 && len(r.byDay) == 0 {
+		if dtstartDay > last {
+			return nil // 31-е в коротком месяце пропускается (RFC 5545)
+		}
+		return []int{dtstartDay}
+	}
+
+	set := make(map[int]bool, 8)
+	if len(r.byMonthDay) > 0 {
+		for _, md := range r.byMonthDay {
+			if md < 0 {
+				md = last + md + 1
+			}
+			if md >= 1 && md <= last {
+				set[md] = true
+			}
+		}
+	}
+	if len(r.byDay) > 0 {
+		byDay := make(map[int]bool, 8)
+		firstWd := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC).Weekday()
+		for _, wd := range r.byDay {
+			first := 1 + (int(wd.day)-int(firstWd)+7)%7
+			switch {
+			case wd.n == 0:
+				for dd := first; dd <= last; dd += 7 {
+					byDay[dd] = true
+				}
+			case wd.n > 0:
+				if dd := first + (wd.n-1)*7; dd <= last {
+					byDay[dd] = true
+				}
+			default:
+				lastWd := first + (last-first)/7*7
+				if dd := lastWd + (wd.n+1)*7; dd >= 1 {
+					byDay[dd] = true
+				}
+			}
+		}
+		if len(r.byMonthDay) > 0 {
+			for dd := range set {
+				if !byDay[dd] {
+					delete(set, dd)
+				}
+			}
+		} else {
+			set = byDay
+		}
+	}
+
+	out := make([]int, 0, len(set))
+	for dd := range set {
+		out = append(out, dd)
+	}
+	sort.Ints(out)
+	return out
+}
+
+func daysIn(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// expandEvents — разворачивает повторяющиеся события в отдельные вхождения, пересекающие [from, to),
+// с учётом RDATE/EXDATE и переопределений (VEVENT с тем же UID и RECURRENCE-ID; отменённые убирают вхождение).
+// unsupported — сколько событий с правилами, которые мы не умеем разворачивать: от них блокируются
+// только DTSTART, RDATE и переопределения.
+func expandEvents(events []ExternalEvent, from, to time.Time) (out []ExternalEvent, unsupported int) {
+	overrides := make(map[string]ExternalEvent)
+	for _, e := range events {
+		if e.RecurrenceID == nil {
+			continue
+		}
+		if e.unsupported {
+			unsupported++
+		}
+		overrides[e.Key()] = e
+	}
+	used := make(map[string]bool, len(overrides))
+
+	out = make([]ExternalEvent, 0, len(events))
+	for _, e := range events {
+		if e.RecurrenceID != nil {
+			continue
+		}
+		if e.unsupported {
+			unsupported++
+		}
+		if e.rrule == "" && len(e.rdates) == 0 {
+			out = append(out, e)
+			continue
+		}
+
+		dur := e.End.Sub(e.Start)
+		loc := e.loc
+		if loc == nil {
+			loc = time.UTC
+		}
+		starts := []time.Time{e.Start}
+		if e.rrule != "" {
+			r, err := parseRRule(e.rrule, loc)
+			if err != nil {
+				unsupported++
+			} else {
+				starts = r.between(e.Start, loc, from.Add(-dur), to, maxImportEvents)
+			}
+		}
+		for _, rd := range e.rdates {
+			starts = append(starts, rd.t)
+		}
+
+		seen := make(map[int64]bool, len(starts))
+		for _, st := range starts {
+			if seen[st.Unix()] || excluded(st, e.exdates, loc) {
+				continue
+			}
+			seen[st.Unix()] = true
+
+			rid := st
+			occ := ExternalEvent{UID: e.UID, RecurrenceID: &rid, Start: st, End: st.Add(dur)}
+			if ov, ok := overrides[occ.Key()]; ok {
+				used[occ.Key()] = true
+				if ov.cancelled {
+					continue
+				}
+				occ = ov
+			}
+			out = append(out, occ)
+		}
+	}
+
+	// переопределения вхождений, которых правило в горизонте не дало (перенесены издалека или без мастера)
+	for k, ov := range overrides {
+		if !used[k] && !ov.cancelled {
+			out = append(out, ov)
+		}
+	}
+	return out, unsupported
+}
+
+func excluded(t time.Time, exdates []icsTime, loc *time.Location) bool {
+	for _, ex := range exdates {
+		if ex.date {
+			y, m, d := t.In(loc).Date()
+			if time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Equal(ex.t) {
+				return true
+			}
+			continue
+		}
+		if t.Equal(ex.t) {
+			return true
+		}
+	}
+	return false
+}
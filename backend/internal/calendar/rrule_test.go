@@ -0,0 +1,169 @@
+package calendar
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseTestICS(t *testing.T, body string) []ExternalEvent {
+	t.Helper()
+	events, err := ParseICS(strings.NewReader("BEGIN:VCALENDAR\n" + body + "END:VCALENDAR\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return events
+}
+
+func starts(events []ExternalEvent) []string {
+	out := make([]string, 0, len(events))
+	for _, e := range events {
+		out = append(out, e.Start.UTC().Format("20060102T1504"))
+	}
+	return out
+}
+
+// TestRelevantEventsExpandsRRule — еженедельная серия с EXDATE, перенесённым и отменённым вхождением:
+// каждое вхождение — своя блокировка с ключом UID + RECURRENCE-ID.
+func TestRelevantEventsExpandsRRule(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC) // понедельник
+	events := parseTestICS(t, `BEGIN:VEVENT
+UID:weekly
+DTSTART:20260601T100000Z
+DTEND:20260601T120000Z
+RRULE:FREQ=WEEKLY;COUNT=5
+EXDATE:20260608T100000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:weekly
+RECURRENCE-ID:20260615T100000Z
+DTSTART:20260616T140000Z
+DTEND:20260616T160000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:weekly
+RECURRENCE-ID:20260622T100000Z
+STATUS:CANCELLED
+DTSTART:20260622T100000Z
+DTEND:20260622T120000Z
+END:VEVENT
+`)
+
+	got, unsupported := relevantEvents(events, now)
+	if unsupported != 0 {
+		t.Errorf("unsupported = %d, want 0", unsupported)
+	}
+	want := []string{"20260601T1000", "20260616T1400", "20260629T1000"}
+	if strings.Join(starts(got), ",") != strings.Join(want, ",") {
+		t.Fatalf("starts = %v, want %v", starts(got), want)
+	}
+	if k := got[1].Key(); k != "weekly/20260615T100000Z" {
+		t.Errorf("moved occurrence key = %q", k)
+	}
+	if d := got[2].End.Sub(got[2].Start); d != 2*time.Hour {
+		t.Errorf("occurrence duration = %v, want 2h", d)
+	}
+}
+
+func TestRRuleRules(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		event string
+		want  []string
+	}{
+		{
+			name: "monthly last friday",
+			event: `DTSTART:20260130T090000Z
+DTEND:20260130T100000Z
+RRULE:FREQ=MONTHLY;BYDAY=-1FR;COUNT=3`,
+			want: []string{"20260130T0900", "20260227T0900", "20260327T0900"},
+		},
+		{
+			name: "monthly 31st skips short months",
+			event: `DTSTART;VALUE=DATE:20260131
+RRULE:FREQ=MONTHLY;UNTIL=20260531`,
+			want: []string{"20260131T0000", "20260331T0000", "20260531T0000"},
+		},
+		{
+			name: "weekly by day with interval",
+			event: `DTSTART:20260106T080000Z
+DTEND:20260106T090000Z
+RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH;COUNT=4`,
+			want: []string{"20260106T0800", "20260108T0800", "20260120T0800", "20260122T0800"},
+		},
+		{
+			name: "daily with rdate",
+			event: `DTSTART;VALUE=DATE:20260110
+RRULE:FREQ=DAILY;COUNT=2
+RDATE;VALUE=DATE:20260201`,
+			want: []string{"20260110T0000", "20260111T0000", "20260201T0000"},
+		},
+		{
+			name: "yearly by month",
+			event: `DTSTART:20260315T120000Z
+DTEND:20260315T130000Z
+RRULE:FREQ=YEARLY;BYMONTH=3,9`,
+			want: []string{"20260315T1200", "20260915T1200", "20270315T1200", "20270915T1200"},
+		},
+		{
+			// 10:00 по Берлину до и после перехода на летнее время
+			name: "local time across DST",
+			event: `DTSTART;TZID=Europe/Berlin:20260322T100000
+DTEND;TZID=Europe/Berlin:20260322T110000
+RRULE:FREQ=WEEKLY;COUNT=2`,
+			want: []string{"20260322T0900", "20260329T0800"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := parseTestICS(t, "BEGIN:VEVENT\nUID:x\n"+tt.event+"\nEND:VEVENT\n")
+			got, unsupported := relevantEvents(events, now)
+			if unsupported != 0 {
+				t.Errorf("unsupported = %d, want 0", unsupported)
+			}
+			if len(got) > len(tt.want) {
+				got = got[:len(tt.want)]
+			}
+			if strings.Join(starts(got), ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("starts = %v, want %v", starts(got), tt.want)
+			}
+		})
+	}
+}
+
+// TestApplyReportsUnsupportedRRule — правило, которое не развернуть, не теряется молча:
+// блокируется первое вхождение, а владелец видит причину в last_error.
+func TestApplyReportsUnsupportedRRule(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ics := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:setpos
+DTSTART:20260105T090000Z
+DTEND:20260105T100000Z
+RRULE:FREQ=MONTHLY;BYDAY=MO,TU;BYSETPOS=1
+END:VEVENT
+BEGIN:VEVENT
+UID:single
+DTSTART:20260110T090000Z
+DTEND:20260110T100000Z
+END:VEVENT
+END:VCALENDAR
+`
+	repo := &fakeRepo{}
+	s := NewSyncer(repo, loopbackGuard(t))
+	res, err := s.Apply(context.Background(), Import{ID: 1, ItemID: 1}, strings.NewReader(ics), now)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if res.Unsupported != 1 {
+		t.Errorf("unsupported = %d, want 1", res.Unsupported)
+	}
+	if repo.replaced != 2 {
+		t.Errorf("replaced = %d, want 2", repo.replaced)
+	}
+	if repo.lastError != ErrUnsupportedRecurrence.Error() {
+		t.Errorf("last_error = %q, want %q", repo.lastError, ErrUnsupportedRecurrence.Error())
+	}
+}
@@ -0,0 +1,184 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/netguard"
+)
+
+const (
+	maxICSSize      = 5 << 20 // 5MB
+	maxImportEvents = 5000
+	importHorizon   = 2 * 365 * 24 * time.Hour // дальше вперёд события не импортируем
+)
+
+// Syncer — скачивает/разбирает внешние календари и раскладывает их в блокировки.
+type Syncer struct {
+	repo   Repo
+	client *http.Client
+}
+
+// NewSyncer — ссылки качаются клиентом guard: во внутреннюю сеть он не соединяется.
+func NewSyncer(repo Repo, guard *netguard.Guard) *Syncer {
+	return &Syncer{
+		repo:   repo,
+		client: guard.Client(10 * time.Second),
+	}
+}
+
+// ValidateFeedURL — http(s) ссылка, хост которой не резолвится во внутреннюю сеть.
+// При каждой перекачке адрес проверяется ещё раз при соединении (см. netguard).
+func ValidateFeedURL(ctx context.Context, guard *netguard.Guard, raw string) error {
+	err := guard.CheckURL(ctx, raw)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, netguard.ErrBlocked):
+		return ErrBlockedURL
+	default:
+		return ErrInvalidURL
+	}
+}
+
+// Apply — разбирает .ics и идемпотентно заменяет блокировки импорта.
+func (s *Syncer) Apply(ctx context.Context, imp Import, r io.Reader, now time.Time) (SyncResult, error) {
+	events, err := ParseICS(io.LimitReader(r, maxICSSize))
+	if err != nil {
+		return SyncResult{ImportID: imp.ID}, fmt.Errorf("%w: %v", ErrInvalidICS, err)
+	}
+	return applyEvents(ctx, s.repo, imp, events, now)
+}
+
+// applyEvents — раскладывает разобранные события в блокировки импорта. Если часть повторений
+// развернуть не удалось, блокировки всё равно обновляются, но причина остаётся в last_error.
+func applyEvents(ctx context.Context, repo Repo, imp Import, events []ExternalEvent, now time.Time) (SyncResult, error) {
+	relevant, unsupported := relevantEvents(events, now)
+	res, err := repo.ReplaceImportedBlocks(ctx, imp, relevant, now)
+	if err != nil {
+		return res, err
+	}
+	res.Unsupported = unsupported
+	if unsupported > 0 {
+		if err := repo.MarkImportError(ctx, imp.ID, ErrUnsupportedRecurrence.Error(), now); err != nil {
+			log.Println("calendar mark import error:", err)
+		}
+	}
+	return res, nil
+}
+
+// SyncURL — перекачивает ленту импорта. Подробности ошибки только в логе:
+// в last_error и ответ клиенту уходит обобщённый текст (см. PublicSyncError),
+// чтобы по ним нельзя было читать ответы чужих серверов.
+func (s *Syncer) SyncURL(ctx context.Context, imp Import, now time.Time) (SyncResult, error) {
+	if imp.Source != ImportURL || imp.URL == nil {
+		return SyncResult{ImportID: imp.ID}, errors.New("import has no url")
+	}
+	res, err := s.syncURL(ctx, imp, now)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		log.Printf("calendar import %d sync: %v", imp.ID, err)
+		if merr := s.repo.MarkImportError(ctx, imp.ID, PublicSyncError(err), now); merr != nil {
+			log.Println("calendar mark import error:", merr)
+		}
+	}
+	return res, err
+}
+
+// PublicSyncError — текст ошибки синхронизации, который можно показать владельцу.
+func PublicSyncError(err error) string {
+	switch {
+	case errors.Is(err, netguard.ErrBlocked):
+		return ErrBlockedURL.Error()
+	case errors.Is(err, ErrTooLarge):
+		return ErrTooLarge.Error()
+	case errors.Is(err, ErrInvalidICS):
+		return ErrInvalidICS.Error()
+	case errors.Is(err, ErrFetch):
+		return ErrFetch.Error()
+	default:
+		return "sync failed"
+	}
+}
+
+func (s *Syncer) syncURL(ctx context.Context, imp Import, now time.Time) (SyncResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *imp.URL, nil)
+	if err != nil {
+		return SyncResult{ImportID: imp.ID}, fmt.Errorf("%w: %v", ErrFetch, err)
+	}
+	req.Header.Set("Accept", "text/calendar")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return SyncResult{ImportID: imp.ID}, fmt.Errorf("%w: %w", ErrFetch, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return SyncResult{ImportID: imp.ID}, fmt.Errorf("%w: status %d", ErrFetch, resp.StatusCode)
+	}
+	if resp.ContentLength > maxICSSize {
+		return SyncResult{ImportID: imp.ID}, fmt.Errorf("%w: content-length %d", ErrTooLarge, resp.ContentLength)
+	}
+	// читаем на байт больше лимита: обрезанный календарь не разбираем, а отклоняем
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxICSSize+1))
+	if err != nil {
+		return SyncResult{ImportID: imp.ID}, fmt.Errorf("%w: read body: %v", ErrFetch, err)
+	}
+	if len(body) > maxICSSize {
+		return SyncResult{ImportID: imp.ID}, ErrTooLarge
+	}
+	return s.Apply(ctx, imp, bytes.NewReader(body), now)
+}
+
+// SyncDue — пересинхронизирует url-импорты, которые не обновлялись дольше interval.
+func (s *Syncer) SyncDue(ctx context.Context, now time.Time, interval time.Duration) (int, error) {
+	due, err := s.repo.ListDueImports(ctx, now.Add(-interval), 50)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, imp := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		if _, err := s.SyncURL(ctx, imp, now); err != nil {
+			continue // уже в логе и last_error
+		}
+		n++
+	}
+	return n, nil
+}
+
+// relevantEvents — повторения разворачиваются в пределах горизонта, прошедшие и слишком далёкие
+// вхождения не нужны; повторяющиеся ключи (UID + RECURRENCE-ID) склеиваются в первый.
+// Второе значение — число событий с правилами повторения, которые развернуть не удалось.
+func relevantEvents(events []ExternalEvent, now time.Time) ([]ExternalEvent, int) {
+	from := now.Add(-24 * time.Hour)
+	to := now.Add(importHorizon)
+
+	expanded, unsupported := expandEvents(events, from, to)
+	seen := make(map[string]struct{}, len(expanded))
+	out := make([]ExternalEvent, 0, len(expanded))
+	for _, e := range expanded {
+		if !e.End.After(from) || !e.Start.Before(to) {
+			continue
+		}
+		if _, ok := seen[e.Key()]; ok {
+			continue
+		}
+		seen[e.Key()] = struct{}{}
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	if len(out) > maxImportEvents {
+		out = out[:maxImportEvents]
+	}
+	return out, unsupported
+}
@@ -0,0 +1,104 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/netguard"
+)
+
+// fakeRepo — только то, что трогает Syncer.SyncURL.
+type fakeRepo struct {
+	Repo
+
+	lastError string
+	replaced  int
+}
+
+func (f *fakeRepo) ReplaceImportedBlocks(ctx context.Context, imp Import, events []ExternalEvent, now time.Time) (SyncResult, error) {
+	f.replaced = len(events)
+	return SyncResult{ImportID: imp.ID}, nil
+}
+
+func (f *fakeRepo) MarkImportError(ctx context.Context, importID int64, msg string, now time.Time) error {
+	f.lastError = msg
+	return nil
+}
+
+func urlImport(u string) Import {
+	return Import{ID: 1, ItemID: 1, Source: ImportURL, URL: &u}
+}
+
+func loopbackGuard(t *testing.T) *netguard.Guard {
+	t.Helper()
+	allow, err := netguard.ParseAllowlist("127.0.0.0/8, ::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return netguard.New(allow)
+}
+
+func TestSyncURLBlocksPrivateAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached loopback server")
+	}))
+	defer srv.Close()
+
+	repo := &fakeRepo{}
+	s := NewSyncer(repo, netguard.New(nil))
+	_, err := s.SyncURL(context.Background(), urlImport(srv.URL), time.Now().UTC())
+	if !errors.Is(err, netguard.ErrBlocked) {
+		t.Fatalf("err = %v, want ErrBlocked", err)
+	}
+	if repo.lastError != ErrBlockedURL.Error() {
+		t.Errorf("last_error = %q, want %q", repo.lastError, ErrBlockedURL.Error())
+	}
+}
+
+func TestSyncURLDoesNotLeakResponse(t *testing.T) {
+	const secret = "internal-admin-token"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// ошибка разбора дословно содержит значение из ответа
+		w.Write([]byte("BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:1\nDTSTART:" + secret + "\nEND:VEVENT\nEND:VCALENDAR\n"))
+	}))
+	defer srv.Close()
+
+	repo := &fakeRepo{}
+	s := NewSyncer(repo, loopbackGuard(t))
+	_, err := s.SyncURL(context.Background(), urlImport(srv.URL), time.Now().UTC())
+	if err == nil {
+		t.Fatal("want error for garbage calendar")
+	}
+	if strings.Contains(repo.lastError, secret) || strings.Contains(PublicSyncError(err), secret) {
+		t.Errorf("response body leaked: last_error=%q public=%q", repo.lastError, PublicSyncError(err))
+	}
+}
+
+func TestSyncURLRejectsTooLarge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// без Content-Length: лимит должен сработать при чтении
+		w.(http.Flusher).Flush()
+		chunk := []byte(strings.Repeat("X", 64<<10))
+		for n := 0; n <= maxICSSize; n += len(chunk) {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	repo := &fakeRepo{}
+	s := NewSyncer(repo, loopbackGuard(t))
+	_, err := s.SyncURL(context.Background(), urlImport(srv.URL), time.Now().UTC())
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("err = %v, want ErrTooLarge", err)
+	}
+	if repo.replaced != 0 {
+		t.Error("truncated calendar must not be applied")
+	}
+}
@@ -0,0 +1,12 @@
+package dispute
+
+import "errors"
+
+var (
+	ErrNotFound     = errors.New("dispute not found")
+	ErrForbidden    = errors.New("forbidden")
+	ErrInvalidState = errors.New("invalid dispute status")
+	ErrAlreadyOpen  = errors.New("dispute already open")
+	// ErrDepositReleased — спор о повреждении/невозврате после того, как залог уже вернули: списывать нечего
+	ErrDepositReleased = errors.New("deposit already released, dispute window is over")
+)
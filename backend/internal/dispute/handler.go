@@ -0,0 +1,198 @@
+package dispute
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/auth"
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/httpx"
+	"github.com/SHILOP0P/Yardly/backend/internal/upload"
+)
+
+type Handler struct {
+	repo     Repo
+	bookings BookingGetter
+}
+
+type BookingGetter interface {
+	GetByID(ctx context.Context, id int64) (booking.Booking, error)
+}
+
+func NewHandler(repo Repo, bookings BookingGetter) *Handler {
+	return &Handler{repo: repo, bookings: bookings}
+}
+
+type openDisputeRequestDTO struct {
+	Reason      string `json:"reason"` // damage | non_return | other
+	Description string `json:"description"`
+}
+
+const maxDescriptionLen = 2000
+
+// POST /api/bookings/{id}/disputes
+func (h *Handler) Open(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var dto openDisputeRequestDTO
+	if err := httpx.ReadJSON(r, &dto); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	reason := Reason(strings.TrimSpace(dto.Reason))
+	if !reason.Valid() {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid reason")
+		return
+	}
+	desc := strings.TrimSpace(dto.Description)
+	if desc == "" {
+		httpx.WriteError(w, http.StatusBadRequest, "description is required")
+		return
+	}
+	if len([]rune(desc)) > maxDescriptionLen {
+		httpx.WriteError(w, http.StatusBadRequest, "description is too long")
+		return
+	}
+
+	d, err := h.repo.Open(r.Context(), bookingID, actorID, reason, desc)
+	if err != nil {
+		writeDisputeError(w, "open dispute", err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusCreated, d)
+}
+
+// GET /api/bookings/{id}/disputes
+func (h *Handler) ListByBooking(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return
+	}
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !h.isParticipant(w, r, bookingID, actorID) {
+		return
+	}
+
+	list, err := h.repo.ListByBooking(r.Context(), bookingID)
+	if err != nil {
+		writeDisputeError(w, "list disputes", err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, map[string]any{"disputes": list})
+}
+
+// GET /api/disputes/{id} — спор с фото и таймлайном
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	disputeID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || disputeID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid dispute id")
+		return
+	}
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	d, err := h.repo.GetDetails(r.Context(), disputeID)
+	if err != nil {
+		writeDisputeError(w, "get dispute", err)
+		return
+	}
+	if !h.isParticipant(w, r, d.BookingID, actorID) {
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, d)
+}
+
+// POST /api/disputes/{id}/attachments (multipart, поле "file")
+func (h *Handler) AddAttachment(w http.ResponseWriter, r *http.Request) {
+	disputeID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || disputeID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid dispute id")
+		return
+	}
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// права проверяем до записи файла на диск
+	d, err := h.repo.GetByID(r.Context(), disputeID)
+	if err != nil {
+		writeDisputeError(w, "get dispute", err)
+		return
+	}
+	if !h.isParticipant(w, r, d.BookingID, actorID) {
+		return
+	}
+	if d.Status != StatusOpen {
+		httpx.WriteError(w, http.StatusConflict, ErrInvalidState.Error())
+		return
+	}
+
+	url, err := upload.SaveImage(r, "disputes")
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	a, err := h.repo.AddAttachment(r.Context(), disputeID, actorID, url)
+	if err != nil {
+		writeDisputeError(w, "add dispute attachment", err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusCreated, a)
+}
+
+// isParticipant — пишет ответ и возвращает false, если пользователь не сторона брони.
+func (h *Handler) isParticipant(w http.ResponseWriter, r *http.Request, bookingID, userID int64) bool {
+	b, err := h.bookings.GetByID(r.Context(), bookingID)
+	if err != nil {
+		if errors.Is(err, booking.ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "booking not found")
+			return false
+		}
+		log.Println("dispute get booking error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return false
+	}
+	if b.RequesterID != userID && b.OwnerID != userID {
+		httpx.WriteError(w, http.StatusForbidden, "forbidden")
+		return false
+	}
+	return true
+}
+
+func writeDisputeError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		httpx.WriteError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrForbidden):
+		httpx.WriteError(w, http.StatusForbidden, "forbidden")
+	case errors.Is(err, ErrInvalidState), errors.Is(err, ErrAlreadyOpen), errors.Is(err, ErrDepositReleased):
+		httpx.WriteError(w, http.StatusConflict, err.Error())
+	default:
+		log.Println(op, "error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+	}
+}
@@ -0,0 +1,97 @@
+package dispute
+
+import "time"
+
+type Reason string
+
+const (
+	ReasonDamage    Reason = "damage"     // вещь вернули повреждённой
+	ReasonNonReturn Reason = "non_return" // вещь не вернули
+	ReasonOther     Reason = "other"
+)
+
+// ClaimsDeposit — по такому спору владельцу может уйти часть залога.
+func (r Reason) ClaimsDeposit() bool {
+	return r == ReasonDamage || r == ReasonNonReturn
+}
+
+func (r Reason) Valid() bool {
+	switch r {
+	case ReasonDamage, ReasonNonReturn, ReasonOther:
+		return true
+	default:
+		return false
+	}
+}
+
+type Status string
+
+const (
+	StatusOpen     Status = "open"
+	StatusResolved Status = "resolved"
+)
+
+type Outcome string
+
+const (
+	OutcomeOwnerFavored     Outcome = "owner_favored"
+	OutcomeRequesterFavored Outcome = "requester_favored"
+	OutcomeSplit            Outcome = "split"
+	OutcomeDismissed        Outcome = "dismissed"
+)
+
+func (o Outcome) Valid() bool {
+	switch o {
+	case OutcomeOwnerFavored, OutcomeRequesterFavored, OutcomeSplit, OutcomeDismissed:
+		return true
+	default:
+		return false
+	}
+}
+
+type Dispute struct {
+	ID          int64  `json:"id"`
+	BookingID   int64  `json:"booking_id"`
+	OpenedBy    int64  `json:"opened_by"`
+	Reason      Reason `json:"reason"`
+	Description string `json:"description"`
+	Status      Status `json:"status"`
+
+	Outcome        *Outcome   `json:"outcome,omitempty"`
+	ResolutionNote *string    `json:"resolution_note,omitempty"`
+	ResolvedBy     *int64     `json:"resolved_by,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Attachment struct {
+	ID         int64     `json:"id"`
+	DisputeID  int64     `json:"dispute_id"`
+	UploadedBy int64     `json:"uploaded_by"`
+	URL        string    `json:"url"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Event — запись таймлайна спора (open, attachment_add, resolve).
+type Event struct {
+	ID          int64     `json:"id"`
+	DisputeID   int64     `json:"dispute_id"`
+	ActorUserID *int64    `json:"actor_user_id,omitempty"`
+	Action      string    `json:"action"`
+	Meta        any       `json:"meta,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Details — спор вместе с фото и таймлайном.
+type Details struct {
+	Dispute
+	Attachments []Attachment `json:"attachments"`
+	Events      []Event      `json:"events"`
+}
+
+type ListFilter struct {
+	Status *Status
+	Limit  int
+	Offset int
+}
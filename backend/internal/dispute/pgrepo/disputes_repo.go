@@ -0,0 +1,271 @@
+package pgrepo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/booking/fsm"
+	bookingpg "github.com/SHILOP0P/Yardly/backend/internal/booking/pgrepo"
+	"github.com/SHILOP0P/Yardly/backend/internal/dispute"
+)
+
+type Repo struct {
+	pool          *pgxpool.Pool
+	eventRepo     *EventRepo
+	bookingEvents *bookingpg.EventRepo
+}
+
+func New(pool *pgxpool.Pool, eventRepo *EventRepo, bookingEvents *bookingpg.EventRepo) *Repo {
+	return &Repo{
+		pool:          pool,
+		eventRepo:     eventRepo,
+		bookingEvents: bookingEvents,
+	}
+}
+
+const SelectDisputeCols = `
+	id, booking_id, opened_by,
+	reason, description, status,
+	outcome, resolution_note, resolved_by, resolved_at,
+	created_at
+`
+
+func ScanDispute(rs interface{ Scan(...any) error }, d *dispute.Dispute) error {
+	return rs.Scan(
+		&d.ID,
+		&d.BookingID,
+		&d.OpenedBy,
+		&d.Reason,
+		&d.Description,
+		&d.Status,
+		&d.Outcome,
+		&d.ResolutionNote,
+		&d.ResolvedBy,
+		&d.ResolvedAt,
+		&d.CreatedAt,
+	)
+}
+
+// Open — участник брони открывает спор; бронь замораживается в той же транзакции.
+func (r *Repo) Open(ctx context.Context, bookingID, actorID int64, reason dispute.Reason, description string) (dispute.Dispute, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return dispute.Dispute{}, fmt.Errorf("disputes pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const lockQ = `
+	SELECT requester_id, owner_id, type, status
+	FROM bookings
+	WHERE id = $1
+	FOR UPDATE
+	`
+	var b booking.Booking
+	if err := tx.QueryRow(ctx, lockQ, bookingID).Scan(&b.RequesterID, &b.OwnerID, &b.Type, &b.Status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return dispute.Dispute{}, dispute.ErrNotFound
+		}
+		return dispute.Dispute{}, fmt.Errorf("disputes pgrepo: open lock booking: %w", err)
+	}
+	if actorID != b.RequesterID && actorID != b.OwnerID {
+		return dispute.Dispute{}, dispute.ErrForbidden
+	}
+	// второй открытый спор отсекает уникальный индекс (ErrAlreadyOpen), поэтому заморозку тут не учитываем
+	if _, err := fsm.Check(fsm.Kind(b.Type), fsm.State(b.Status), fsm.ActionOpenDispute, b.RoleOf(actorID), fsm.Facts{}); err != nil {
+		return dispute.Dispute{}, dispute.ErrInvalidState
+	}
+
+	// после завершения залог удерживается только в окне споров; когда он уже возвращён,
+	// спор о повреждении/невозврате ничего бы не списал — отказываем сразу, а не молча
+	if reason.ClaimsDeposit() && b.Status == booking.StatusCompleted {
+		const releasedQ = `
+		SELECT EXISTS (SELECT 1 FROM deposit_entries WHERE booking_id = $1 AND kind = 'release')
+		   AND (SELECT deposit_release_at IS NULL FROM bookings WHERE id = $1)
+		`
+		var released bool
+		if err := tx.QueryRow(ctx, releasedQ, bookingID).Scan(&released); err != nil {
+			return dispute.Dispute{}, fmt.Errorf("disputes pgrepo: open deposit check: %w", err)
+		}
+		if released {
+			return dispute.Dispute{}, dispute.ErrDepositReleased
+		}
+	}
+
+	const insQ = `
+	INSERT INTO disputes (booking_id, opened_by, reason, description)
+	VALUES ($1, $2, $3, $4)
+	RETURNING ` + SelectDisputeCols + `
+	`
+	var d dispute.Dispute
+	if err := ScanDispute(tx.QueryRow(ctx, insQ, bookingID, actorID, reason, description), &d); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return dispute.Dispute{}, dispute.ErrAlreadyOpen
+		}
+		return dispute.Dispute{}, fmt.Errorf("disputes pgrepo: open insert: %w", err)
+	}
+
+	const freezeQ = `UPDATE bookings SET frozen_at = now() WHERE id = $1`
+	if _, err := tx.Exec(ctx, freezeQ, bookingID); err != nil {
+		return dispute.Dispute{}, fmt.Errorf("disputes pgrepo: freeze booking: %w", err)
+	}
+
+	actor := actorID
+	meta, _ := json.Marshal(map[string]any{"reason": reason, "booking_id": bookingID})
+	if err := r.eventRepo.InsertDisputeEvent(ctx, tx, d.ID, &actor, "open", meta); err != nil {
+		return dispute.Dispute{}, err
+	}
+	bMeta, _ := json.Marshal(map[string]any{"dispute_id": d.ID, "reason": reason})
+	if err := r.bookingEvents.InsertBookingEvent(ctx, tx, bookingID, &actor, "dispute_open", nil, nil, bMeta); err != nil {
+		return dispute.Dispute{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return dispute.Dispute{}, fmt.Errorf("disputes pgrepo: commit: %w", err)
+	}
+	return d, nil
+}
+
+func (r *Repo) GetByID(ctx context.Context, id int64) (dispute.Dispute, error) {
+	const q = `SELECT ` + SelectDisputeCols + ` FROM disputes WHERE id = $1`
+	var d dispute.Dispute
+	if err := ScanDispute(r.pool.QueryRow(ctx, q, id), &d); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return dispute.Dispute{}, dispute.ErrNotFound
+		}
+		return dispute.Dispute{}, fmt.Errorf("disputes pgrepo: get: %w", err)
+	}
+	return d, nil
+}
+
+func (r *Repo) ListByBooking(ctx context.Context, bookingID int64) ([]dispute.Dispute, error) {
+	const q = `
+	SELECT ` + SelectDisputeCols + `
+	FROM disputes
+	WHERE booking_id = $1
+	ORDER BY created_at DESC, id DESC
+	`
+	rows, err := r.pool.Query(ctx, q, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("disputes pgrepo: list by booking: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]dispute.Dispute, 0, 4)
+	for rows.Next() {
+		var d dispute.Dispute
+		if err := ScanDispute(rows, &d); err != nil {
+			return nil, fmt.Errorf("disputes pgrepo: list by booking scan: %w", err)
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("disputes pgrepo: list by booking rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *Repo) GetDetails(ctx context.Context, id int64) (dispute.Details, error) {
+	d, err := r.GetByID(ctx, id)
+	if err != nil {
+		return dispute.Details{}, err
+	}
+	atts, err := ListAttachments(ctx, r.pool, id)
+	if err != nil {
+		return dispute.Details{}, err
+	}
+	evs, err := r.eventRepo.ListDisputeEvents(ctx, r.pool, id)
+	if err != nil {
+		return dispute.Details{}, err
+	}
+	return dispute.Details{Dispute: d, Attachments: atts, Events: evs}, nil
+}
+
+// AddAttachment — фото к открытому спору от участника брони.
+func (r *Repo) AddAttachment(ctx context.Context, disputeID, actorID int64, url string) (dispute.Attachment, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return dispute.Attachment{}, fmt.Errorf("disputes pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const lockQ = `
+	SELECT d.status, b.requester_id, b.owner_id
+	FROM disputes d
+	JOIN bookings b ON b.id = d.booking_id
+	WHERE d.id = $1
+	FOR UPDATE OF d
+	`
+	var st dispute.Status
+	var requesterID, ownerID int64
+	if err := tx.QueryRow(ctx, lockQ, disputeID).Scan(&st, &requesterID, &ownerID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return dispute.Attachment{}, dispute.ErrNotFound
+		}
+		return dispute.Attachment{}, fmt.Errorf("disputes pgrepo: attachment lock: %w", err)
+	}
+	if actorID != requesterID && actorID != ownerID {
+		return dispute.Attachment{}, dispute.ErrForbidden
+	}
+	if st != dispute.StatusOpen {
+		return dispute.Attachment{}, dispute.ErrInvalidState
+	}
+
+	const insQ = `
+	INSERT INTO dispute_attachments (dispute_id, uploaded_by, url)
+	VALUES ($1, $2, $3)
+	RETURNING id, dispute_id, uploaded_by, url, created_at
+	`
+	var a dispute.Attachment
+	if err := tx.QueryRow(ctx, insQ, disputeID, actorID, url).Scan(&a.ID, &a.DisputeID, &a.UploadedBy, &a.URL, &a.CreatedAt); err != nil {
+		return dispute.Attachment{}, fmt.Errorf("disputes pgrepo: attachment insert: %w", err)
+	}
+
+	actor := actorID
+	meta, _ := json.Marshal(map[string]any{"attachment_id": a.ID, "url": a.URL})
+	if err := r.eventRepo.InsertDisputeEvent(ctx, tx, disputeID, &actor, "attachment_add", meta); err != nil {
+		return dispute.Attachment{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return dispute.Attachment{}, fmt.Errorf("disputes pgrepo: commit: %w", err)
+	}
+	return a, nil
+}
+
+// ListAttachments — общий для пользовательского и админского чтения спора.
+func ListAttachments(ctx context.Context, pool interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+}, disputeID int64) ([]dispute.Attachment, error) {
+	const q = `
+	SELECT id, dispute_id, uploaded_by, url, created_at
+	FROM dispute_attachments
+	WHERE dispute_id = $1
+	ORDER BY created_at ASC, id ASC
+	`
+	rows, err := pool.Query(ctx, q, disputeID)
+	if err != nil {
+		return nil, fmt.Errorf("disputes pgrepo: list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]dispute.Attachment, 0, 4)
+	for rows.Next() {
+		var a dispute.Attachment
+		if err := rows.Scan(&a.ID, &a.DisputeID, &a.UploadedBy, &a.URL, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("disputes pgrepo: list attachments scan: %w", err)
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("disputes pgrepo: list attachments rows: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,64 @@
+package pgrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/dispute"
+)
+
+type EventRepo struct{}
+
+func NewEventRepo() *EventRepo { return &EventRepo{} }
+
+func (r *EventRepo) InsertDisputeEvent(ctx context.Context, tx pgx.Tx, disputeID int64, actorID *int64, action string, meta []byte) error {
+	const q = `
+	INSERT INTO dispute_events (dispute_id, actor_user_id, action, meta)
+	VALUES ($1, $2, $3, $4)
+	`
+	if _, err := tx.Exec(ctx, q, disputeID, actorID, action, meta); err != nil {
+		return fmt.Errorf("disputes pgrepo: insert dispute event: %w", err)
+	}
+	return nil
+}
+
+func (r *EventRepo) ListDisputeEvents(ctx context.Context, pool interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+}, disputeID int64) ([]dispute.Event, error) {
+	const q = `
+	SELECT id, dispute_id, actor_user_id, action, meta, created_at
+	FROM dispute_events
+	WHERE dispute_id = $1
+	ORDER BY created_at ASC, id ASC
+	`
+	rows, err := pool.Query(ctx, q, disputeID)
+	if err != nil {
+		return nil, fmt.Errorf("disputes pgrepo: list dispute events: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]dispute.Event, 0, 8)
+	for rows.Next() {
+		var e dispute.Event
+		var metaBytes []byte
+		if err := rows.Scan(&e.ID, &e.DisputeID, &e.ActorUserID, &e.Action, &metaBytes, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("disputes pgrepo: scan dispute event: %w", err)
+		}
+		if len(metaBytes) > 0 {
+			var m map[string]any
+			if err := json.Unmarshal(metaBytes, &m); err == nil {
+				e.Meta = m
+			} else {
+				e.Meta = string(metaBytes)
+			}
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("disputes pgrepo: rows dispute events: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,12 @@
+package dispute
+
+import "context"
+
+type Repo interface {
+	Open(ctx context.Context, bookingID, actorID int64, reason Reason, description string) (Dispute, error)
+	GetByID(ctx context.Context, id int64) (Dispute, error)
+	ListByBooking(ctx context.Context, bookingID int64) ([]Dispute, error)
+	GetDetails(ctx context.Context, id int64) (Details, error)
+
+	AddAttachment(ctx context.Context, disputeID, actorID int64, url string) (Attachment, error)
+}
@@ -0,0 +1,17 @@
+package dispute
+
+import (
+	"net/http"
+)
+
+type Middleware func(http.Handler) http.Handler
+
+func RegisterRoutes(mux *http.ServeMux, repo Repo, bookings BookingGetter, authMw Middleware) {
+	h := NewHandler(repo, bookings)
+
+	mux.Handle("POST /api/bookings/{id}/disputes", authMw(http.HandlerFunc(h.Open)))
+	mux.Handle("GET /api/bookings/{id}/disputes", authMw(http.HandlerFunc(h.ListByBooking)))
+
+	mux.Handle("GET /api/disputes/{id}", authMw(http.HandlerFunc(h.Get)))
+	mux.Handle("POST /api/disputes/{id}/attachments", authMw(http.HandlerFunc(h.AddAttachment)))
+}
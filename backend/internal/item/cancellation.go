@@ -0,0 +1,54 @@
+package item
+
+import "time"
+
+// CancellationPolicy — условия возврата при отмене аренды арендатором.
+type CancellationPolicy string
+
+const (
+	CancelFlexible CancellationPolicy = "flexible" // 100% за сутки до начала, позже 50%
+	CancelModerate CancellationPolicy = "moderate" // 100% за 5 дней, 50% за сутки, позже 0
+	CancelStrict   CancellationPolicy = "strict"   // 100% за 14 дней, 50% за 7 дней, позже 0
+)
+
+// RefundTier — возвращается RefundPct цены, если до начала осталось не меньше MinNotice.
+type RefundTier struct {
+	MinNotice time.Duration `json:"-"`
+	MinHours  int           `json:"min_hours_before_start"`
+	RefundPct int           `json:"refund_pct"`
+}
+
+func tier(notice time.Duration, pct int) RefundTier {
+	return RefundTier{MinNotice: notice, MinHours: int(notice / time.Hour), RefundPct: pct}
+}
+
+var cancellationTiers = map[CancellationPolicy][]RefundTier{
+	CancelFlexible: {tier(24*time.Hour, 100), tier(0, 50)},
+	CancelModerate: {tier(5*24*time.Hour, 100), tier(24*time.Hour, 50), tier(0, 0)},
+	CancelStrict:   {tier(14*24*time.Hour, 100), tier(7*24*time.Hour, 50), tier(0, 0)},
+}
+
+func (p CancellationPolicy) Valid() bool {
+	_, ok := cancellationTiers[p]
+	return ok
+}
+
+// Tiers — ступени политики от самой ранней отмены к самой поздней.
+func (p CancellationPolicy) Tiers() []RefundTier {
+	return cancellationTiers[p]
+}
+
+// RefundPct — доля цены к возврату, если до начала аренды осталось untilStart
+// (отрицательное значение — аренда уже началась, действует последняя ступень).
+func (p CancellationPolicy) RefundPct(untilStart time.Duration) int {
+	tiers := cancellationTiers[p]
+	for _, t := range tiers {
+		if untilStart >= t.MinNotice {
+			return t.RefundPct
+		}
+	}
+	if len(tiers) == 0 {
+		return 100
+	}
+	return tiers[len(tiers)-1].RefundPct
+}
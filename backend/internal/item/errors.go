@@ -2,4 +2,6 @@ package item
 
 import "errors"
 
-var ErrNotFound = errors.New("item not found")
\ No newline at end of file
+var ErrNotFound = errors.New("item not found")
+
+var ErrBlockConflict = errors.New("dates overlap an approved booking")
\ No newline at end of file
@@ -2,21 +2,16 @@ package item
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"errors"
-	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/SHILOP0P/Yardly/backend/internal/auth"
 	"github.com/SHILOP0P/Yardly/backend/internal/httpx"
+	"github.com/SHILOP0P/Yardly/backend/internal/pricing"
+	"github.com/SHILOP0P/Yardly/backend/internal/upload"
 )
 
 type Handler struct {
@@ -76,6 +71,13 @@ func (h *Handler) GetByID(w http.ResponseWriter, r *http.Request) {
 	}
 	it.Images = imgs
 
+	rt, err := h.repo.GetRating(r.Context(), it.ID)
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	it.Rating = &rt
+
 	httpx.WriteJSON(w, http.StatusOK, it)
 }
 
@@ -94,6 +96,22 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		Deposit     int64    `json:"deposit"`
 		Location    string   `json:"location"`
 		Category    string   `json:"category"`
+
+		WeeklyDiscountPct  int    `json:"weekly_discount_pct"`
+		MonthlyDiscountPct int    `json:"monthly_discount_pct"`
+		Currency           string `json:"currency"`
+
+		BookingGranularity Granularity `json:"booking_granularity"`
+
+		RentRules
+
+		InstantBook             bool `json:"instant_book"`
+		InstantBookMinCompleted int  `json:"instant_book_min_completed"`
+
+		RequireHandoverCode bool `json:"require_handover_code"`
+
+		CancellationPolicy CancellationPolicy `json:"cancellation_policy"`
+
 		Images      []struct {
 			URL       string `json:"url"`
 			SortOrder int    `json:"sort_order"`
@@ -114,6 +132,37 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dto.Currency = strings.ToUpper(strings.TrimSpace(dto.Currency))
+	if dto.Currency == "" {
+		dto.Currency = DefaultCurrency
+	}
+	if err := validatePricing(dto.Price, dto.Deposit, dto.WeeklyDiscountPct, dto.MonthlyDiscountPct, dto.Currency); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dto.BookingGranularity == "" {
+		dto.BookingGranularity = GranularityDay
+	}
+	if !dto.BookingGranularity.Valid() {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking_granularity")
+		return
+	}
+	if err := dto.RentRules.Validate(dto.BookingGranularity); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dto.InstantBookMinCompleted < 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "instant_book_min_completed must be >= 0")
+		return
+	}
+	if dto.CancellationPolicy == "" {
+		dto.CancellationPolicy = CancelFlexible
+	}
+	if !dto.CancellationPolicy.Valid() {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid cancellation_policy")
+		return
+	}
+
 	it := Item{
 		OwnerID:     ownerID,
 		Title:       dto.Title,
@@ -124,6 +173,21 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		Deposit:     dto.Deposit,
 		Location:    dto.Location,
 		Category:    dto.Category,
+
+		WeeklyDiscountPct:  dto.WeeklyDiscountPct,
+		MonthlyDiscountPct: dto.MonthlyDiscountPct,
+		Currency:           dto.Currency,
+
+		BookingGranularity: dto.BookingGranularity,
+		RentRules:          dto.RentRules,
+
+		InstantBook:             dto.InstantBook,
+		InstantBookMinCompleted: dto.InstantBookMinCompleted,
+
+		RequireHandoverCode: dto.RequireHandoverCode,
+
+		CancellationPolicy: dto.CancellationPolicy,
+
 		Images:      nil,
 	}
 
@@ -150,6 +214,140 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	httpx.WriteJSON(w, http.StatusCreated, it)
 }
 
+// PATCH /api/items/{id} — владелец меняет описание и цены; уже созданные брони держат свой снимок цены.
+func (h *Handler) Patch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	itemID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || itemID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid item id")
+		return
+	}
+
+	var req PatchRequest
+	if err := httpx.ReadJSON(r, &req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+
+	it, err := h.repo.GetByID(r.Context(), itemID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "item not found")
+			return
+		}
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if it.OwnerID != userID {
+		httpx.WriteError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	if req.Title != nil && strings.TrimSpace(*req.Title) == "" {
+		httpx.WriteError(w, http.StatusBadRequest, "title is required")
+		return
+	}
+	if req.Currency != nil {
+		c := strings.ToUpper(strings.TrimSpace(*req.Currency))
+		req.Currency = &c
+	}
+	if req.BookingGranularity != nil && !req.BookingGranularity.Valid() {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking_granularity")
+		return
+	}
+	if req.CancellationPolicy != nil && !req.CancellationPolicy.Valid() {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid cancellation_policy")
+		return
+	}
+
+	// валидируем итоговое состояние, а не только переданные поля
+	next := it
+	if req.Price != nil {
+		next.Price = *req.Price
+	}
+	if req.Deposit != nil {
+		next.Deposit = *req.Deposit
+	}
+	if req.WeeklyDiscountPct != nil {
+		next.WeeklyDiscountPct = *req.WeeklyDiscountPct
+	}
+	if req.MonthlyDiscountPct != nil {
+		next.MonthlyDiscountPct = *req.MonthlyDiscountPct
+	}
+	if req.Currency != nil {
+		next.Currency = *req.Currency
+	}
+	if err := validatePricing(next.Price, next.Deposit, next.WeeklyDiscountPct, next.MonthlyDiscountPct, next.Currency); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.BookingGranularity != nil {
+		next.BookingGranularity = *req.BookingGranularity
+	}
+	if err := req.RentRulesPatch.Apply(it.RentRules).Validate(next.BookingGranularity); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.InstantBookMinCompleted != nil && *req.InstantBookMinCompleted < 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "instant_book_min_completed must be >= 0")
+		return
+	}
+
+	out, err := h.repo.Update(r.Context(), itemID, req)
+	if err != nil {
+		log.Println("item patch error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, out)
+}
+
+// GET /api/items/{id}/quote?from&to — формат дат как при бронировании (YYYY-MM-DD или RFC3339 для почасовых)
+func (h *Handler) Quote(w http.ResponseWriter, r *http.Request) {
+	itemID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || itemID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid item id")
+		return
+	}
+
+	it, err := h.repo.GetByID(r.Context(), itemID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "item not found")
+			return
+		}
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if it.Status != StatusActive && it.Status != StatusInUse {
+		httpx.WriteError(w, http.StatusNotFound, "item not found")
+		return
+	}
+	if !it.Mode.AllowsRent() {
+		httpx.WriteError(w, http.StatusConflict, "item is not for rent")
+		return
+	}
+
+	q := r.URL.Query()
+	start, end, err := ParseRentRange(it.BookingGranularity, q.Get("from"), q.Get("to"))
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	quote, err := pricing.QuoteRent(it.PricingRules(), start, end)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, quote)
+}
+
 func (h *Handler) ListMyItems(w http.ResponseWriter, r *http.Request) {
 	ownerID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
@@ -243,7 +441,7 @@ func (h *Handler) AddImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	url, err := saveUploadedImage(r)
+	url, err := upload.SaveImage(r, "items")
 	if err != nil {
 		httpx.WriteError(w, http.StatusBadRequest, err.Error())
 		return
@@ -303,6 +501,129 @@ func (h *Handler) DeleteImage(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// ownedItem — вещь из пути {id}, если текущий пользователь её владелец; иначе ответ уже записан.
+func (h *Handler) ownedItem(w http.ResponseWriter, r *http.Request) (Item, bool) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return Item{}, false
+	}
+	itemID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || itemID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid item id")
+		return Item{}, false
+	}
+	it, err := h.repo.GetByID(r.Context(), itemID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "item not found")
+			return Item{}, false
+		}
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return Item{}, false
+	}
+	if it.OwnerID != userID {
+		httpx.WriteError(w, http.StatusForbidden, "forbidden")
+		return Item{}, false
+	}
+	return it, true
+}
+
+const maxBlockNoteLen = 500
+
+// POST /api/items/{id}/blocks — даты в том же формате, что и у бронирования этой вещи.
+func (h *Handler) AddBlock(w http.ResponseWriter, r *http.Request) {
+	it, ok := h.ownedItem(w, r)
+	if !ok {
+		return
+	}
+
+	var dto struct {
+		Start string  `json:"start_at"`
+		End   string  `json:"end_at"`
+		Note  *string `json:"note"`
+	}
+	if err := httpx.ReadJSON(r, &dto); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	start, end, err := ParseRentRange(it.BookingGranularity, dto.Start, dto.End)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dto.Note != nil {
+		n := strings.TrimSpace(*dto.Note)
+		if len([]rune(n)) > maxBlockNoteLen {
+			httpx.WriteError(w, http.StatusBadRequest, "note is too long")
+			return
+		}
+		dto.Note = &n
+		if n == "" {
+			dto.Note = nil
+		}
+	}
+
+	b := Block{
+		ItemID:    it.ID,
+		Start:     start,
+		End:       end,
+		Note:      dto.Note,
+		CreatedBy: it.OwnerID,
+	}
+	if err := h.repo.AddBlock(r.Context(), &b); err != nil {
+		switch {
+		case errors.Is(err, ErrBlockConflict):
+			httpx.WriteError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, ErrNotFound):
+			httpx.WriteError(w, http.StatusNotFound, "item not found")
+		default:
+			log.Println("item add block error:", err)
+			httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+	httpx.WriteJSON(w, http.StatusCreated, b)
+}
+
+// GET /api/items/{id}/blocks — блокировки с заметками, только владельцу.
+func (h *Handler) ListBlocks(w http.ResponseWriter, r *http.Request) {
+	it, ok := h.ownedItem(w, r)
+	if !ok {
+		return
+	}
+	blocks, err := h.repo.ListBlocks(r.Context(), it.ID)
+	if err != nil {
+		log.Println("item list blocks error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, blocks)
+}
+
+// DELETE /api/items/{id}/blocks/{blockId}
+func (h *Handler) DeleteBlock(w http.ResponseWriter, r *http.Request) {
+	it, ok := h.ownedItem(w, r)
+	if !ok {
+		return
+	}
+	blockID, err := strconv.ParseInt(r.PathValue("blockId"), 10, 64)
+	if err != nil || blockID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid block id")
+		return
+	}
+	if err := h.repo.DeleteBlock(r.Context(), it.ID, blockID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "block not found")
+			return
+		}
+		log.Println("item delete block error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func parseLimitOffset(r *http.Request) (int, int, error) {
 	q := r.URL.Query()
 
@@ -330,6 +651,21 @@ func parseLimitOffset(r *http.Request) (int, int, error) {
 	return limit, offset, nil
 }
 
+const DefaultCurrency = "RUB"
+
+func validatePricing(price, deposit int64, weeklyPct, monthlyPct int, currency string) error {
+	if price < 0 || deposit < 0 {
+		return errors.New("price/deposit must be >= 0")
+	}
+	if weeklyPct < 0 || weeklyPct > 100 || monthlyPct < 0 || monthlyPct > 100 {
+		return errors.New("discount must be between 0 and 100")
+	}
+	if len(currency) != 3 || strings.ToUpper(currency) != currency {
+		return errors.New("currency must be a 3-letter code")
+	}
+	return nil
+}
+
 func parseListFilter(r *http.Request) (*ListFilter, error) {
 	limit, offset, err := parseLimitOffset(r)
 	if err != nil {
@@ -381,66 +717,6 @@ func parseListFilter(r *http.Request) (*ListFilter, error) {
 	return f, nil
 }
 
-func saveUploadedImage(r *http.Request) (string, error) {
-	const maxFileSize = 10 << 20 // 10MB - битовый сдвиг = 10*2^20
-
-	if err := r.ParseMultipartForm(maxFileSize); err != nil {
-		return "", errors.New("invalid multipart form")
-	}
-
-	file, hdr, err := r.FormFile("file")
-	if err != nil {
-		return "", errors.New("file is required")
-	}
-	defer file.Close()
-
-	ext := strings.ToLower(filepath.Ext(hdr.Filename))
-	if ext != ".png" && ext != ".jpg" && ext != ".jpeg" {
-		return "", errors.New("only .png, .jpg, .jpeg are allowed")
-	}
-
-	buf := make([]byte, 512)
-	n, err := file.Read(buf)
-	if err != nil && err != io.EOF {
-		return "", errors.New("failed to read file")
-	}
-	ct := http.DetectContentType(buf[:n])
-	if ct != "image/png" && ct != "image/jpeg" {
-		return "", errors.New("only PNG or JPEG content is allowed")
-	}
-	if _, err := file.Seek(0, io.SeekStart); err != nil {
-		return "", errors.New("failed to reset file reader")
-	}
-
-	root := os.Getenv("UPLOADS_DIR")
-	if strings.TrimSpace(root) == "" {
-		root = "uploads"
-	}
-	itemsDir := filepath.Join(root, "items")
-	if err := os.MkdirAll(itemsDir, 0o755); err != nil {
-		return "", errors.New("failed to prepare upload directory")
-	}
-
-	suffix := make([]byte, 6)
-	if _, err := rand.Read(suffix); err != nil {
-		return "", errors.New("failed to generate filename")
-	}
-	filename := fmt.Sprintf("%d_%s%s", time.Now().UnixNano(), hex.EncodeToString(suffix), ext)
-	dstPath := filepath.Join(itemsDir, filename)
-
-	dst, err := os.Create(dstPath)
-	if err != nil {
-		return "", errors.New("failed to save file")
-	}
-	defer dst.Close()
-
-	if _, err := io.Copy(dst, file); err != nil {
-		return "", errors.New("failed to write file")
-	}
-
-	return "/uploads/items/" + filename, nil
-}
-
 func (h *Handler) hydrateImages(ctx context.Context, items []Item) error {
 	for i := range items {
 		imgs, err := h.repo.ListImages(ctx, items[i].ID)
@@ -2,6 +2,8 @@ package item
 
 import(
 	"time"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/pricing"
 )
 
 type Status string
@@ -23,6 +25,18 @@ const (
 	DealSaleRent DealMode = "sale_rent"  // продам или сдам
 )
 
+// Granularity — шаг аренды: сутки или час.
+type Granularity string
+
+const (
+	GranularityDay  Granularity = "day"
+	GranularityHour Granularity = "hour"
+)
+
+func (g Granularity) Valid() bool {
+	return g == GranularityDay || g == GranularityHour
+}
+
 type ItemImage struct {
 	ID        int64     `json:"id"`
 	ItemID    int64     `json:"item_id"`
@@ -31,6 +45,18 @@ type ItemImage struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// Block — период, когда владелец сам закрыл вещь для аренды. Note приватная.
+type Block struct {
+	ID        int64     `json:"id"`
+	ItemID    int64     `json:"item_id"`
+	Start     time.Time `json:"start_at"`
+	End       time.Time `json:"end_at"` // не включительно
+	Note      *string   `json:"note,omitempty"`
+	ImportID  *int64    `json:"import_id,omitempty"` // блокировка из импортированного календаря
+	CreatedBy int64     `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Item struct {
 	ID      int64    `json:"id"`
 	OwnerID int64    `json:"owner_id"`
@@ -44,10 +70,74 @@ type Item struct {
 	Location    string `json:"location,omitempty"`
 	Category    string `json:"category,omitempty"`
 
+	WeeklyDiscountPct  int    `json:"weekly_discount_pct"`  // скидка от 7 дней аренды
+	MonthlyDiscountPct int    `json:"monthly_discount_pct"` // скидка от 30 дней аренды
+	Currency           string `json:"currency"`
+
+	BookingGranularity Granularity `json:"booking_granularity"` // price — за сутки или за час соответственно
+
+	RentRules
+
+	InstantBook             bool `json:"instant_book"`               // заявки одобряются сразу
+	InstantBookMinCompleted int  `json:"instant_book_min_completed"` // только для арендаторов с N+ завершёнными сделками
+
+	RequireHandoverCode bool `json:"require_handover_code"` // передача и возврат подтверждаются одноразовым кодом
+
+	CancellationPolicy CancellationPolicy `json:"cancellation_policy"`
+
 	Images []ItemImage `json:"images,omitempty"`
+	Rating *Rating     `json:"rating,omitempty"` // только в карточке вещи
+}
+
+// Rating — средняя оценка вещи по раскрытым отзывам арендаторов.
+type Rating struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
+
+
+// PatchRequest — частичное обновление вещи владельцем; nil = не менять.
+type PatchRequest struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Price       *int64  `json:"price,omitempty"`
+	Deposit     *int64  `json:"deposit,omitempty"`
+	Location    *string `json:"location,omitempty"`
+	Category    *string `json:"category,omitempty"`
+
+	WeeklyDiscountPct  *int    `json:"weekly_discount_pct,omitempty"`
+	MonthlyDiscountPct *int    `json:"monthly_discount_pct,omitempty"`
+	Currency           *string `json:"currency,omitempty"`
+
+	BookingGranularity *Granularity `json:"booking_granularity,omitempty"`
+
+	RentRulesPatch
+
+	InstantBook             *bool `json:"instant_book,omitempty"`
+	InstantBookMinCompleted *int  `json:"instant_book_min_completed,omitempty"`
+
+	RequireHandoverCode *bool `json:"require_handover_code,omitempty"`
+
+	CancellationPolicy *CancellationPolicy `json:"cancellation_policy,omitempty"`
 }
 
+// PricingRules — ценовые настройки для расчёта аренды.
+func (it Item) PricingRules() pricing.Rules {
+	return pricing.Rules{
+		UnitPrice:          it.Price,
+		WeeklyDiscountPct:  it.WeeklyDiscountPct,
+		MonthlyDiscountPct: it.MonthlyDiscountPct,
+		Deposit:            it.Deposit,
+		Currency:           it.Currency,
+		Hourly:             it.BookingGranularity == GranularityHour,
+	}
+}
 
+// AllowsRent — вещь можно арендовать.
+func (m DealMode) AllowsRent() bool {
+	return m == DealRent || m == DealSaleRent
+}
 
 func (s Status) Valid() bool {
 	switch s{
@@ -0,0 +1,122 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/item"
+)
+
+const selectBlockCols = `id, item_id, start_at, end_at, note, import_id, created_by, created_at`
+
+func scanBlock(rs interface{ Scan(...any) error }, b *item.Block) error {
+	return rs.Scan(
+		&b.ID,
+		&b.ItemID,
+		&b.Start,
+		&b.End,
+		&b.Note,
+		&b.ImportID,
+		&b.CreatedBy,
+		&b.CreatedAt,
+	)
+}
+
+// AddBlock — блокировка не может накрывать уже одобренную аренду; заявки в статусе requested не мешают
+// (они упадут на конфликте при одобрении).
+func (r *Repo) AddBlock(ctx context.Context, b *item.Block) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("items pgrepo: add block begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// сериализуем с одобрением броней, которое тоже берёт items FOR UPDATE
+	const lockQ = `SELECT 1 FROM items WHERE id = $1 FOR UPDATE`
+	var one int
+	if err := tx.QueryRow(ctx, lockQ, b.ItemID).Scan(&one); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return item.ErrNotFound
+		}
+		return fmt.Errorf("items pgrepo: add block lock item: %w", err)
+	}
+
+	const busyQ = `
+	SELECT EXISTS (
+		SELECT 1
+		FROM bookings
+		WHERE item_id = $1
+		  AND type = 'rent'
+		  AND status IN ('approved','handover_pending','in_use','return_pending')
+		  AND start_at < $3
+		  AND end_at   > $2
+	)
+	`
+	var busy bool
+	if err := tx.QueryRow(ctx, busyQ, b.ItemID, b.Start, b.End).Scan(&busy); err != nil {
+		return fmt.Errorf("items pgrepo: add block busy check: %w", err)
+	}
+	if busy {
+		return item.ErrBlockConflict
+	}
+
+	const insQ = `
+	INSERT INTO item_blocks (item_id, start_at, end_at, note, created_by)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING ` + selectBlockCols + `
+	`
+	if err := scanBlock(tx.QueryRow(ctx, insQ, b.ItemID, b.Start, b.End, b.Note, b.CreatedBy), b); err != nil {
+		return fmt.Errorf("items pgrepo: add block insert: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("items pgrepo: add block commit: %w", err)
+	}
+	return nil
+}
+
+// ListBlocks — текущие и будущие блокировки вещи.
+func (r *Repo) ListBlocks(ctx context.Context, itemID int64) ([]item.Block, error) {
+	const q = `
+	SELECT ` + selectBlockCols + `
+	FROM item_blocks
+	WHERE item_id = $1
+	  AND end_at > now()
+	ORDER BY start_at
+	`
+	rows, err := r.pool.Query(ctx, q, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("items pgrepo: list blocks: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]item.Block, 0, 8)
+	for rows.Next() {
+		var b item.Block
+		if err := scanBlock(rows, &b); err != nil {
+			return nil, fmt.Errorf("items pgrepo: list blocks scan: %w", err)
+		}
+		out = append(out, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("items pgrepo: list blocks rows: %w", err)
+	}
+	return out, nil
+}
+
+// DeleteBlock — только ручные блокировки; импортированные вернутся при следующей синхронизации,
+// их убирают удалением импорта.
+func (r *Repo) DeleteBlock(ctx context.Context, itemID, blockID int64) error {
+	const q = `DELETE FROM item_blocks WHERE id = $1 AND item_id = $2 AND import_id IS NULL`
+	ct, err := r.pool.Exec(ctx, q, blockID, itemID)
+	if err != nil {
+		return fmt.Errorf("items pgrepo: delete block: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return item.ErrNotFound
+	}
+	return nil
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/SHILOP0P/Yardly/backend/internal/item"
+	"github.com/SHILOP0P/Yardly/backend/internal/outbox"
+	outboxpg "github.com/SHILOP0P/Yardly/backend/internal/outbox/pgrepo"
 )
 
 type Repo struct {
@@ -20,17 +22,16 @@ func New(pool *pgxpool.Pool) *Repo {
 	return &Repo{pool: pool}
 }
 
-// GetByID: SELECT одной строки.
-// Если строки нет — возвращаем item.ErrNotFound (а не pgx.ErrNoRows).
-func (r *Repo) GetByID(ctx context.Context, id int64) (item.Item, error) {
-	const q = `
-SELECT id, owner_id, title, status, mode, description, price, deposit, location, category
-FROM items
-WHERE id = $1
-`
+const selectItemCols = `id, owner_id, title, status, mode, description, price, deposit, location, category,
+	weekly_discount_pct, monthly_discount_pct, currency, booking_granularity,
+	buffer_before_days, buffer_after_days, min_rental_days, max_rental_days,
+	buffer_before_hours, buffer_after_hours, min_rental_hours, max_rental_hours,
+	min_notice_hours, max_horizon_days,
+	instant_book, instant_book_min_completed, require_handover_code,
+	cancellation_policy`
 
-	var it item.Item
-	err := r.pool.QueryRow(ctx, q, id).Scan(
+func scanItem(rs interface{ Scan(...any) error }, it *item.Item) error {
+	return rs.Scan(
 		&it.ID,
 		&it.OwnerID,
 		&it.Title,
@@ -41,7 +42,38 @@ WHERE id = $1
 		&it.Deposit,
 		&it.Location,
 		&it.Category,
+		&it.WeeklyDiscountPct,
+		&it.MonthlyDiscountPct,
+		&it.Currency,
+		&it.BookingGranularity,
+		&it.BufferBeforeDays,
+		&it.BufferAfterDays,
+		&it.MinRentalDays,
+		&it.MaxRentalDays,
+		&it.BufferBeforeHours,
+		&it.BufferAfterHours,
+		&it.MinRentalHours,
+		&it.MaxRentalHours,
+		&it.MinNoticeHours,
+		&it.MaxHorizonDays,
+		&it.InstantBook,
+		&it.InstantBookMinCompleted,
+		&it.RequireHandoverCode,
+		&it.CancellationPolicy,
 	)
+}
+
+// GetByID: SELECT одной строки.
+// Если строки нет — возвращаем item.ErrNotFound (а не pgx.ErrNoRows).
+func (r *Repo) GetByID(ctx context.Context, id int64) (item.Item, error) {
+	const q = `
+SELECT ` + selectItemCols + `
+FROM items
+WHERE id = $1
+`
+
+	var it item.Item
+	err := scanItem(r.pool.QueryRow(ctx, q, id), &it)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return item.Item{}, item.ErrNotFound
@@ -52,6 +84,20 @@ WHERE id = $1
 	return it, nil
 }
 
+// GetRating — по visible_reviews: скрытые и ещё не раскрытые отзывы не учитываются.
+func (r *Repo) GetRating(ctx context.Context, id int64) (item.Rating, error) {
+	const q = `
+SELECT COALESCE(avg(rating), 0)::float8, count(*)::int
+FROM visible_reviews
+WHERE item_id = $1 AND author_role = 'requester'
+`
+	var rt item.Rating
+	if err := r.pool.QueryRow(ctx, q, id).Scan(&rt.Average, &rt.Count); err != nil {
+		return item.Rating{}, fmt.Errorf("items pgrepo: get rating: %w", err)
+	}
+	return rt, nil
+}
+
 func (r *Repo) List(ctx context.Context, f item.ListFilter) ([]item.Item, error) {
 	limit := f.Limit
 	if limit <= 0 || limit > 100 {
@@ -68,7 +114,7 @@ func (r *Repo) List(ctx context.Context, f item.ListFilter) ([]item.Item, error)
 	}
 
 	q := `
-		SELECT id, owner_id, title, status, mode, description, price, deposit, location, category
+		SELECT ` + selectItemCols + `
 		FROM items
 		WHERE status = ANY($1::text[])
 		`
@@ -121,18 +167,7 @@ func (r *Repo) List(ctx context.Context, f item.ListFilter) ([]item.Item, error)
 	var out []item.Item
 	for rows.Next() {
 		var it item.Item
-		if err := rows.Scan(
-			&it.ID,
-			&it.OwnerID,
-			&it.Title,
-			&it.Status,
-			&it.Mode,
-			&it.Description,
-			&it.Price,
-			&it.Deposit,
-			&it.Location,
-			&it.Category,
-		); err != nil {
+		if err := scanItem(rows, &it); err != nil {
 			return nil, fmt.Errorf("items pgrepo: list scan: %w", err)
 		}
 		out = append(out, it)
@@ -156,13 +191,37 @@ func (r *Repo) Create(ctx context.Context, it *item.Item) error {
 			price,
 			deposit,
 			location,
-			category
+			category,
+			weekly_discount_pct,
+			monthly_discount_pct,
+			currency,
+			booking_granularity,
+			buffer_before_days,
+			buffer_after_days,
+			min_rental_days,
+			max_rental_days,
+			buffer_before_hours,
+			buffer_after_hours,
+			min_rental_hours,
+			max_rental_hours,
+			min_notice_hours,
+			max_horizon_days,
+			instant_book,
+			instant_book_min_completed,
+			require_handover_code,
+			cancellation_policy
 		)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27)
 		RETURNING id
 	`
 
-	err := r.pool.QueryRow(ctx, q,
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("items pgrepo create begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, q,
 		it.OwnerID,
 		it.Title,
 		it.Status,
@@ -172,21 +231,123 @@ func (r *Repo) Create(ctx context.Context, it *item.Item) error {
 		it.Deposit,
 		it.Location,
 		it.Category,
+		it.WeeklyDiscountPct,
+		it.MonthlyDiscountPct,
+		it.Currency,
+		it.BookingGranularity,
+		it.BufferBeforeDays,
+		it.BufferAfterDays,
+		it.MinRentalDays,
+		it.MaxRentalDays,
+		it.BufferBeforeHours,
+		it.BufferAfterHours,
+		it.MinRentalHours,
+		it.MaxRentalHours,
+		it.MinNoticeHours,
+		it.MaxHorizonDays,
+		it.InstantBook,
+		it.InstantBookMinCompleted,
+		it.RequireHandoverCode,
+		it.CancellationPolicy,
 	).Scan(&it.ID)
 	if err != nil {
 		return fmt.Errorf("items pgrepo create: %w", err)
 	}
 
 	if len(it.Images) > 0 {
-		if err := r.insertImages(ctx, it.ID, it.Images); err != nil {
+		if err := insertImages(ctx, tx, it.ID, it.Images); err != nil {
 			return err
 		}
 	}
 
+	if err := outboxpg.AppendItemEventTx(ctx, tx, it.ID, outbox.EventItemCreated); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("items pgrepo create commit: %w", err)
+	}
 	return nil
 }
 
-func (r *Repo) insertImages(ctx context.Context, itemID int64, imgs []item.ItemImage) error {
+func (r *Repo) Update(ctx context.Context, id int64, req item.PatchRequest) (item.Item, error) {
+	const q = `
+	UPDATE items
+	SET title                = COALESCE($2, title),
+	    description          = COALESCE($3, description),
+	    price                = COALESCE($4, price),
+	    deposit              = COALESCE($5, deposit),
+	    location             = COALESCE($6, location),
+	    category             = COALESCE($7, category),
+	    weekly_discount_pct  = COALESCE($8, weekly_discount_pct),
+	    monthly_discount_pct = COALESCE($9, monthly_discount_pct),
+	    currency             = COALESCE($10, currency),
+	    booking_granularity  = COALESCE($11, booking_granularity),
+	    buffer_before_days   = COALESCE($12, buffer_before_days),
+	    buffer_after_days    = COALESCE($13, buffer_after_days),
+	    min_rental_days      = COALESCE($14, min_rental_days),
+	    max_rental_days      = COALESCE($15, max_rental_days),
+	    min_notice_hours     = COALESCE($16, min_notice_hours),
+	    max_horizon_days     = COALESCE($17, max_horizon_days),
+	    instant_book         = COALESCE($18, instant_book),
+	    instant_book_min_completed = COALESCE($19, instant_book_min_completed),
+	    require_handover_code = COALESCE($20, require_handover_code),
+	    cancellation_policy  = COALESCE($21, cancellation_policy),
+	    buffer_before_hours  = COALESCE($22, buffer_before_hours),
+	    buffer_after_hours   = COALESCE($23, buffer_after_hours),
+	    min_rental_hours     = COALESCE($24, min_rental_hours),
+	    max_rental_hours     = COALESCE($25, max_rental_hours)
+	WHERE id = $1
+	RETURNING ` + selectItemCols + `
+	`
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return item.Item{}, fmt.Errorf("items pgrepo: update begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var it item.Item
+	err = scanItem(tx.QueryRow(ctx, q, id,
+		req.Title,
+		req.Description,
+		req.Price,
+		req.Deposit,
+		req.Location,
+		req.Category,
+		req.WeeklyDiscountPct,
+		req.MonthlyDiscountPct,
+		req.Currency,
+		req.BookingGranularity,
+		req.BufferBeforeDays,
+		req.BufferAfterDays,
+		req.MinRentalDays,
+		req.MaxRentalDays,
+		req.MinNoticeHours,
+		req.MaxHorizonDays,
+		req.InstantBook,
+		req.InstantBookMinCompleted,
+		req.RequireHandoverCode,
+		req.CancellationPolicy,
+		req.BufferBeforeHours,
+		req.BufferAfterHours,
+		req.MinRentalHours,
+		req.MaxRentalHours,
+	), &it)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return item.Item{}, item.ErrNotFound
+		}
+		return item.Item{}, fmt.Errorf("items pgrepo: update: %w", err)
+	}
+	if err := outboxpg.AppendItemEventTx(ctx, tx, id, outbox.EventItemUpdated); err != nil {
+		return item.Item{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return item.Item{}, fmt.Errorf("items pgrepo: update commit: %w", err)
+	}
+	return it, nil
+}
+
+func insertImages(ctx context.Context, tx pgx.Tx, itemID int64, imgs []item.ItemImage) error {
 	const q = `
 		INSERT INTO item_images (item_id, url, sort_order)
 		VALUES ($1, $2, $3)
@@ -199,7 +360,7 @@ func (r *Repo) insertImages(ctx context.Context, itemID int64, imgs []item.ItemI
 			imgs[i].SortOrder = i + 1
 		}
 
-		if err := r.pool.QueryRow(ctx, q, imgs[i].ItemID, imgs[i].URL, imgs[i].SortOrder).
+		if err := tx.QueryRow(ctx, q, imgs[i].ItemID, imgs[i].URL, imgs[i].SortOrder).
 			Scan(&imgs[i].ID, &imgs[i].CreatedAt); err != nil {
 			return fmt.Errorf("items pgrepo insertImages: %w", err)
 		}
@@ -219,7 +380,7 @@ func (r *Repo) ListByOwnerPublic(ctx context.Context, ownerID int64, f item.List
 	}
 
 	q := `
-	SELECT id, owner_id, title, status, mode, description, price, deposit, location, category
+	SELECT ` + selectItemCols + `
 	FROM items
 	WHERE owner_id = $1
 	AND status IN ('active', 'in_use')
@@ -271,18 +432,7 @@ func (r *Repo) ListByOwnerPublic(ctx context.Context, ownerID int64, f item.List
 	out := make([]item.Item, 0, limit)
 	for rows.Next() {
 		var it item.Item
-		if err := rows.Scan(
-			&it.ID,
-			&it.OwnerID,
-			&it.Title,
-			&it.Status,
-			&it.Mode,
-			&it.Description,
-			&it.Price,
-			&it.Deposit,
-			&it.Location,
-			&it.Category,
-		); err != nil {
+		if err := scanItem(rows, &it); err != nil {
 			return nil, fmt.Errorf("items pgrepo: list by owner public scan: %w", err)
 		}
 		out = append(out, it)
@@ -304,7 +454,7 @@ func (r *Repo) ListMyItems(ctx context.Context, ownerID int64, f item.ListFilter
 	}
 
 	q := `
-	SELECT id, owner_id, title, status, mode, description, price, deposit, location, category
+	SELECT ` + selectItemCols + `
 	FROM items
 	WHERE owner_id = $1
 	AND status NOT IN ('deleted','transferred')
@@ -356,18 +506,7 @@ func (r *Repo) ListMyItems(ctx context.Context, ownerID int64, f item.ListFilter
 	out := make([]item.Item, 0, 64)
 	for rows.Next() {
 		var it item.Item
-		if err := rows.Scan(
-			&it.ID,
-			&it.OwnerID,
-			&it.Title,
-			&it.Status,
-			&it.Mode,
-			&it.Description,
-			&it.Price,
-			&it.Deposit,
-			&it.Location,
-			&it.Category,
-		); err != nil {
+		if err := scanItem(rows, &it); err != nil {
 			return nil, fmt.Errorf("items pgrepo: list my items scan: %w", err)
 		}
 		out = append(out, it)
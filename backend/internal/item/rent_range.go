@@ -0,0 +1,62 @@
+package item
+
+import (
+	"errors"
+	"time"
+)
+
+// ParseRentRange — интервал аренды [start, end) в UTC по шагу вещи.
+// day: YYYY-MM-DD, end включительно (end+24h); hour: RFC3339 на границе часа, end исключительно.
+func ParseRentRange(g Granularity, startRaw, endRaw string) (time.Time, time.Time, error) {
+	if g == GranularityHour {
+		start, err1 := parseHour(startRaw)
+		end, err2 := parseHour(endRaw)
+		if err1 != nil || err2 != nil {
+			return time.Time{}, time.Time{}, errors.New("start/end must be RFC3339 on a whole hour")
+		}
+		if !end.After(start) {
+			return time.Time{}, time.Time{}, errors.New("end must be after start")
+		}
+		return start, end, nil
+	}
+
+	startDay, err1 := time.Parse("2006-01-02", startRaw)
+	endDay, err2 := time.Parse("2006-01-02", endRaw)
+	if err1 != nil || err2 != nil {
+		return time.Time{}, time.Time{}, errors.New("start/end must be YYYY-MM-DD")
+	}
+	if endDay.Before(startDay) {
+		return time.Time{}, time.Time{}, errors.New("end must be >= start")
+	}
+	start := time.Date(startDay.Year(), startDay.Month(), startDay.Day(), 0, 0, 0, 0, time.UTC)
+	endExclusive := time.Date(endDay.Year(), endDay.Month(), endDay.Day(), 0, 0, 0, 0, time.UTC).Add(24 * time.Hour)
+	return start, endExclusive, nil
+}
+
+// ParseRentEnd — новый конец аренды (для продления) в том же формате, что и ParseRentRange.
+func ParseRentEnd(g Granularity, raw string) (time.Time, error) {
+	if g == GranularityHour {
+		end, err := parseHour(raw)
+		if err != nil {
+			return time.Time{}, errors.New("end_at must be RFC3339 on a whole hour")
+		}
+		return end, nil
+	}
+	endDay, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, errors.New("end_at must be YYYY-MM-DD")
+	}
+	return time.Date(endDay.Year(), endDay.Month(), endDay.Day(), 0, 0, 0, 0, time.UTC).Add(24 * time.Hour), nil
+}
+
+func parseHour(raw string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t = t.UTC()
+	if !t.Equal(t.Truncate(time.Hour)) {
+		return time.Time{}, errors.New("not a whole hour")
+	}
+	return t, nil
+}
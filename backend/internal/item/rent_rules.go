@@ -0,0 +1,151 @@
+package item
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrRentRule = errors.New("rent rules violated")
+
+// RentRules — ограничения владельца на аренду. Нули = без ограничения.
+// Срок и буферы задаются в единицах шага вещи: *_days — только у посуточных, *_hours — только у почасовых.
+type RentRules struct {
+	BufferBeforeDays  int `json:"buffer_before_days"` // дни подготовки перед арендой
+	BufferAfterDays   int `json:"buffer_after_days"`  // дни на возврат/обслуживание после аренды
+	MinRentalDays     int `json:"min_rental_days"`
+	MaxRentalDays     int `json:"max_rental_days"`
+	BufferBeforeHours int `json:"buffer_before_hours"` // то же для почасовых вещей
+	BufferAfterHours  int `json:"buffer_after_hours"`
+	MinRentalHours    int `json:"min_rental_hours"`
+	MaxRentalHours    int `json:"max_rental_hours"`
+	MinNoticeHours    int `json:"min_notice_hours"` // за сколько часов до начала можно бронировать
+	MaxHorizonDays    int `json:"max_horizon_days"` // как далеко вперёд можно бронировать
+}
+
+const maxRuleValue = 3650
+
+// RentRulesPatch — частичное изменение правил; nil = не менять.
+type RentRulesPatch struct {
+	BufferBeforeDays  *int `json:"buffer_before_days,omitempty"`
+	BufferAfterDays   *int `json:"buffer_after_days,omitempty"`
+	MinRentalDays     *int `json:"min_rental_days,omitempty"`
+	MaxRentalDays     *int `json:"max_rental_days,omitempty"`
+	BufferBeforeHours *int `json:"buffer_before_hours,omitempty"`
+	BufferAfterHours  *int `json:"buffer_after_hours,omitempty"`
+	MinRentalHours    *int `json:"min_rental_hours,omitempty"`
+	MaxRentalHours    *int `json:"max_rental_hours,omitempty"`
+	MinNoticeHours    *int `json:"min_notice_hours,omitempty"`
+	MaxHorizonDays    *int `json:"max_horizon_days,omitempty"`
+}
+
+// Apply — правила после применения патча.
+func (p RentRulesPatch) Apply(r RentRules) RentRules {
+	set := func(dst *int, v *int) {
+		if v != nil {
+			*dst = *v
+		}
+	}
+	set(&r.BufferBeforeDays, p.BufferBeforeDays)
+	set(&r.BufferAfterDays, p.BufferAfterDays)
+	set(&r.MinRentalDays, p.MinRentalDays)
+	set(&r.MaxRentalDays, p.MaxRentalDays)
+	set(&r.BufferBeforeHours, p.BufferBeforeHours)
+	set(&r.BufferAfterHours, p.BufferAfterHours)
+	set(&r.MinRentalHours, p.MinRentalHours)
+	set(&r.MaxRentalHours, p.MaxRentalHours)
+	set(&r.MinNoticeHours, p.MinNoticeHours)
+	set(&r.MaxHorizonDays, p.MaxHorizonDays)
+	return r
+}
+
+// Validate — проверка самих настроек (при создании/изменении вещи) для шага вещи g:
+// поля чужих единиц должны быть нулевыми, иначе при смене шага «3 дня» молча стали бы «3 часами».
+func (r RentRules) Validate(g Granularity) error {
+	for _, v := range []int{
+		r.BufferBeforeDays, r.BufferAfterDays, r.MinRentalDays, r.MaxRentalDays,
+		r.BufferBeforeHours, r.BufferAfterHours, r.MinRentalHours, r.MaxRentalHours,
+		r.MinNoticeHours, r.MaxHorizonDays,
+	} {
+		if v < 0 || v > maxRuleValue {
+			return fmt.Errorf("rent rules must be between 0 and %d", maxRuleValue)
+		}
+	}
+	if g == GranularityHour {
+		if r.BufferBeforeDays != 0 || r.BufferAfterDays != 0 || r.MinRentalDays != 0 || r.MaxRentalDays != 0 {
+			return errors.New("hourly items use buffer_*_hours and min/max_rental_hours; day rules must be 0")
+		}
+	} else if r.BufferBeforeHours != 0 || r.BufferAfterHours != 0 || r.MinRentalHours != 0 || r.MaxRentalHours != 0 {
+		return errors.New("daily items use buffer_*_days and min/max_rental_days; hour rules must be 0")
+	}
+	if r.MaxRentalDays > 0 && r.MinRentalDays > r.MaxRentalDays {
+		return errors.New("min_rental_days must be <= max_rental_days")
+	}
+	if r.MaxRentalHours > 0 && r.MinRentalHours > r.MaxRentalHours {
+		return errors.New("min_rental_hours must be <= max_rental_hours")
+	}
+	return nil
+}
+
+// BufferBefore — сколько перед уже занятой арендой вещь недоступна (подготовка).
+func (r RentRules) BufferBefore() time.Duration {
+	return time.Duration(r.BufferBeforeDays)*24*time.Hour + time.Duration(r.BufferBeforeHours)*time.Hour
+}
+
+// BufferAfter — сколько после уже занятой аренды вещь недоступна (возврат/обслуживание).
+func (r RentRules) BufferAfter() time.Duration {
+	return time.Duration(r.BufferAfterDays)*24*time.Hour + time.Duration(r.BufferAfterHours)*time.Hour
+}
+
+// Gap — минимальный зазор между двумя арендами вещи. Буфер нужен с обеих сторон каждой пары:
+// [start-before, end+after) новой брони не пересекает занятую, а сама новая — занятую с её буферами.
+// Для интервалов ненулевой длины это то же, что расширить занятую на max(before, after) в обе стороны,
+// поэтому результат не зависит от того, какая бронь появилась раньше.
+func (r RentRules) Gap() time.Duration {
+	return max(r.BufferBefore(), r.BufferAfter())
+}
+
+// Unit — шаг аренды вещи, в нём считается длительность.
+func (g Granularity) Unit() time.Duration {
+	if g == GranularityHour {
+		return time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// CheckLength — длительность [start, end) в единицах шага вещи (неполный — за целый):
+// у посуточных — в пределах min/max_rental_days, у почасовых — min/max_rental_hours.
+func (r RentRules) CheckLength(g Granularity, start, end time.Time) error {
+	unit := g.Unit()
+	n := int((end.Sub(start) + unit - 1) / unit)
+	minN, maxN, name := r.MinRentalDays, r.MaxRentalDays, "days"
+	if g == GranularityHour {
+		minN, maxN, name = r.MinRentalHours, r.MaxRentalHours, "hours"
+	}
+	if minN > 0 && n < minN {
+		return fmt.Errorf("%w: rental must be at least %d %s", ErrRentRule, minN, name)
+	}
+	if maxN > 0 && n > maxN {
+		return fmt.Errorf("%w: rental must be at most %d %s", ErrRentRule, maxN, name)
+	}
+	return nil
+}
+
+// CheckWindow — начало аренды не раньше min_notice_hours и не дальше max_horizon_days от now.
+func (r RentRules) CheckWindow(start, now time.Time) error {
+	if r.MinNoticeHours > 0 && start.Before(now.Add(time.Duration(r.MinNoticeHours)*time.Hour)) {
+		return fmt.Errorf("%w: booking requires %d hours notice", ErrRentRule, r.MinNoticeHours)
+	}
+	if r.MaxHorizonDays > 0 && start.After(now.Add(time.Duration(r.MaxHorizonDays)*24*time.Hour)) {
+		return fmt.Errorf("%w: booking is too far ahead (max %d days)", ErrRentRule, r.MaxHorizonDays)
+	}
+	return nil
+}
+
+// Check — все правила для новой заявки.
+func (r RentRules) Check(g Granularity, start, end, now time.Time) error {
+	if err := r.CheckLength(g, start, end); err != nil {
+		return err
+	}
+	return r.CheckWindow(start, now)
+}
@@ -0,0 +1,79 @@
+package item
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckLengthByGranularity(t *testing.T) {
+	start := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name  string
+		rules RentRules
+		g     Granularity
+		dur   time.Duration
+		ok    bool
+	}{
+		{"day within", RentRules{MinRentalDays: 2, MaxRentalDays: 5}, GranularityDay, 3 * 24 * time.Hour, true},
+		{"day too short", RentRules{MinRentalDays: 2}, GranularityDay, 24 * time.Hour, false},
+		{"day too long", RentRules{MaxRentalDays: 5}, GranularityDay, 6 * 24 * time.Hour, false},
+		{"hour within", RentRules{MinRentalHours: 2, MaxRentalHours: 8}, GranularityHour, 3 * time.Hour, true},
+		{"hour too short", RentRules{MinRentalHours: 2}, GranularityHour, time.Hour, false},
+		{"hour too long", RentRules{MaxRentalHours: 8}, GranularityHour, 9 * time.Hour, false},
+		{"hour no limits", RentRules{}, GranularityHour, time.Hour, true},
+		{"partial unit rounds up", RentRules{MinRentalHours: 2}, GranularityHour, 90 * time.Minute, true},
+		{"day rules do not apply to hours", RentRules{MinRentalDays: 3}, GranularityHour, time.Hour, true},
+		{"hour rules do not apply to days", RentRules{MinRentalHours: 48}, GranularityDay, 24 * time.Hour, true},
+	}
+	for _, c := range cases {
+		err := c.rules.CheckLength(c.g, start, start.Add(c.dur))
+		if c.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		}
+		if !c.ok && !errors.Is(err, ErrRentRule) {
+			t.Errorf("%s: err = %v, want ErrRentRule", c.name, err)
+		}
+	}
+}
+
+func TestGapIsSymmetric(t *testing.T) {
+	r := RentRules{BufferBeforeDays: 1, BufferAfterDays: 0}
+	if got := r.Gap(); got != 24*time.Hour {
+		t.Fatalf("Gap = %v, want 24h", got)
+	}
+	r = RentRules{BufferBeforeDays: 1, BufferAfterDays: 3}
+	if got := r.Gap(); got != 3*24*time.Hour {
+		t.Fatalf("Gap = %v, want 72h", got)
+	}
+}
+
+func TestValidateByGranularity(t *testing.T) {
+	cases := []struct {
+		name  string
+		rules RentRules
+		g     Granularity
+		ok    bool
+	}{
+		{"day rules on day item", RentRules{MinRentalDays: 3, BufferAfterDays: 1}, GranularityDay, true},
+		{"hour rules on hour item", RentRules{MinRentalHours: 3, BufferAfterHours: 2}, GranularityHour, true},
+		{"day length on hour item", RentRules{MinRentalDays: 3}, GranularityHour, false},
+		{"day buffer on hour item", RentRules{BufferAfterDays: 1}, GranularityHour, false},
+		{"hour length on day item", RentRules{MaxRentalHours: 5}, GranularityDay, false},
+		{"hour min > max", RentRules{MinRentalHours: 5, MaxRentalHours: 2}, GranularityHour, false},
+		{"notice and horizon on any", RentRules{MinNoticeHours: 2, MaxHorizonDays: 30}, GranularityHour, true},
+	}
+	for _, c := range cases {
+		err := c.rules.Validate(c.g)
+		if c.ok != (err == nil) {
+			t.Errorf("%s: Validate = %v, want ok=%v", c.name, err, c.ok)
+		}
+	}
+}
+
+func TestHourBuffers(t *testing.T) {
+	r := RentRules{BufferBeforeHours: 2, BufferAfterHours: 1}
+	if r.BufferBefore() != 2*time.Hour || r.BufferAfter() != time.Hour || r.Gap() != 2*time.Hour {
+		t.Fatalf("before=%v after=%v gap=%v", r.BufferBefore(), r.BufferAfter(), r.Gap())
+	}
+}
@@ -17,6 +17,8 @@ type Repo interface {
 	Create(ctx context.Context, it *Item) error
 	List(ctx context.Context, f ListFilter) ([]Item, error)
 	GetByID(ctx context.Context, id int64) (Item, error)
+	Update(ctx context.Context, id int64, req PatchRequest) (Item, error)
+	GetRating(ctx context.Context, id int64) (Rating, error)
 
 	ListByOwnerPublic(ctx context.Context, ownerID int64, f ListFilter)([]Item, error)
 	ListMyItems(ctx context.Context, ownerId int64, f ListFilter)([]Item, error)
@@ -25,5 +27,10 @@ type Repo interface {
 	ListImages(ctx context.Context, itemID int64) ([]ItemImage, error)
 	AddImage(ctx context.Context, itemID int64, url string) (ItemImage, error)
 	DeleteImage(ctx context.Context, itemID int64, imageID int64) error
+
+	// Blocks
+	AddBlock(ctx context.Context, b *Block) error
+	ListBlocks(ctx context.Context, itemID int64) ([]Block, error)
+	DeleteBlock(ctx context.Context, itemID, blockID int64) error
 }
  
@@ -10,6 +10,8 @@ func RegisterRoutes(mux *http.ServeMux, repo Repo, authMw Middleware) {
 	mux.Handle("POST /api/items", authMw(http.HandlerFunc(h.Create)))
 	mux.HandleFunc("GET /api/items", h.List)
 	mux.HandleFunc("GET /api/items/{id}", h.GetByID)
+	mux.Handle("PATCH /api/items/{id}", authMw(http.HandlerFunc(h.Patch)))
+	mux.HandleFunc("GET /api/items/{id}/quote", h.Quote)
 
 	mux.Handle("GET /api/my/items", authMw(http.HandlerFunc(h.ListMyItems)))
 	mux.HandleFunc("GET /api/users/{id}/items", h.ListByOwnerPublic)
@@ -18,4 +20,9 @@ func RegisterRoutes(mux *http.ServeMux, repo Repo, authMw Middleware) {
 	mux.HandleFunc("GET /api/items/{id}/images", h.ListImages)
 	mux.Handle("POST /api/items/{id}/images", authMw(http.HandlerFunc(h.AddImage)))
 	mux.Handle("DELETE /api/items/{id}/images/{imageId}", authMw(http.HandlerFunc(h.DeleteImage)))
+
+	// Blocks (только владелец; публично видны как занятые даты в availability)
+	mux.Handle("GET /api/items/{id}/blocks", authMw(http.HandlerFunc(h.ListBlocks)))
+	mux.Handle("POST /api/items/{id}/blocks", authMw(http.HandlerFunc(h.AddBlock)))
+	mux.Handle("DELETE /api/items/{id}/blocks/{blockId}", authMw(http.HandlerFunc(h.DeleteBlock)))
 }
@@ -0,0 +1,248 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/auth"
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/httpx"
+	"github.com/SHILOP0P/Yardly/backend/internal/upload"
+)
+
+type Handler struct {
+	repo     Repo
+	bookings BookingGetter
+}
+
+type BookingGetter interface {
+	GetByID(ctx context.Context, id int64) (booking.Booking, error)
+}
+
+func NewHandler(repo Repo, bookings BookingGetter) *Handler {
+	return &Handler{repo: repo, bookings: bookings}
+}
+
+const maxBodyLen = 4000
+
+type sendMessageRequestDTO struct {
+	Body string `json:"body"`
+}
+
+type markReadRequestDTO struct {
+	UpTo int64 `json:"up_to,omitempty"` // id последнего прочитанного; 0 — всё
+}
+
+// participantBooking — {id} из пути, если пользователь сторона брони (как в ListEvents).
+func (h *Handler) participantBooking(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return 0, 0, false
+	}
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return 0, 0, false
+	}
+	b, err := h.bookings.GetByID(r.Context(), bookingID)
+	if err != nil {
+		if errors.Is(err, booking.ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "booking not found")
+			return 0, 0, false
+		}
+		log.Println("messages get booking error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return 0, 0, false
+	}
+	if b.RequesterID != userID && b.OwnerID != userID {
+		httpx.WriteError(w, http.StatusForbidden, "forbidden")
+		return 0, 0, false
+	}
+	return bookingID, userID, true
+}
+
+// GET /api/bookings/{id}/messages?limit=&offset= — новые сверху
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	bookingID, userID, ok := h.participantBooking(w, r)
+	if !ok {
+		return
+	}
+	limit := httpx.QueryInt(r, "limit", 50, 1, 200)
+	offset := httpx.QueryInt(r, "offset", 0, 0, 1_000_000)
+
+	msgs, err := h.repo.ListByBooking(r.Context(), bookingID, limit, offset)
+	if err != nil {
+		log.Println("list messages error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	unread, err := h.repo.CountUnread(r.Context(), bookingID, userID)
+	if err != nil {
+		log.Println("count unread messages error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, map[string]any{
+		"items":  msgs,
+		"unread": unread,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// attachmentDir — вложения брони лежат в PRIVATE_UPLOADS_DIR/messages/<booking_id>,
+// наружу их отдаёт только Attachment после проверки участника.
+func attachmentDir(bookingID int64) string {
+	return filepath.Join("messages", strconv.FormatInt(bookingID, 10))
+}
+
+func attachmentURL(bookingID int64, name string) string {
+	return fmt.Sprintf("/api/bookings/%d/messages/attachments/%s", bookingID, name)
+}
+
+// POST /api/bookings/{id}/messages — JSON {"body"} или multipart (file + body).
+// Файл сохраняется только после проверки текста и удаляется, если сообщение не записалось.
+func (h *Handler) Send(w http.ResponseWriter, r *http.Request) {
+	bookingID, userID, ok := h.participantBooking(w, r)
+	if !ok {
+		return
+	}
+
+	m := Message{BookingID: bookingID, SenderID: userID}
+	multipart := strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data")
+	if multipart {
+		if err := r.ParseMultipartForm(upload.MaxImageSize); err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, "invalid multipart form")
+			return
+		}
+		m.Body = r.FormValue("body")
+	} else {
+		var dto sendMessageRequestDTO
+		if err := httpx.ReadJSON(r, &dto); err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, "invalid json body")
+			return
+		}
+		m.Body = dto.Body
+	}
+
+	m.Body = strings.TrimSpace(m.Body)
+	if m.Body == "" && !multipart {
+		httpx.WriteError(w, http.StatusBadRequest, "body is required")
+		return
+	}
+	if len([]rune(m.Body)) > maxBodyLen {
+		httpx.WriteError(w, http.StatusBadRequest, "body is too long")
+		return
+	}
+
+	var saved string
+	if multipart {
+		name, err := upload.SavePrivateImage(r, attachmentDir(bookingID))
+		if err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		saved = name
+		url := attachmentURL(bookingID, name)
+		m.AttachmentURL = &url
+	}
+
+	if err := h.repo.Create(r.Context(), &m); err != nil {
+		log.Println("send message error:", err)
+		if saved != "" {
+			if err := upload.RemovePrivate(attachmentDir(bookingID), saved); err != nil {
+				log.Println("remove message attachment error:", err)
+			}
+		}
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	httpx.WriteJSON(w, http.StatusCreated, m)
+}
+
+// GET /api/bookings/{id}/messages/attachments/{name} — вложение переписки, только участникам брони
+func (h *Handler) Attachment(w http.ResponseWriter, r *http.Request) {
+	bookingID, _, ok := h.participantBooking(w, r)
+	if !ok {
+		return
+	}
+
+	f, err := upload.OpenPrivate(attachmentDir(bookingID), r.PathValue("name"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			httpx.WriteError(w, http.StatusNotFound, "attachment not found")
+			return
+		}
+		log.Println("open message attachment error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil || st.IsDir() {
+		httpx.WriteError(w, http.StatusNotFound, "attachment not found")
+		return
+	}
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, st.Name(), st.ModTime(), f)
+}
+
+// POST /api/bookings/{id}/messages/read — опционально {"up_to": id}
+func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	bookingID, userID, ok := h.participantBooking(w, r)
+	if !ok {
+		return
+	}
+	var dto markReadRequestDTO
+	if r.ContentLength != 0 {
+		if err := httpx.ReadJSON(r, &dto); err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, "invalid json body")
+			return
+		}
+	}
+	if dto.UpTo < 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid up_to")
+		return
+	}
+
+	if err := h.repo.MarkRead(r.Context(), bookingID, userID, dto.UpTo); err != nil {
+		log.Println("mark messages read error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/my/messages/unread — счётчики непрочитанного по броням
+func (h *Handler) Unread(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	items, err := h.repo.ListUnread(r.Context(), userID)
+	if err != nil {
+		log.Println("list unread messages error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	total := 0
+	for _, u := range items {
+		total += u.Count
+	}
+	httpx.WriteJSON(w, http.StatusOK, map[string]any{
+		"total":    total,
+		"bookings": items,
+	})
+}
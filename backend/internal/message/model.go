@@ -0,0 +1,19 @@
+package message
+
+import "time"
+
+// Message — сообщение в переписке по брони.
+type Message struct {
+	ID            int64     `json:"id"`
+	BookingID     int64     `json:"booking_id"`
+	SenderID      int64     `json:"sender_id"`
+	Body          string    `json:"body"`
+	AttachmentURL *string   `json:"attachment_url,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Unread — непрочитанные сообщения по одной брони.
+type Unread struct {
+	BookingID int64 `json:"booking_id"`
+	Count     int   `json:"count"`
+}
@@ -0,0 +1,180 @@
+package pgrepo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/message"
+)
+
+type Repo struct {
+	pool *pgxpool.Pool
+}
+
+func New(pool *pgxpool.Pool) *Repo {
+	return &Repo{pool: pool}
+}
+
+const SelectMessageCols = `id, booking_id, sender_id, body, attachment_url, created_at`
+
+func ScanMessage(rs interface{ Scan(...any) error }, m *message.Message) error {
+	return rs.Scan(
+		&m.ID,
+		&m.BookingID,
+		&m.SenderID,
+		&m.Body,
+		&m.AttachmentURL,
+		&m.CreatedAt,
+	)
+}
+
+// Create — своё сообщение сразу считается прочитанным отправителем.
+func (r *Repo) Create(ctx context.Context, m *message.Message) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("messages pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const q = `
+	INSERT INTO booking_messages (booking_id, sender_id, body, attachment_url)
+	VALUES ($1, $2, $3, $4)
+	RETURNING ` + SelectMessageCols + `
+	`
+	if err := ScanMessage(tx.QueryRow(ctx, q, m.BookingID, m.SenderID, m.Body, m.AttachmentURL), m); err != nil {
+		return fmt.Errorf("messages pgrepo: insert: %w", err)
+	}
+	if err := markReadTx(ctx, tx, m.BookingID, m.SenderID, m.ID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("messages pgrepo: commit: %w", err)
+	}
+	return nil
+}
+
+// ListByBooking — новые сверху.
+func (r *Repo) ListByBooking(ctx context.Context, bookingID int64, limit, offset int) ([]message.Message, error) {
+	return ListByBooking(ctx, r.pool, bookingID, limit, offset)
+}
+
+// ListByBooking — общий запрос для участников и админки.
+func ListByBooking(ctx context.Context, q interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+}, bookingID int64, limit, offset int) ([]message.Message, error) {
+	const sqlQ = `
+	SELECT ` + SelectMessageCols + `
+	FROM booking_messages
+	WHERE booking_id = $1
+	ORDER BY id DESC
+	LIMIT $2 OFFSET $3
+	`
+	rows, err := q.Query(ctx, sqlQ, bookingID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("messages pgrepo: list: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]message.Message, 0, limit)
+	for rows.Next() {
+		var m message.Message
+		if err := ScanMessage(rows, &m); err != nil {
+			return nil, fmt.Errorf("messages pgrepo: list scan: %w", err)
+		}
+		out = append(out, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("messages pgrepo: list rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *Repo) MarkRead(ctx context.Context, bookingID, userID, upTo int64) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("messages pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if upTo <= 0 {
+		const lastQ = `SELECT COALESCE(max(id), 0) FROM booking_messages WHERE booking_id = $1`
+		if err := tx.QueryRow(ctx, lastQ, bookingID).Scan(&upTo); err != nil {
+			return fmt.Errorf("messages pgrepo: last message: %w", err)
+		}
+	}
+	if err := markReadTx(ctx, tx, bookingID, userID, upTo); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("messages pgrepo: commit: %w", err)
+	}
+	return nil
+}
+
+func markReadTx(ctx context.Context, tx pgx.Tx, bookingID, userID, upTo int64) error {
+	const q = `
+	INSERT INTO booking_message_reads (booking_id, user_id, last_read_message_id, updated_at)
+	VALUES ($1, $2, $3, now())
+	ON CONFLICT (booking_id, user_id) DO UPDATE
+	SET last_read_message_id = GREATEST(booking_message_reads.last_read_message_id, EXCLUDED.last_read_message_id),
+	    updated_at = now()
+	`
+	if _, err := tx.Exec(ctx, q, bookingID, userID, upTo); err != nil {
+		return fmt.Errorf("messages pgrepo: mark read: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) CountUnread(ctx context.Context, bookingID, userID int64) (int, error) {
+	const q = `
+	SELECT count(*)::int
+	FROM booking_messages m
+	LEFT JOIN booking_message_reads rd ON rd.booking_id = m.booking_id AND rd.user_id = $2
+	WHERE m.booking_id = $1
+	  AND m.sender_id <> $2
+	  AND m.id > COALESCE(rd.last_read_message_id, 0)
+	`
+	var n int
+	if err := r.pool.QueryRow(ctx, q, bookingID, userID).Scan(&n); err != nil {
+		return 0, fmt.Errorf("messages pgrepo: count unread: %w", err)
+	}
+	return n, nil
+}
+
+// ListUnread — брони пользователя (как владельца или арендатора) с непрочитанными сообщениями.
+func (r *Repo) ListUnread(ctx context.Context, userID int64) ([]message.Unread, error) {
+	const q = `
+	SELECT m.booking_id, count(*)::int
+	FROM booking_messages m
+	JOIN bookings b ON b.id = m.booking_id
+	LEFT JOIN booking_message_reads rd ON rd.booking_id = m.booking_id AND rd.user_id = $1
+	WHERE (b.owner_id = $1 OR b.requester_id = $1)
+	  AND m.sender_id <> $1
+	  AND m.id > COALESCE(rd.last_read_message_id, 0)
+	GROUP BY m.booking_id
+	ORDER BY max(m.id) DESC
+	`
+	rows, err := r.pool.Query(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("messages pgrepo: list unread: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]message.Unread, 0, 8)
+	for rows.Next() {
+		var u message.Unread
+		if err := rows.Scan(&u.BookingID, &u.Count); err != nil {
+			return nil, fmt.Errorf("messages pgrepo: list unread scan: %w", err)
+		}
+		out = append(out, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("messages pgrepo: list unread rows: %w", err)
+	}
+	return out, nil
+}
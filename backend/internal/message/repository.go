@@ -0,0 +1,13 @@
+package message
+
+import "context"
+
+type Repo interface {
+	Create(ctx context.Context, m *Message) error
+	ListByBooking(ctx context.Context, bookingID int64, limit, offset int) ([]Message, error)
+
+	// MarkRead — дочитано до upTo включительно (0 = до последнего); назад не откатывается.
+	MarkRead(ctx context.Context, bookingID, userID, upTo int64) error
+	CountUnread(ctx context.Context, bookingID, userID int64) (int, error)
+	ListUnread(ctx context.Context, userID int64) ([]Unread, error)
+}
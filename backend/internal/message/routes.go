@@ -0,0 +1,15 @@
+package message
+
+import "net/http"
+
+type Middleware func(http.Handler) http.Handler
+
+func RegisterRoutes(mux *http.ServeMux, repo Repo, bookings BookingGetter, authMw Middleware) {
+	h := NewHandler(repo, bookings)
+
+	mux.Handle("GET /api/bookings/{id}/messages", authMw(http.HandlerFunc(h.List)))
+	mux.Handle("POST /api/bookings/{id}/messages", authMw(http.HandlerFunc(h.Send)))
+	mux.Handle("POST /api/bookings/{id}/messages/read", authMw(http.HandlerFunc(h.MarkRead)))
+	mux.Handle("GET /api/bookings/{id}/messages/attachments/{name}", authMw(http.HandlerFunc(h.Attachment)))
+	mux.Handle("GET /api/my/messages/unread", authMw(http.HandlerFunc(h.Unread)))
+}
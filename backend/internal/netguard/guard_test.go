@@ -0,0 +1,96 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestAllowed(t *testing.T) {
+	g := New(nil)
+	cases := []struct {
+		addr string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false}, // метаданные облака
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false}, // IPv4-mapped не обходит запрет
+		{"::ffff:10.0.0.1", false},
+	}
+	for _, c := range cases {
+		if got := g.Allowed(netip.MustParseAddr(c.addr)); got != c.want {
+			t.Errorf("Allowed(%s) = %v, want %v", c.addr, got, c.want)
+		}
+	}
+}
+
+func TestAllowlist(t *testing.T) {
+	allow, err := ParseAllowlist(" 127.0.0.1 , 10.0.0.0/8,")
+	if err != nil {
+		t.Fatalf("ParseAllowlist: %v", err)
+	}
+	g := New(allow)
+	for _, a := range []string{"127.0.0.1", "10.20.30.40", "::ffff:127.0.0.1"} {
+		if !g.Allowed(netip.MustParseAddr(a)) {
+			t.Errorf("Allowed(%s) = false, want true (allowlisted)", a)
+		}
+	}
+	for _, a := range []string{"127.0.0.2", "192.168.0.1", "::1"} {
+		if g.Allowed(netip.MustParseAddr(a)) {
+			t.Errorf("Allowed(%s) = true, want false", a)
+		}
+	}
+
+	if _, err := ParseAllowlist("not-an-ip"); err == nil {
+		t.Error("ParseAllowlist(not-an-ip): want error")
+	}
+}
+
+func TestControl(t *testing.T) {
+	g := New(nil)
+	if err := g.Control("tcp4", "127.0.0.1:80", nil); !errors.Is(err, ErrBlocked) {
+		t.Errorf("Control(127.0.0.1:80) = %v, want ErrBlocked", err)
+	}
+	if err := g.Control("tcp6", "[::1]:443", nil); !errors.Is(err, ErrBlocked) {
+		t.Errorf("Control([::1]:443) = %v, want ErrBlocked", err)
+	}
+	if err := g.Control("tcp4", "8.8.8.8:443", nil); err != nil {
+		t.Errorf("Control(8.8.8.8:443) = %v, want nil", err)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	g := New(nil)
+	ctx := context.Background()
+	cases := []struct {
+		url  string
+		want error
+	}{
+		{"ftp://example.com/x", ErrInvalidURL},
+		{"/relative", ErrInvalidURL},
+		{"http://127.0.0.1:8080/hook", ErrBlocked},
+		{"http://[::1]/hook", ErrBlocked},
+		{"http://169.254.169.254/latest/meta-data", ErrBlocked},
+		{"http://localhost/hook", ErrBlocked},
+		{"https://8.8.8.8/hook", nil},
+	}
+	for _, c := range cases {
+		err := g.CheckURL(ctx, c.url)
+		if c.want == nil && err != nil || c.want != nil && !errors.Is(err, c.want) {
+			t.Errorf("CheckURL(%q) = %v, want %v", c.url, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	MaxAttempts  = 8
+	baseBackoff  = 10 * time.Second
+	maxBackoff   = time.Hour
+	lease        = time.Minute
+	batchSize    = 200
+	retryBatch   = 50
+	maxErrLength = 500
+)
+
+// Backoff — пауза после attempt-й неудачи: 10s, 20s, 40s, ... не больше часа.
+func Backoff(attempt int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
+
+// Dispatcher — раздаёт события outbox потребителям, у каждого свой чекпоинт.
+type Dispatcher struct {
+	repo      Repo
+	consumers []Consumer
+}
+
+func NewDispatcher(repo Repo, consumers ...Consumer) *Dispatcher {
+	return &Dispatcher{repo: repo, consumers: consumers}
+}
+
+// RunOnce — один проход по всем потребителям; ошибка одного не останавливает остальных.
+func (d *Dispatcher) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	total := 0
+	var errs []error
+	for _, c := range d.consumers {
+		n, err := d.runConsumer(ctx, c, now)
+		total += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("outbox consumer %s: %w", c.Name(), err))
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+func (d *Dispatcher) runConsumer(ctx context.Context, c Consumer, now time.Time) (int, error) {
+	name := c.Name()
+	cp, ok, err := d.repo.Claim(ctx, name, now, now.Add(lease))
+	if err != nil || !ok {
+		return 0, err
+	}
+	defer func() {
+		if err := d.repo.Release(context.WithoutCancel(ctx), name); err != nil {
+			log.Printf("outbox consumer %s release: %v", name, err)
+		}
+	}()
+
+	handled := 0
+
+	// сначала отложенные: по одному на агрегат, чтобы не нарушить порядок внутри него
+	retries, err := d.repo.DueRetries(ctx, name, now, retryBatch)
+	if err != nil {
+		return handled, err
+	}
+	for _, rt := range retries {
+		if err := ctx.Err(); err != nil {
+			return handled, err
+		}
+		if herr := c.Handle(ctx, rt.Event); herr != nil {
+			attempts := rt.Attempts + 1
+			msg := errText(herr)
+			if attempts >= MaxAttempts {
+				log.Printf("outbox consumer %s: event %d dead-lettered after %d attempts: %s", name, rt.ID, attempts, msg)
+				if err := d.repo.DeadLetter(ctx, name, rt.ID, attempts, msg); err != nil {
+					return handled, err
+				}
+				continue
+			}
+			if err := d.repo.Defer(ctx, name, rt.Event, attempts, now.Add(Backoff(attempts)), &msg); err != nil {
+				return handled, err
+			}
+			continue
+		}
+		if err := d.repo.Done(ctx, name, rt.ID); err != nil {
+			return handled, err
+		}
+		handled++
+	}
+
+	// новые события; агрегат с отложенными событиями ставим в хвост его очереди
+	blocked, err := d.repo.BlockedAggregates(ctx, name)
+	if err != nil {
+		return handled, err
+	}
+	events, err := d.repo.ListAfter(ctx, cp, batchSize)
+	if err != nil {
+		return handled, err
+	}
+	for _, ev := range events {
+		if err := ctx.Err(); err != nil {
+			return handled, err
+		}
+		key := ev.Aggregate()
+		switch {
+		case blocked[key]:
+			if err := d.repo.Defer(ctx, name, ev, 0, now, nil); err != nil {
+				return handled, err
+			}
+		default:
+			if herr := c.Handle(ctx, ev); herr != nil {
+				msg := errText(herr)
+				if err := d.repo.Defer(ctx, name, ev, 1, now.Add(Backoff(1)), &msg); err != nil {
+					return handled, err
+				}
+				blocked[key] = true
+			} else {
+				handled++
+			}
+		}
+		if err := d.repo.Advance(ctx, name, ev.Position()); err != nil {
+			return handled, err
+		}
+	}
+	return handled, nil
+}
+
+func errText(err error) string {
+	msg := err.Error()
+	if len(msg) > maxErrLength {
+		msg = msg[:maxErrLength]
+	}
+	return msg
+}
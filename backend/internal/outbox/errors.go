@@ -0,0 +1,5 @@
+package outbox
+
+import "errors"
+
+var ErrNotFound = errors.New("outbox entry not found")
@@ -0,0 +1,134 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AggregateBooking  = "booking"
+	AggregateItem     = "item"
+	AggregateWaitlist = "waitlist"
+)
+
+// Типы событий. Админские действия дополнительно пишутся как "admin.<action>" по сущности действия.
+const (
+	EventBookingEvent  = "booking.event" // каждая запись booking_events, payload — BookingEventPayload
+	EventItemCreated   = "item.created"  // payload — ItemPayload
+	EventItemUpdated   = "item.updated"
+	EventItemBlocked   = "item.blocked"
+	EventItemUnblocked = "item.unblocked"
+	EventItemDeleted   = "item.deleted"
+
+	EventWaitlistOffered = "waitlist.offered" // даты освободились и придержаны за ожидающим, payload — WaitlistPayload
+
+	AdminEventPrefix = "admin."
+)
+
+type Event struct {
+	ID            int64           `json:"id"`
+	TxID          int64           `json:"txid"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	Type          string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// Position — место события в общем порядке чтения.
+func (e Event) Position() Checkpoint {
+	return Checkpoint{TxID: e.TxID, ID: e.ID}
+}
+
+func (e Event) Aggregate() AggregateKey {
+	return AggregateKey{Type: e.AggregateType, ID: e.AggregateID}
+}
+
+type AggregateKey struct {
+	Type string
+	ID   int64
+}
+
+// Checkpoint — последнее обработанное потребителем событие; события читаются по (txid, id).
+type Checkpoint struct {
+	TxID int64 `json:"last_txid"`
+	ID   int64 `json:"last_id"`
+}
+
+// Retry — отложенное событие потребителя: упало с ошибкой или ждёт более раннее событие своего агрегата.
+type Retry struct {
+	Event
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     *string
+}
+
+type DeadLetter struct {
+	Consumer  string    `json:"consumer"`
+	Event     Event     `json:"event"`
+	Attempts  int       `json:"attempts"`
+	LastError *string   `json:"last_error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConsumerState — чекпоинт и отставание потребителя (для админки).
+type ConsumerState struct {
+	Name        string     `json:"name"`
+	Checkpoint             // last_txid, last_id
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Lag         int64      `json:"lag"` // событий после чекпоинта
+	Retries     int64      `json:"retries"`
+	DeadLetters int64      `json:"dead_letters"`
+}
+
+// BookingEventPayload — снимок события брони вместе с участниками и датами.
+type BookingEventPayload struct {
+	EventID     int64      `json:"event_id"`
+	BookingID   int64      `json:"booking_id"`
+	ItemID      int64      `json:"item_id"`
+	Type        string     `json:"type"`
+	OwnerID     int64      `json:"owner_id"`
+	RequesterID int64      `json:"requester_id"`
+	ActorUserID *int64     `json:"actor_user_id"`
+	Action      string     `json:"action"`
+	FromStatus  *string    `json:"from_status"`
+	ToStatus    *string    `json:"to_status"` // nil — событие без смены статуса
+	StartAt     *time.Time `json:"start_at"`
+	EndAt       *time.Time `json:"end_at"`
+	OccurredAt  time.Time  `json:"occurred_at"`
+}
+
+// ItemPayload — снимок вещи на момент изменения.
+type ItemPayload struct {
+	ItemID     int64      `json:"item_id"`
+	OwnerID    int64      `json:"owner_id"`
+	Title      string     `json:"title"`
+	Status     string     `json:"status"`
+	Mode       string     `json:"mode"`
+	Price      int64      `json:"price"`
+	Deposit    int64      `json:"deposit"`
+	Currency   string     `json:"currency"`
+	BlockedAt  *time.Time `json:"blocked_at"`
+	OccurredAt time.Time  `json:"occurred_at"`
+}
+
+// WaitlistPayload — запись листа ожидания на момент изменения.
+type WaitlistPayload struct {
+	WaitlistID    int64      `json:"waitlist_id"`
+	ItemID        int64      `json:"item_id"`
+	UserID        int64      `json:"user_id"`
+	Status        string     `json:"status"`
+	StartAt       time.Time  `json:"start_at"`
+	EndAt         time.Time  `json:"end_at"`
+	PriorityUntil *time.Time `json:"priority_until"`
+	OccurredAt    time.Time  `json:"occurred_at"`
+}
+
+// AdminPayload — запись admin_events.
+type AdminPayload struct {
+	ActorUserID int64           `json:"actor_user_id"`
+	Action      string          `json:"action"`
+	Reason      *string         `json:"reason,omitempty"`
+	Meta        json.RawMessage `json:"meta,omitempty"`
+}
@@ -0,0 +1,309 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/outbox"
+)
+
+type Repo struct {
+	pool *pgxpool.Pool
+}
+
+func New(pool *pgxpool.Pool) *Repo {
+	return &Repo{pool: pool}
+}
+
+// Exec — транзакция изменения: событие попадает в outbox только вместе с ним.
+type Exec interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+const SelectEventCols = `e.id, e.txid, e.aggregate_type, e.aggregate_id, e.event_type, e.payload, e.created_at`
+
+func ScanEvent(rs interface{ Scan(...any) error }, ev *outbox.Event, extra ...any) error {
+	dest := append([]any{&ev.ID, &ev.TxID, &ev.AggregateType, &ev.AggregateID, &ev.Type, &ev.Payload, &ev.CreatedAt}, extra...)
+	return rs.Scan(dest...)
+}
+
+// AppendTx — произвольное событие с готовым payload (JSON).
+func AppendTx(ctx context.Context, q Exec, aggregateType string, aggregateID int64, eventType string, payload []byte) error {
+	const sqlQ = `
+	INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, payload)
+	VALUES ($1, $2, $3, $4)
+	`
+	if _, err := q.Exec(ctx, sqlQ, aggregateType, aggregateID, eventType, payload); err != nil {
+		return fmt.Errorf("outbox pgrepo: append: %w", err)
+	}
+	return nil
+}
+
+// AppendBookingEventTx — снимок записи booking_events вместе с бронью (outbox.BookingEventPayload).
+func AppendBookingEventTx(ctx context.Context, q Exec, eventID int64) error {
+	const sqlQ = `
+	INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, payload)
+	SELECT $2, b.id, $3, json_build_object(
+		'event_id', e.id,
+		'booking_id', b.id,
+		'item_id', b.item_id,
+		'type', b.type,
+		'owner_id', b.owner_id,
+		'requester_id', b.requester_id,
+		'actor_user_id', e.actor_user_id,
+		'action', e.action,
+		'from_status', e.from_status,
+		'to_status', e.to_status,
+		'start_at', b.start_at,
+		'end_at', b.end_at,
+		'occurred_at', e.created_at
+	)
+	FROM booking_events e
+	JOIN bookings b ON b.id = e.booking_id
+	WHERE e.id = $1
+	`
+	if _, err := q.Exec(ctx, sqlQ, eventID, outbox.AggregateBooking, outbox.EventBookingEvent); err != nil {
+		return fmt.Errorf("outbox pgrepo: append booking event: %w", err)
+	}
+	return nil
+}
+
+// AppendItemEventTx — снимок текущей строки items (outbox.ItemPayload).
+func AppendItemEventTx(ctx context.Context, q Exec, itemID int64, eventType string) error {
+	const sqlQ = `
+	INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, payload)
+	SELECT $2, i.id, $3, json_build_object(
+		'item_id', i.id,
+		'owner_id', i.owner_id,
+		'title', i.title,
+		'status', i.status,
+		'mode', i.mode,
+		'price', i.price,
+		'deposit', i.deposit,
+		'currency', i.currency,
+		'blocked_at', i.blocked_at,
+		'occurred_at', now()
+	)
+	FROM items i
+	WHERE i.id = $1
+	`
+	if _, err := q.Exec(ctx, sqlQ, itemID, outbox.AggregateItem, eventType); err != nil {
+		return fmt.Errorf("outbox pgrepo: append item event: %w", err)
+	}
+	return nil
+}
+
+// AppendWaitlistEventTx — снимок текущей строки booking_waitlist (outbox.WaitlistPayload).
+func AppendWaitlistEventTx(ctx context.Context, q Exec, waitlistID int64, eventType string) error {
+	const sqlQ = `
+	INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, payload)
+	SELECT $2, w.id, $3, json_build_object(
+		'waitlist_id', w.id,
+		'item_id', w.item_id,
+		'user_id', w.user_id,
+		'status', w.status,
+		'start_at', w.start_at,
+		'end_at', w.end_at,
+		'priority_until', w.priority_until,
+		'occurred_at', now()
+	)
+	FROM booking_waitlist w
+	WHERE w.id = $1
+	`
+	if _, err := q.Exec(ctx, sqlQ, waitlistID, outbox.AggregateWaitlist, eventType); err != nil {
+		return fmt.Errorf("outbox pgrepo: append waitlist event: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) Claim(ctx context.Context, consumer string, now, leaseUntil time.Time) (outbox.Checkpoint, bool, error) {
+	const ins = `INSERT INTO outbox_consumers (name) VALUES ($1) ON CONFLICT DO NOTHING`
+	if _, err := r.pool.Exec(ctx, ins, consumer); err != nil {
+		return outbox.Checkpoint{}, false, fmt.Errorf("outbox pgrepo: register consumer: %w", err)
+	}
+
+	const q = `
+	UPDATE outbox_consumers
+	SET locked_until = $3
+	WHERE name = $1
+	  AND (locked_until IS NULL OR locked_until <= $2)
+	RETURNING last_txid, last_id
+	`
+	var cp outbox.Checkpoint
+	if err := r.pool.QueryRow(ctx, q, consumer, now, leaseUntil).Scan(&cp.TxID, &cp.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return outbox.Checkpoint{}, false, nil
+		}
+		return outbox.Checkpoint{}, false, fmt.Errorf("outbox pgrepo: claim: %w", err)
+	}
+	return cp, true, nil
+}
+
+func (r *Repo) Release(ctx context.Context, consumer string) error {
+	if _, err := r.pool.Exec(ctx, `UPDATE outbox_consumers SET locked_until = NULL WHERE name = $1`, consumer); err != nil {
+		return fmt.Errorf("outbox pgrepo: release: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) ListAfter(ctx context.Context, after outbox.Checkpoint, limit int) ([]outbox.Event, error) {
+	// txid < xmin текущего снимка: все транзакции до него завершены, и ни одно событие
+	// с меньшей позицией уже не появится. Для одного агрегата порядок txid совпадает с порядком
+	// изменений: xid выдаётся при первой записи, а она идёт после блокировки строки агрегата.
+	const q = `
+	SELECT ` + SelectEventCols + `
+	FROM outbox_events e
+	WHERE (e.txid, e.id) > ($1, $2)
+	  AND e.txid < pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+	ORDER BY e.txid, e.id
+	LIMIT $3
+	`
+	rows, err := r.pool.Query(ctx, q, after.TxID, after.ID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("outbox pgrepo: list after: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]outbox.Event, 0)
+	for rows.Next() {
+		var ev outbox.Event
+		if err := ScanEvent(rows, &ev); err != nil {
+			return nil, fmt.Errorf("outbox pgrepo: list after scan: %w", err)
+		}
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox pgrepo: list after rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *Repo) Advance(ctx context.Context, consumer string, to outbox.Checkpoint) error {
+	const q = `
+	UPDATE outbox_consumers
+	SET last_txid = $2, last_id = $3, updated_at = now()
+	WHERE name = $1
+	  AND (last_txid, last_id) < ($2, $3)
+	`
+	if _, err := r.pool.Exec(ctx, q, consumer, to.TxID, to.ID); err != nil {
+		return fmt.Errorf("outbox pgrepo: advance: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) DueRetries(ctx context.Context, consumer string, now time.Time, limit int) ([]outbox.Retry, error) {
+	const q = `
+	SELECT ` + SelectEventCols + `, h.attempts, h.next_attempt_at, h.last_error
+	FROM (
+		SELECT DISTINCT ON (rt.aggregate_type, rt.aggregate_id)
+		       rt.event_id, rt.attempts, rt.next_attempt_at, rt.last_error
+		FROM outbox_retries rt
+		JOIN outbox_events ev ON ev.id = rt.event_id
+		WHERE rt.consumer = $1
+		ORDER BY rt.aggregate_type, rt.aggregate_id, ev.txid, ev.id
+	) h
+	JOIN outbox_events e ON e.id = h.event_id
+	WHERE h.next_attempt_at <= $2
+	ORDER BY e.txid, e.id
+	LIMIT $3
+	`
+	rows, err := r.pool.Query(ctx, q, consumer, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("outbox pgrepo: due retries: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]outbox.Retry, 0)
+	for rows.Next() {
+		var rt outbox.Retry
+		if err := ScanEvent(rows, &rt.Event, &rt.Attempts, &rt.NextAttemptAt, &rt.LastError); err != nil {
+			return nil, fmt.Errorf("outbox pgrepo: due retries scan: %w", err)
+		}
+		out = append(out, rt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox pgrepo: due retries rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *Repo) BlockedAggregates(ctx context.Context, consumer string) (map[outbox.AggregateKey]bool, error) {
+	const q = `
+	SELECT DISTINCT aggregate_type, aggregate_id
+	FROM outbox_retries
+	WHERE consumer = $1
+	`
+	rows, err := r.pool.Query(ctx, q, consumer)
+	if err != nil {
+		return nil, fmt.Errorf("outbox pgrepo: blocked aggregates: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[outbox.AggregateKey]bool)
+	for rows.Next() {
+		var k outbox.AggregateKey
+		if err := rows.Scan(&k.Type, &k.ID); err != nil {
+			return nil, fmt.Errorf("outbox pgrepo: blocked aggregates scan: %w", err)
+		}
+		out[k] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox pgrepo: blocked aggregates rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *Repo) Defer(ctx context.Context, consumer string, ev outbox.Event, attempts int, next time.Time, lastErr *string) error {
+	const q = `
+	INSERT INTO outbox_retries (consumer, event_id, aggregate_type, aggregate_id, attempts, next_attempt_at, last_error)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (consumer, event_id) DO UPDATE
+	SET attempts = EXCLUDED.attempts,
+	    next_attempt_at = EXCLUDED.next_attempt_at,
+	    last_error = EXCLUDED.last_error
+	`
+	if _, err := r.pool.Exec(ctx, q, consumer, ev.ID, ev.AggregateType, ev.AggregateID, attempts, next, lastErr); err != nil {
+		return fmt.Errorf("outbox pgrepo: defer: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) Done(ctx context.Context, consumer string, eventID int64) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM outbox_retries WHERE consumer = $1 AND event_id = $2`, consumer, eventID); err != nil {
+		return fmt.Errorf("outbox pgrepo: done: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) DeadLetter(ctx context.Context, consumer string, eventID int64, attempts int, lastErr string) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("outbox pgrepo: dead letter begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const ins = `
+	INSERT INTO outbox_dead_letters (consumer, event_id, attempts, last_error)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (consumer, event_id) DO UPDATE
+	SET attempts = EXCLUDED.attempts,
+	    last_error = EXCLUDED.last_error,
+	    created_at = now()
+	`
+	if _, err := tx.Exec(ctx, ins, consumer, eventID, attempts, lastErr); err != nil {
+		return fmt.Errorf("outbox pgrepo: dead letter insert: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM outbox_retries WHERE consumer = $1 AND event_id = $2`, consumer, eventID); err != nil {
+		return fmt.Errorf("outbox pgrepo: dead letter delete retry: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("outbox pgrepo: dead letter commit: %w", err)
+	}
+	return nil
+}
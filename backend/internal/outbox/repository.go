@@ -0,0 +1,31 @@
+package outbox
+
+import (
+	"context"
+	"time"
+)
+
+// Consumer — подписчик outbox. Доставка at-least-once: Handle должен быть идемпотентным
+// (например, по Event.ID). Ошибка откладывает событие и все следующие события того же агрегата.
+type Consumer interface {
+	Name() string
+	Handle(ctx context.Context, ev Event) error
+}
+
+type Repo interface {
+	// Claim — берёт lease потребителя до leaseUntil; ok = false, если он занят другим экземпляром.
+	Claim(ctx context.Context, consumer string, now, leaseUntil time.Time) (cp Checkpoint, ok bool, err error)
+	Release(ctx context.Context, consumer string) error
+
+	// ListAfter — события после чекпоинта только из завершённых транзакций, по (txid, id).
+	ListAfter(ctx context.Context, after Checkpoint, limit int) ([]Event, error)
+	Advance(ctx context.Context, consumer string, to Checkpoint) error
+
+	// DueRetries — по одному (самому раннему) отложенному событию на агрегат, если оно созрело.
+	DueRetries(ctx context.Context, consumer string, now time.Time, limit int) ([]Retry, error)
+	BlockedAggregates(ctx context.Context, consumer string) (map[AggregateKey]bool, error)
+	Defer(ctx context.Context, consumer string, ev Event, attempts int, next time.Time, lastErr *string) error
+	Done(ctx context.Context, consumer string, eventID int64) error
+	// DeadLetter — переносит отложенное событие в dead letters; следующие события агрегата разблокируются.
+	DeadLetter(ctx context.Context, consumer string, eventID int64, attempts int, lastErr string) error
+}
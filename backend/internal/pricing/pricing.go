@@ -0,0 +1,108 @@
+// Package pricing — расчёт стоимости аренды. Без БД и HTTP, только арифметика в минимальных единицах.
+package pricing
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	WeeklyMinDays  = 7  // с этой длительности действует недельная скидка
+	MonthlyMinDays = 30 // с этой — месячная (вместо недельной)
+)
+
+var ErrInvalidRange = errors.New("invalid rent range")
+
+type Unit string
+
+const (
+	UnitDay  Unit = "day"
+	UnitHour Unit = "hour"
+)
+
+// Rules — ценовые настройки вещи. UnitPrice — за сутки, а при Hourly — за час.
+type Rules struct {
+	UnitPrice          int64
+	Hourly             bool
+	WeeklyDiscountPct  int
+	MonthlyDiscountPct int
+	Deposit            int64
+	Currency           string
+}
+
+type Quote struct {
+	Unit        Unit   `json:"unit"`
+	Units       int    `json:"units"`
+	UnitPrice   int64  `json:"unit_price"`
+	Days        int    `json:"days"` // длительность в сутках (для скидок), неполные сутки — целые
+	Subtotal    int64  `json:"subtotal"`
+	DiscountPct int    `json:"discount_pct"`
+	Discount    int64  `json:"discount"`
+	Total       int64  `json:"total"`
+	Deposit     int64  `json:"deposit"`
+	Currency    string `json:"currency"`
+}
+
+// countUnits — число шагов в [start, end); неполный шаг считается целым.
+func countUnits(start, end time.Time, step time.Duration) (int, error) {
+	if !end.After(start) {
+		return 0, ErrInvalidRange
+	}
+	d := end.Sub(start)
+	n := int(d / step)
+	if d%step != 0 {
+		n++
+	}
+	return n, nil
+}
+
+// Days — число суток аренды в [start, end).
+func Days(start, end time.Time) (int, error) {
+	return countUnits(start, end, 24*time.Hour)
+}
+
+// DiscountPct — применимая скидка: месячная перекрывает недельную.
+func DiscountPct(r Rules, days int) int {
+	switch {
+	case days >= MonthlyMinDays && r.MonthlyDiscountPct > 0:
+		return r.MonthlyDiscountPct
+	case days >= WeeklyMinDays && r.WeeklyDiscountPct > 0:
+		return r.WeeklyDiscountPct
+	default:
+		return 0
+	}
+}
+
+// QuoteRent — цена за шаг × число шагов минус скидка; скидка округляется вниз.
+func QuoteRent(r Rules, start, end time.Time) (Quote, error) {
+	days, err := Days(start, end)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	unit, units := UnitDay, days
+	if r.Hourly {
+		unit = UnitHour
+		units, err = countUnits(start, end, time.Hour)
+		if err != nil {
+			return Quote{}, err
+		}
+	}
+
+	subtotal := r.UnitPrice * int64(units)
+	pct := DiscountPct(r, days)
+	discount := subtotal * int64(pct) / 100
+
+	return Quote{
+		Unit:        unit,
+		Units:       units,
+		UnitPrice:   r.UnitPrice,
+		Days:        days,
+		Subtotal:    subtotal,
+		DiscountPct: pct,
+		Discount:    discount,
+		Total:       subtotal - discount,
+		Deposit:     r.Deposit,
+		Currency:    r.Currency,
+	}, nil
+}
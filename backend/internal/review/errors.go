@@ -0,0 +1,10 @@
+package review
+
+import "errors"
+
+var (
+	ErrNotFound        = errors.New("review not found")
+	ErrNotCompleted    = errors.New("booking is not completed")
+	ErrWindowClosed    = errors.New("review window is closed")
+	ErrAlreadyReviewed = errors.New("review already submitted")
+)
@@ -0,0 +1,185 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/auth"
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/httpx"
+)
+
+type Handler struct {
+	repo     Repo
+	bookings BookingGetter
+}
+
+type BookingGetter interface {
+	GetByID(ctx context.Context, id int64) (booking.Booking, error)
+}
+
+func NewHandler(repo Repo, bookings BookingGetter) *Handler {
+	return &Handler{repo: repo, bookings: bookings}
+}
+
+const maxBodyLen = 2000
+
+type createReviewRequestDTO struct {
+	Rating int    `json:"rating"`
+	Body   string `json:"body,omitempty"`
+}
+
+// participantBooking — бронь из пути, если пользователь её сторона.
+func (h *Handler) participantBooking(w http.ResponseWriter, r *http.Request) (booking.Booking, int64, bool) {
+	bookingID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || bookingID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid booking id")
+		return booking.Booking{}, 0, false
+	}
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return booking.Booking{}, 0, false
+	}
+	b, err := h.bookings.GetByID(r.Context(), bookingID)
+	if err != nil {
+		if errors.Is(err, booking.ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "booking not found")
+			return booking.Booking{}, 0, false
+		}
+		log.Println("reviews get booking error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return booking.Booking{}, 0, false
+	}
+	if b.RequesterID != userID && b.OwnerID != userID {
+		httpx.WriteError(w, http.StatusForbidden, "forbidden")
+		return booking.Booking{}, 0, false
+	}
+	return b, userID, true
+}
+
+func writeReviewError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, ErrNotCompleted):
+		httpx.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrWindowClosed):
+		httpx.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrAlreadyReviewed):
+		httpx.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrNotFound):
+		httpx.WriteError(w, http.StatusNotFound, err.Error())
+	default:
+		log.Println(op+" error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// POST /api/bookings/{id}/reviews — {"rating": 1..5, "body"}
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	b, userID, ok := h.participantBooking(w, r)
+	if !ok {
+		return
+	}
+	if b.Status != booking.StatusCompleted {
+		httpx.WriteError(w, http.StatusConflict, ErrNotCompleted.Error())
+		return
+	}
+
+	var dto createReviewRequestDTO
+	if err := httpx.ReadJSON(r, &dto); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid json body")
+		return
+	}
+	if dto.Rating < 1 || dto.Rating > 5 {
+		httpx.WriteError(w, http.StatusBadRequest, "rating must be between 1 and 5")
+		return
+	}
+	dto.Body = strings.TrimSpace(dto.Body)
+	if len([]rune(dto.Body)) > maxBodyLen {
+		httpx.WriteError(w, http.StatusBadRequest, "body is too long")
+		return
+	}
+
+	rv := Review{
+		BookingID: b.ID,
+		ItemID:    b.ItemID,
+		AuthorID:  userID,
+		Rating:    dto.Rating,
+		Body:      dto.Body,
+	}
+	if userID == b.RequesterID {
+		rv.AuthorRole = RoleRequester
+		rv.TargetUserID = b.OwnerID
+	} else {
+		rv.AuthorRole = RoleOwner
+		rv.TargetUserID = b.RequesterID
+	}
+
+	if err := h.repo.Create(r.Context(), &rv); err != nil {
+		writeReviewError(w, "create review", err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusCreated, rv)
+}
+
+// GET /api/bookings/{id}/reviews — свой отзыв и встречный, если уже раскрыт
+func (h *Handler) ForBooking(w http.ResponseWriter, r *http.Request) {
+	b, userID, ok := h.participantBooking(w, r)
+	if !ok {
+		return
+	}
+	out, err := h.repo.ForBooking(r.Context(), b.ID, userID)
+	if err != nil {
+		writeReviewError(w, "booking reviews", err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, out)
+}
+
+// GET /api/items/{id}/reviews?limit=&offset=
+func (h *Handler) ListByItem(w http.ResponseWriter, r *http.Request) {
+	itemID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || itemID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid item id")
+		return
+	}
+	limit := httpx.QueryInt(r, "limit", 20, 1, 100)
+	offset := httpx.QueryInt(r, "offset", 0, 0, 1_000_000)
+
+	items, err := h.repo.ListByItem(r.Context(), itemID, limit, offset)
+	if err != nil {
+		writeReviewError(w, "list item reviews", err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, map[string]any{
+		"items":  items,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GET /api/users/{id}/reviews?limit=&offset=
+func (h *Handler) ListByUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	limit := httpx.QueryInt(r, "limit", 20, 1, 100)
+	offset := httpx.QueryInt(r, "offset", 0, 0, 1_000_000)
+
+	items, err := h.repo.ListByUser(r.Context(), userID, limit, offset)
+	if err != nil {
+		writeReviewError(w, "list user reviews", err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, map[string]any{
+		"items":  items,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
@@ -0,0 +1,38 @@
+package review
+
+import "time"
+
+// AuthorRole — кем автор был в брони.
+type AuthorRole string
+
+const (
+	RoleRequester AuthorRole = "requester" // оценивает вещь и владельца
+	RoleOwner     AuthorRole = "owner"     // оценивает арендатора
+)
+
+// Review — отзыв одной стороны о другой после завершённой брони.
+type Review struct {
+	ID           int64      `json:"id"`
+	BookingID    int64      `json:"booking_id"`
+	ItemID       int64      `json:"item_id"`
+	AuthorID     int64      `json:"author_id"`
+	TargetUserID int64      `json:"target_user_id"`
+	AuthorRole   AuthorRole `json:"author_role"`
+	Rating       int        `json:"rating"` // 1..5
+	Body         string     `json:"body,omitempty"`
+
+	HiddenAt     *time.Time `json:"hidden_at,omitempty"`
+	HiddenBy     *int64     `json:"hidden_by,omitempty"`
+	HiddenReason *string    `json:"hidden_reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BookingReviews — отзывы по брони глазами участника: встречный отзыв
+// показывается только после раскрытия (обе стороны написали или окно закрылось).
+type BookingReviews struct {
+	Mine           *Review   `json:"mine,omitempty"`
+	Counterpart    *Review   `json:"counterpart,omitempty"`
+	WindowClosesAt time.Time `json:"window_closes_at"`
+	CanReview      bool      `json:"can_review"`
+}
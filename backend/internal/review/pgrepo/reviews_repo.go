@@ -0,0 +1,193 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/review"
+)
+
+type Repo struct {
+	pool   *pgxpool.Pool
+	window time.Duration // сколько после завершения брони можно оставить отзыв
+}
+
+func New(pool *pgxpool.Pool, window time.Duration) *Repo {
+	return &Repo{pool: pool, window: window}
+}
+
+const SelectReviewCols = `
+	id, booking_id, item_id, author_id, target_user_id, author_role,
+	rating, body, hidden_at, hidden_by, hidden_reason, created_at
+`
+
+func ScanReview(rs interface{ Scan(...any) error }, rv *review.Review) error {
+	return rs.Scan(
+		&rv.ID,
+		&rv.BookingID,
+		&rv.ItemID,
+		&rv.AuthorID,
+		&rv.TargetUserID,
+		&rv.AuthorRole,
+		&rv.Rating,
+		&rv.Body,
+		&rv.HiddenAt,
+		&rv.HiddenBy,
+		&rv.HiddenReason,
+		&rv.CreatedAt,
+	)
+}
+
+// completedAtQ — момент завершения брони: по событию смены статуса,
+// для старых броней — по последнему подтверждению сторон.
+const completedAtQ = `
+	SELECT b.status = 'completed',
+		COALESCE(
+			(SELECT max(e.created_at) FROM booking_events e
+			 WHERE e.booking_id = b.id AND e.to_status = 'completed'),
+			GREATEST(
+				b.return_confirmed_by_owner_at, b.return_confirmed_by_requester_at,
+				b.handover_confirmed_by_owner_at, b.handover_confirmed_by_requester_at
+			),
+			b.created_at
+		)
+	FROM bookings b
+	WHERE b.id = $1
+`
+
+func (r *Repo) windowClosesAt(ctx context.Context, q interface {
+	QueryRow(context.Context, string, ...any) pgx.Row
+}, bookingID int64) (time.Time, error) {
+	var completed bool
+	var completedAt time.Time
+	if err := q.QueryRow(ctx, completedAtQ, bookingID).Scan(&completed, &completedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, review.ErrNotCompleted
+		}
+		return time.Time{}, fmt.Errorf("reviews pgrepo: completed at: %w", err)
+	}
+	if !completed {
+		return time.Time{}, review.ErrNotCompleted
+	}
+	return completedAt.Add(r.window), nil
+}
+
+func (r *Repo) Create(ctx context.Context, rv *review.Review) error {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("reviews pgrepo: begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// бронь не должна поменяться, пока пишем отзыв
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM bookings WHERE id = $1 FOR SHARE`, rv.BookingID); err != nil {
+		return fmt.Errorf("reviews pgrepo: lock booking: %w", err)
+	}
+	closesAt, err := r.windowClosesAt(ctx, tx, rv.BookingID)
+	if err != nil {
+		return err
+	}
+	if !time.Now().UTC().Before(closesAt) {
+		return review.ErrWindowClosed
+	}
+
+	const q = `
+	INSERT INTO reviews (booking_id, item_id, author_id, target_user_id, author_role, rating, body, window_closes_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING ` + SelectReviewCols
+	err = ScanReview(tx.QueryRow(ctx, q,
+		rv.BookingID, rv.ItemID, rv.AuthorID, rv.TargetUserID, rv.AuthorRole, rv.Rating, rv.Body, closesAt,
+	), rv)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return review.ErrAlreadyReviewed
+		}
+		return fmt.Errorf("reviews pgrepo: insert: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("reviews pgrepo: commit: %w", err)
+	}
+	return nil
+}
+
+// ForBooking — свой отзыв виден всегда, встречный — только из visible_reviews.
+func (r *Repo) ForBooking(ctx context.Context, bookingID, userID int64) (review.BookingReviews, error) {
+	var out review.BookingReviews
+	closesAt, err := r.windowClosesAt(ctx, r.pool, bookingID)
+	if err != nil {
+		return out, err
+	}
+	out.WindowClosesAt = closesAt
+
+	const mineQ = `SELECT ` + SelectReviewCols + ` FROM reviews WHERE booking_id = $1 AND author_id = $2`
+	var mine review.Review
+	if err := ScanReview(r.pool.QueryRow(ctx, mineQ, bookingID, userID), &mine); err == nil {
+		out.Mine = &mine
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return out, fmt.Errorf("reviews pgrepo: get mine: %w", err)
+	}
+
+	const otherQ = `SELECT ` + SelectReviewCols + ` FROM visible_reviews WHERE booking_id = $1 AND author_id <> $2`
+	var other review.Review
+	if err := ScanReview(r.pool.QueryRow(ctx, otherQ, bookingID, userID), &other); err == nil {
+		out.Counterpart = &other
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return out, fmt.Errorf("reviews pgrepo: get counterpart: %w", err)
+	}
+
+	out.CanReview = out.Mine == nil && time.Now().UTC().Before(closesAt)
+	return out, nil
+}
+
+// ListByItem — отзывы арендаторов о вещи.
+func (r *Repo) ListByItem(ctx context.Context, itemID int64, limit, offset int) ([]review.Review, error) {
+	const q = `
+	SELECT ` + SelectReviewCols + `
+	FROM visible_reviews
+	WHERE item_id = $1 AND author_role = 'requester'
+	ORDER BY id DESC
+	LIMIT $2 OFFSET $3
+	`
+	return r.list(ctx, q, itemID, limit, offset)
+}
+
+// ListByUser — отзывы о пользователе в любой роли.
+func (r *Repo) ListByUser(ctx context.Context, userID int64, limit, offset int) ([]review.Review, error) {
+	const q = `
+	SELECT ` + SelectReviewCols + `
+	FROM visible_reviews
+	WHERE target_user_id = $1
+	ORDER BY id DESC
+	LIMIT $2 OFFSET $3
+	`
+	return r.list(ctx, q, userID, limit, offset)
+}
+
+func (r *Repo) list(ctx context.Context, q string, id int64, limit, offset int) ([]review.Review, error) {
+	rows, err := r.pool.Query(ctx, q, id, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("reviews pgrepo: list: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]review.Review, 0, limit)
+	for rows.Next() {
+		var rv review.Review
+		if err := ScanReview(rows, &rv); err != nil {
+			return nil, fmt.Errorf("reviews pgrepo: list scan: %w", err)
+		}
+		out = append(out, rv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reviews pgrepo: list rows: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,13 @@
+package review
+
+import "context"
+
+type Repo interface {
+	// Create — окно считается от завершения брони; роль и цель отзыва заполняет вызывающий.
+	Create(ctx context.Context, rv *Review) error
+	ForBooking(ctx context.Context, bookingID, userID int64) (BookingReviews, error)
+
+	// только видимые отзывы
+	ListByItem(ctx context.Context, itemID int64, limit, offset int) ([]Review, error)
+	ListByUser(ctx context.Context, userID int64, limit, offset int) ([]Review, error)
+}
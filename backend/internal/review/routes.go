@@ -0,0 +1,14 @@
+package review
+
+import "net/http"
+
+type Middleware func(http.Handler) http.Handler
+
+func RegisterRoutes(mux *http.ServeMux, repo Repo, bookings BookingGetter, authMw Middleware) {
+	h := NewHandler(repo, bookings)
+
+	mux.Handle("POST /api/bookings/{id}/reviews", authMw(http.HandlerFunc(h.Create)))
+	mux.Handle("GET /api/bookings/{id}/reviews", authMw(http.HandlerFunc(h.ForBooking)))
+	mux.HandleFunc("GET /api/items/{id}/reviews", h.ListByItem)
+	mux.HandleFunc("GET /api/users/{id}/reviews", h.ListByUser)
+}
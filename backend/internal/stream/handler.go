@@ -0,0 +1,194 @@
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/auth"
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/httpx"
+)
+
+const (
+	heartbeatInterval = 25 * time.Second
+	retryMillis       = 3000 // подсказка клиенту, через сколько переподключаться
+	batchSize         = 200
+	recheckDelay      = 2 * time.Second
+)
+
+var errInvalidCursor = errors.New("invalid Last-Event-ID")
+
+type Handler struct {
+	hub    *Hub
+	events EventSource
+}
+
+func NewHandler(hub *Hub, events EventSource) *Handler {
+	return &Handler{hub: hub, events: events}
+}
+
+// formatCursor — значение id: в SSE, "<txid>-<id>".
+func formatCursor(c booking.EventCursor) string {
+	return strconv.FormatInt(c.TxID, 10) + "-" + strconv.FormatInt(c.ID, 10)
+}
+
+// parseCursor — "<txid>-<id>"; legacy = true для старого формата (только id события).
+func parseCursor(v string) (c booking.EventCursor, legacy bool, err error) {
+	tx, id, ok := strings.Cut(v, "-")
+	if !ok {
+		c.ID, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || c.ID < 0 {
+			return booking.EventCursor{}, false, errInvalidCursor
+		}
+		return c, true, nil
+	}
+	c.TxID, err = strconv.ParseInt(tx, 10, 64)
+	if err != nil || c.TxID < 0 {
+		return booking.EventCursor{}, false, errInvalidCursor
+	}
+	c.ID, err = strconv.ParseInt(id, 10, 64)
+	if err != nil || c.ID < 0 {
+		return booking.EventCursor{}, false, errInvalidCursor
+	}
+	return c, false, nil
+}
+
+// startCursor — откуда дочитывать: Last-Event-ID (браузер шлёт сам при переподключении)
+// или ?last_event_id=; без них — только то, что произойдёт дальше.
+func (h *Handler) startCursor(r *http.Request) (booking.EventCursor, error) {
+	v := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if v == "" {
+		v = strings.TrimSpace(r.URL.Query().Get("last_event_id"))
+	}
+	if v == "" {
+		return h.events.CurrentEventCursor(r.Context())
+	}
+	c, legacy, err := parseCursor(v)
+	if err != nil || !legacy {
+		return c, err
+	}
+	c, err = h.events.EventCursorByID(r.Context(), c.ID)
+	if errors.Is(err, booking.ErrNotFound) {
+		return h.events.CurrentEventCursor(r.Context())
+	}
+	return c, err
+}
+
+// GET /api/my/stream — SSE с событиями броней, где пользователь владелец или арендатор.
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	cursor, err := h.startCursor(r)
+	if err != nil {
+		if errors.Is(err, errInvalidCursor) {
+			httpx.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Println("stream start cursor error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	sub := h.hub.Subscribe(userID)
+	if sub == nil {
+		httpx.WriteError(w, http.StatusServiceUnavailable, "server is shutting down")
+		return
+	}
+	defer h.hub.Unsubscribe(sub)
+
+	rc := http.NewResponseController(w)
+	// стрим живёт дольше любого WriteTimeout сервера
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", retryMillis)
+	if err := rc.Flush(); err != nil {
+		log.Println("stream flush unsupported:", err)
+		return
+	}
+
+	// всё, что успело случиться до подписки (или после Last-Event-ID)
+	if cursor, err = h.sendSince(w, r, userID, cursor); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	// событие, о котором пришло уведомление, может быть ещё не выдано: его придерживает
+	// более старая незавершённая транзакция (xmin). Дочитываем чуть позже и на каждом heartbeat.
+	recheck := time.NewTimer(recheckDelay)
+	recheck.Stop()
+	defer recheck.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case _, open := <-sub.Wake:
+			if !open {
+				return
+			}
+			if cursor, err = h.sendSince(w, r, userID, cursor); err != nil {
+				return
+			}
+			recheck.Reset(recheckDelay)
+		case <-recheck.C:
+			if cursor, err = h.sendSince(w, r, userID, cursor); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if cursor, err = h.sendSince(w, r, userID, cursor); err != nil {
+				return
+			}
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// sendSince — дописывает в стрим события после курсора; возвращает новый курсор.
+// Событие ещё не завершённой транзакции не выдаётся, пока она не закоммитится (см. ListUserEventsSince),
+// поэтому курсор не перепрыгивает через события, которые станут видны позже.
+func (h *Handler) sendSince(w http.ResponseWriter, r *http.Request, userID int64, cursor booking.EventCursor) (booking.EventCursor, error) {
+	for {
+		events, err := h.events.ListUserEventsSince(r.Context(), userID, cursor, batchSize)
+		if err != nil {
+			log.Println("stream list events error:", err)
+			return cursor, err
+		}
+		for _, e := range events {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return cursor, err
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: booking_event\ndata: %s\n\n", formatCursor(e.Cursor()), data); err != nil {
+				return cursor, err
+			}
+			cursor = e.Cursor()
+		}
+		if len(events) < batchSize {
+			return cursor, nil
+		}
+	}
+}
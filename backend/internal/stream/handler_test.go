@@ -0,0 +1,43 @@
+package stream
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+)
+
+func TestParseCursor(t *testing.T) {
+	cases := []struct {
+		in     string
+		want   booking.EventCursor
+		legacy bool
+		err    bool
+	}{
+		{in: "812-45", want: booking.EventCursor{TxID: 812, ID: 45}},
+		{in: "0-0", want: booking.EventCursor{}},
+		{in: "45", want: booking.EventCursor{ID: 45}, legacy: true},
+		{in: "-45", err: true},
+		{in: "812-", err: true},
+		{in: "a-1", err: true},
+		{in: "1-2-3", err: true},
+		{in: "x", err: true},
+	}
+	for _, c := range cases {
+		got, legacy, err := parseCursor(c.in)
+		if c.err {
+			if !errors.Is(err, errInvalidCursor) {
+				t.Errorf("parseCursor(%q): err = %v, want errInvalidCursor", c.in, err)
+			}
+			continue
+		}
+		if err != nil || got != c.want || legacy != c.legacy {
+			t.Errorf("parseCursor(%q) = %+v, %v, %v; want %+v, %v", c.in, got, legacy, err, c.want, c.legacy)
+		}
+	}
+
+	c := booking.EventCursor{TxID: 99, ID: 7}
+	if got, _, err := parseCursor(formatCursor(c)); err != nil || got != c {
+		t.Errorf("round trip %+v -> %q -> %+v (%v)", c, formatCursor(c), got, err)
+	}
+}
@@ -0,0 +1,99 @@
+package stream
+
+import "sync"
+
+// Notification — payload pg_notify из booking.EventsChannel.
+type Notification struct {
+	EventID     int64 `json:"id"`
+	BookingID   int64 `json:"booking_id"`
+	OwnerID     int64 `json:"owner_id"`
+	RequesterID int64 `json:"requester_id"`
+}
+
+// Subscriber — одно SSE-подключение. Wake — сигнал «есть что-то новое»: сигналы схлопываются,
+// а сами события подключение дочитывает из БД по своему последнему id.
+type Subscriber struct {
+	UserID int64
+	Wake   chan struct{}
+}
+
+// Hub — раздаёт уведомления подключениям участников брони.
+type Hub struct {
+	mu     sync.Mutex
+	subs   map[int64]map[*Subscriber]struct{}
+	closed bool
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[int64]map[*Subscriber]struct{})}
+}
+
+// Subscribe — nil, если хаб уже закрыт (сервер останавливается).
+func (h *Hub) Subscribe(userID int64) *Subscriber {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil
+	}
+	s := &Subscriber{UserID: userID, Wake: make(chan struct{}, 1)}
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[*Subscriber]struct{})
+	}
+	h.subs[userID][s] = struct{}{}
+	return s
+}
+
+func (h *Hub) Unsubscribe(s *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	set := h.subs[s.UserID]
+	if _, ok := set[s]; !ok {
+		return
+	}
+	delete(set, s)
+	if len(set) == 0 {
+		delete(h.subs, s.UserID)
+	}
+	close(s.Wake)
+}
+
+// Publish — будит подключения владельца и арендатора брони.
+func (h *Hub) Publish(n Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.wakeUser(n.OwnerID)
+	if n.RequesterID != n.OwnerID {
+		h.wakeUser(n.RequesterID)
+	}
+}
+
+// WakeAll — после переподключения LISTEN уведомления могли потеряться, пусть все дочитают из БД.
+func (h *Hub) WakeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for userID := range h.subs {
+		h.wakeUser(userID)
+	}
+}
+
+// Close — закрывает все подключения (graceful shutdown не ждёт бесконечных стримов).
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for userID, set := range h.subs {
+		for s := range set {
+			close(s.Wake)
+		}
+		delete(h.subs, userID)
+	}
+}
+
+func (h *Hub) wakeUser(userID int64) {
+	for s := range h.subs[userID] {
+		select {
+		case s.Wake <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package pgrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+	"github.com/SHILOP0P/Yardly/backend/internal/stream"
+)
+
+// Listener — держит отдельное соединение с LISTEN booking_events и пересылает уведомления в Hub.
+type Listener struct {
+	pool  *pgxpool.Pool
+	hub   *stream.Hub
+	retry time.Duration
+}
+
+func NewListener(pool *pgxpool.Pool, hub *stream.Hub) *Listener {
+	return &Listener{pool: pool, hub: hub, retry: 5 * time.Second}
+}
+
+// Run — до отмены ctx; при обрыве соединения переподключается.
+func (l *Listener) Run(ctx context.Context) {
+	for {
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Println("stream listener error:", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(l.retry):
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("stream pgrepo: acquire: %w", err)
+	}
+	defer func() {
+		// соединение возвращается в пул — снимаем подписку, если оно ещё живо
+		uctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, _ = conn.Exec(uctx, "UNLISTEN *")
+		cancel()
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{booking.EventsChannel}.Sanitize()); err != nil {
+		return fmt.Errorf("stream pgrepo: listen: %w", err)
+	}
+	// пока не слушали, уведомления могли пройти мимо
+	l.hub.WakeAll()
+
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("stream pgrepo: wait: %w", err)
+		}
+		var msg stream.Notification
+		if err := json.Unmarshal([]byte(n.Payload), &msg); err != nil {
+			log.Println("stream listener bad payload:", err)
+			continue
+		}
+		l.hub.Publish(msg)
+	}
+}
@@ -0,0 +1,15 @@
+package stream
+
+import (
+	"context"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/booking"
+)
+
+// EventSource — откуда стрим дочитывает события (реализует booking pgrepo).
+type EventSource interface {
+	ListUserEventsSince(ctx context.Context, userID int64, after booking.EventCursor, limit int) ([]booking.Event, error)
+	CurrentEventCursor(ctx context.Context) (booking.EventCursor, error)
+	// EventCursorByID — booking.ErrNotFound, если события нет.
+	EventCursorByID(ctx context.Context, eventID int64) (booking.EventCursor, error)
+}
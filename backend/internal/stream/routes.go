@@ -0,0 +1,11 @@
+package stream
+
+import "net/http"
+
+type Middleware func(http.Handler) http.Handler
+
+func RegisterRoutes(mux *http.ServeMux, hub *Hub, events EventSource, authMw Middleware) {
+	h := NewHandler(hub, events)
+
+	mux.Handle("GET /api/my/stream", authMw(http.HandlerFunc(h.Stream)))
+}
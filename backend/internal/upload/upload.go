@@ -0,0 +1,125 @@
+package upload
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MaxImageSize — предел multipart-формы с картинкой.
+const MaxImageSize = 10 << 20 // 10MB - битовый сдвиг = 10*2^20
+
+// SaveImage — сохраняет PNG/JPEG из multipart-поля "file" в UPLOADS_DIR/<subdir>
+// и возвращает публичный путь /uploads/<subdir>/<file>.
+func SaveImage(r *http.Request, subdir string) (string, error) {
+	filename, err := saveImage(r, filepath.Join(publicRoot(), subdir))
+	if err != nil {
+		return "", err
+	}
+	return "/uploads/" + subdir + "/" + filename, nil
+}
+
+// SavePrivateImage — как SaveImage, но в PRIVATE_UPLOADS_DIR/<subdir>, который не раздаётся
+// по /uploads: такие файлы отдают свои обработчики с проверкой доступа. Возвращает имя файла.
+func SavePrivateImage(r *http.Request, subdir string) (string, error) {
+	return saveImage(r, filepath.Join(privateRoot(), subdir))
+}
+
+// OpenPrivate — файл из PRIVATE_UPLOADS_DIR/<subdir>; name — только имя, без путей.
+func OpenPrivate(subdir, name string) (*os.File, error) {
+	if !validName(name) {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(filepath.Join(privateRoot(), subdir, name))
+}
+
+// RemovePrivate — удалить файл, сохранённый SavePrivateImage (например, если запись в БД не прошла).
+func RemovePrivate(subdir, name string) error {
+	if !validName(name) {
+		return os.ErrNotExist
+	}
+	return os.Remove(filepath.Join(privateRoot(), subdir, name))
+}
+
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && filepath.Base(name) == name && !strings.ContainsAny(name, `/\`)
+}
+
+func publicRoot() string {
+	root := os.Getenv("UPLOADS_DIR")
+	if strings.TrimSpace(root) == "" {
+		root = "uploads"
+	}
+	return root
+}
+
+func privateRoot() string {
+	root := os.Getenv("PRIVATE_UPLOADS_DIR")
+	if strings.TrimSpace(root) == "" {
+		root = "uploads_private"
+	}
+	return root
+}
+
+// saveImage — проверяет и пишет картинку в dir; возвращает имя файла.
+func saveImage(r *http.Request, dir string) (string, error) {
+	if err := r.ParseMultipartForm(MaxImageSize); err != nil {
+		return "", errors.New("invalid multipart form")
+	}
+
+	file, hdr, err := r.FormFile("file")
+	if err != nil {
+		return "", errors.New("file is required")
+	}
+	defer file.Close()
+
+	ext := strings.ToLower(filepath.Ext(hdr.Filename))
+	if ext != ".png" && ext != ".jpg" && ext != ".jpeg" {
+		return "", errors.New("only .png, .jpg, .jpeg are allowed")
+	}
+
+	buf := make([]byte, 512)
+	n, err := file.Read(buf)
+	if err != nil && err != io.EOF {
+		return "", errors.New("failed to read file")
+	}
+	ct := http.DetectContentType(buf[:n])
+	if ct != "image/png" && ct != "image/jpeg" {
+		return "", errors.New("only PNG or JPEG content is allowed")
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", errors.New("failed to reset file reader")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", errors.New("failed to prepare upload directory")
+	}
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", errors.New("failed to generate filename")
+	}
+	filename := fmt.Sprintf("%d_%s%s", time.Now().UnixNano(), hex.EncodeToString(suffix), ext)
+	dstPath := filepath.Join(dir, filename)
+
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return "", errors.New("failed to save file")
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, file); err != nil {
+		dst.Close()
+		os.Remove(dstPath)
+		return "", errors.New("failed to write file")
+	}
+
+	return filename, nil
+}
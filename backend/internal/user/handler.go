@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/SHILOP0P/Yardly/backend/internal/auth"
@@ -142,7 +143,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request){
 		return
 	}
 
-	tok, err := h.jwt.Mint(u.ID, "", true)
+	tok, err := h.jwt.Mint(u.ID, auth.Role(u.Role), u.TokenVersion)
 	if err != nil {
 		httpx.WriteError(w, http.StatusInternalServerError, "could not mint token")
 		return
@@ -180,3 +181,23 @@ func (h *Handler) Me(w http.ResponseWriter, r *http.Request){
 		LastName:  p.LastName,
 	})
 }
+
+// GET /api/users/{id}
+func (h *Handler) PublicProfile(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	p, err := h.repo.GetPublicProfile(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, p)
+}
@@ -24,6 +24,27 @@ type User struct {
 	BanReason *string    `json:"ban_reason,omitempty"`
 }
 
+// PublicProfile — то, что видно другим пользователям (без email и служебных полей).
+type PublicProfile struct {
+	ID        int64      `json:"id"`
+	FirstName string     `json:"first_name"`
+	LastName  *string    `json:"last_name,omitempty"`
+	AvatarURL *string    `json:"avatar_url,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// сколько раз пользователь как владелец отменял уже одобренные брони
+	OwnerCancellations int64 `json:"owner_cancellations"`
+
+	// по раскрытым отзывам о пользователе в любой роли
+	Rating Rating `json:"rating"`
+}
+
+// Rating — средняя оценка и число отзывов.
+type Rating struct {
+	Average float64 `json:"average"`
+	Count   int     `json:"count"`
+}
+
 type Profile struct {
 	UserID    int64      `json:"user_id"`
 	FirstName string     `json:"first_name"`
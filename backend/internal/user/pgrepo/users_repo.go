@@ -116,6 +116,36 @@ func (r *Repo) GetByID(ctx context.Context, id int64)(user.User, user.Profile, e
 	return u, p, nil
 }
 
+func (r *Repo) GetPublicProfile(ctx context.Context, id int64) (user.PublicProfile, error) {
+	const q = `
+	SELECT
+		u.id, p.first_name, p.last_name, p.avatar_url, u.created_at,
+		(SELECT count(*) FROM bookings b WHERE b.owner_id = u.id AND b.cancelled_by = 'owner'),
+		rt.avg, rt.cnt
+	FROM users u
+	CROSS JOIN LATERAL (
+		SELECT COALESCE(avg(v.rating), 0)::float8 AS avg, count(*)::int AS cnt
+		FROM visible_reviews v
+		WHERE v.target_user_id = u.id
+	) rt
+	JOIN user_profiles p ON p.user_id = u.id
+	WHERE u.id = $1
+	`
+	var pp user.PublicProfile
+	err := r.pool.QueryRow(ctx, q, id).Scan(
+		&pp.ID, &pp.FirstName, &pp.LastName, &pp.AvatarURL, &pp.CreatedAt,
+		&pp.OwnerCancellations,
+		&pp.Rating.Average, &pp.Rating.Count,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user.PublicProfile{}, user.ErrNotFound
+		}
+		return user.PublicProfile{}, fmt.Errorf("users pgrepo: get public profile: %w", err)
+	}
+	return pp, nil
+}
+
 func (r *Repo) Authenticate(ctx context.Context, email, password string) (auth.AuthUser, error) {
 	u, err := r.GetByEmail(ctx, email)
 	if err != nil {
@@ -14,4 +14,5 @@ func RegisterRoutes(mux *http.ServeMux, authMw Middleware, userRepo Repo, jwtSvc
 
 	mux.HandleFunc("POST /api/auth/register", h.Register)
 	mux.Handle("GET /api/users/me", authMw(http.HandlerFunc(h.Me)))
+	mux.HandleFunc("GET /api/users/{id}", h.PublicProfile)
 }
\ No newline at end of file
@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/outbox"
+)
+
+const ConsumerName = "webhooks"
+
+// OutboxConsumer — превращает события outbox в доставки на вебхуки участников.
+type OutboxConsumer struct {
+	repo Repo
+}
+
+func NewOutboxConsumer(repo Repo) *OutboxConsumer {
+	return &OutboxConsumer{repo: repo}
+}
+
+func (c *OutboxConsumer) Name() string { return ConsumerName }
+
+var itemEvents = map[string]EventType{
+	outbox.EventItemCreated:   EventItemCreated,
+	outbox.EventItemUpdated:   EventItemUpdated,
+	outbox.EventItemBlocked:   EventItemBlocked,
+	outbox.EventItemUnblocked: EventItemUnblocked,
+	outbox.EventItemDeleted:   EventItemDeleted,
+}
+
+func (c *OutboxConsumer) Handle(ctx context.Context, ev outbox.Event) error {
+	if ev.Type == outbox.EventBookingEvent {
+		var p outbox.BookingEventPayload
+		if err := json.Unmarshal(ev.Payload, &p); err != nil {
+			return fmt.Errorf("decode booking event: %w", err)
+		}
+		// события без смены статуса (коды передачи, перенос дат и т.п.) не рассылаются
+		if p.ToStatus == nil {
+			return nil
+		}
+		return c.repo.EnqueueEvent(ctx, ev.ID, EventBookingStatusChanged, []int64{p.OwnerID, p.RequesterID}, ev.Payload)
+	}
+
+	if ev.Type == outbox.EventWaitlistOffered {
+		var p outbox.WaitlistPayload
+		if err := json.Unmarshal(ev.Payload, &p); err != nil {
+			return fmt.Errorf("decode waitlist event: %w", err)
+		}
+		return c.repo.EnqueueEvent(ctx, ev.ID, EventWaitlistOffered, []int64{p.UserID}, ev.Payload)
+	}
+
+	typ, ok := itemEvents[ev.Type]
+	if !ok {
+		return nil
+	}
+	var p outbox.ItemPayload
+	if err := json.Unmarshal(ev.Payload, &p); err != nil {
+		return fmt.Errorf("decode item event: %w", err)
+	}
+	return c.repo.EnqueueEvent(ctx, ev.ID, typ, []int64{p.OwnerID}, ev.Payload)
+}
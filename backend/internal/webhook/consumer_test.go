@@ -0,0 +1,33 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/outbox"
+)
+
+type enqueueRepo struct {
+	Repo
+
+	ev      EventType
+	userIDs []int64
+}
+
+func (f *enqueueRepo) EnqueueEvent(ctx context.Context, outboxEventID int64, ev EventType, userIDs []int64, payload json.RawMessage) error {
+	f.ev, f.userIDs = ev, userIDs
+	return nil
+}
+
+func TestConsumerWaitlistOfferGoesToWaitingUser(t *testing.T) {
+	payload, _ := json.Marshal(outbox.WaitlistPayload{WaitlistID: 3, ItemID: 5, UserID: 42, Status: "notified"})
+	repo := &enqueueRepo{}
+	c := NewOutboxConsumer(repo)
+	if err := c.Handle(context.Background(), outbox.Event{ID: 1, Type: outbox.EventWaitlistOffered, Payload: payload}); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if repo.ev != EventWaitlistOffered || len(repo.userIDs) != 1 || repo.userIDs[0] != 42 {
+		t.Fatalf("enqueued %q to %v, want %q to [42]", repo.ev, repo.userIDs, EventWaitlistOffered)
+	}
+}
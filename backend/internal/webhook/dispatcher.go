@@ -0,0 +1,136 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/netguard"
+)
+
+const (
+	MaxAttempts  = 10
+	baseBackoff  = 30 * time.Second
+	maxBackoff   = 6 * time.Hour
+	claimLease   = 2 * time.Minute // сколько доставка «занята» воркером
+	claimBatch   = 10              // пачка × таймаут клиента должны укладываться в lease
+	maxErrLength = 500
+)
+
+// Backoff — пауза после attempt-й неудачной попытки: 30s, 1m, 2m, ... не больше 6h.
+func Backoff(attempt int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
+
+// Envelope — тело POST-запроса к вебхуку.
+type Envelope struct {
+	ID        int64           `json:"id"` // id доставки — для дедупликации на стороне получателя
+	Type      EventType       `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Dispatcher — фоновая доставка: берёт созревшие записи из журнала и шлёт их с подписью.
+type Dispatcher struct {
+	repo   Repo
+	client *http.Client
+}
+
+// NewDispatcher — client = nil: клиент netguard без исключений (во внутреннюю сеть не ходит), таймаут 10s.
+func NewDispatcher(repo Repo, client *http.Client) *Dispatcher {
+	if client == nil {
+		client = netguard.New(nil).Client(10 * time.Second)
+	}
+	return &Dispatcher{repo: repo, client: client}
+}
+
+// Deliver — одна попытка: 2xx — успех, иначе ошибка (код ответа, если он был).
+func (d *Dispatcher) Deliver(ctx context.Context, pd PendingDelivery, now time.Time) (int, error) {
+	body, err := json.Marshal(Envelope{
+		ID:        pd.ID,
+		Type:      pd.EventType,
+		CreatedAt: pd.CreatedAt,
+		Data:      pd.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pd.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Yardly-Webhooks/1")
+	req.Header.Set(HeaderEvent, string(pd.EventType))
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(pd.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(pd.Secret, ts, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// RunOnce — обрабатывает одну пачку созревших доставок; возвращает число успешных.
+func (d *Dispatcher) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	due, err := d.repo.ClaimDue(ctx, now, now.Add(claimLease), claimBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, pd := range due {
+		code, err := d.Deliver(ctx, pd, time.Now().UTC())
+		if err == nil {
+			if err := d.repo.MarkDelivered(ctx, pd.ID, code, time.Now().UTC()); err != nil {
+				return delivered, err
+			}
+			delivered++
+			continue
+		}
+
+		var statusCode *int
+		if code != 0 {
+			statusCode = &code
+		}
+		msg := err.Error()
+		if len(msg) > maxErrLength {
+			msg = msg[:maxErrLength]
+		}
+
+		var next *time.Time
+		if attempt := pd.Attempts + 1; attempt < MaxAttempts {
+			t := time.Now().UTC().Add(Backoff(attempt))
+			next = &t
+		} else {
+			log.Printf("webhook delivery %d failed after %d attempts: %s", pd.ID, attempt, msg)
+		}
+		if err := d.repo.MarkAttemptFailed(ctx, pd.ID, statusCode, msg, next); err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
@@ -0,0 +1,190 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/netguard"
+)
+
+// fakeRepo — только то, что трогает Dispatcher.RunOnce.
+type fakeRepo struct {
+	Repo
+
+	mu        sync.Mutex
+	due       []PendingDelivery
+	delivered map[int64]int
+	failed    map[int64]*time.Time
+}
+
+func (f *fakeRepo) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]PendingDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := f.due
+	f.due = nil
+	return out, nil
+}
+
+func (f *fakeRepo) MarkDelivered(ctx context.Context, id int64, statusCode int, now time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delivered[id] = statusCode
+	return nil
+}
+
+func (f *fakeRepo) MarkAttemptFailed(ctx context.Context, id int64, statusCode *int, msg string, next *time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failed[id] = next
+	return nil
+}
+
+// loopbackClient — httptest слушает 127.0.0.1, поэтому loopback разрешаем явно, как через OUTBOUND_ALLOW_CIDRS.
+func loopbackClient(t *testing.T) *http.Client {
+	t.Helper()
+	allow, err := netguard.ParseAllowlist("127.0.0.0/8, ::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return netguard.New(allow).Client(5 * time.Second)
+}
+
+func TestRunOnceDeliversSignedRequest(t *testing.T) {
+	const secret = "whsec_test"
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{header: r.Header.Clone(), body: body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	repo := &fakeRepo{
+		due: []PendingDelivery{{
+			Delivery: Delivery{
+				ID:        42,
+				EventType: EventBookingStatusChanged,
+				Payload:   json.RawMessage(`{"booking_id":7}`),
+				CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+			URL:    srv.URL + "/hook",
+			Secret: secret,
+		}},
+		delivered: map[int64]int{},
+		failed:    map[int64]*time.Time{},
+	}
+	d := NewDispatcher(repo, loopbackClient(t))
+
+	n, err := d.RunOnce(context.Background(), time.Now().UTC())
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if n != 1 || repo.delivered[42] != http.StatusNoContent {
+		t.Fatalf("delivered n=%d marks=%v, want 1 delivery with 204", n, repo.delivered)
+	}
+
+	req := <-got
+	if ev := req.header.Get(HeaderEvent); ev != string(EventBookingStatusChanged) {
+		t.Errorf("%s = %q", HeaderEvent, ev)
+	}
+	if id := req.header.Get(HeaderDelivery); id != "42" {
+		t.Errorf("%s = %q, want 42", HeaderDelivery, id)
+	}
+	ts, err := strconv.ParseInt(req.header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("bad %s: %v", HeaderTimestamp, err)
+	}
+	sig := req.header.Get(HeaderSignature)
+	if !Verify(secret, ts, req.body, sig) {
+		t.Errorf("signature %q does not verify", sig)
+	}
+	if Verify("whsec_other", ts, req.body, sig) || Verify(secret, ts+1, req.body, sig) {
+		t.Error("signature verifies with wrong secret or timestamp")
+	}
+
+	var env Envelope
+	if err := json.Unmarshal(req.body, &env); err != nil {
+		t.Fatalf("body: %v", err)
+	}
+	if env.ID != 42 || env.Type != EventBookingStatusChanged || string(env.Data) != `{"booking_id":7}` {
+		t.Errorf("envelope = %+v", env)
+	}
+}
+
+func TestRunOnceSchedulesRetryOnError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	repo := &fakeRepo{
+		due: []PendingDelivery{
+			{Delivery: Delivery{ID: 1, EventType: EventPing, Attempts: 0}, URL: srv.URL, Secret: "s"},
+			{Delivery: Delivery{ID: 2, EventType: EventPing, Attempts: MaxAttempts - 1}, URL: srv.URL, Secret: "s"},
+		},
+		delivered: map[int64]int{},
+		failed:    map[int64]*time.Time{},
+	}
+	d := NewDispatcher(repo, loopbackClient(t))
+
+	if _, err := d.RunOnce(context.Background(), time.Now().UTC()); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if next, ok := repo.failed[1]; !ok || next == nil {
+		t.Errorf("delivery 1: want retry scheduled, got %v (%v)", next, ok)
+	}
+	if next, ok := repo.failed[2]; !ok || next != nil {
+		t.Errorf("delivery 2: want final failure (next=nil), got %v (%v)", next, ok)
+	}
+}
+
+func TestDeliverBlocksPrivateAddressByDefault(t *testing.T) {
+	hit := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(nil, nil) // клиент без исключений
+	_, err := d.Deliver(context.Background(), PendingDelivery{
+		Delivery: Delivery{ID: 1, EventType: EventPing},
+		URL:      srv.URL,
+		Secret:   "s",
+	}, time.Now())
+	if !errors.Is(err, netguard.ErrBlocked) {
+		t.Fatalf("Deliver to %s: err = %v, want ErrBlocked", srv.URL, err)
+	}
+	if hit {
+		t.Fatal("request reached loopback server")
+	}
+}
+
+func TestDeliverBlocksRedirectToPrivateAddress(t *testing.T) {
+	// сам получатель разрешён, но редиректит на метаданные облака — соединение туда отклоняется
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data", http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(nil, loopbackClient(t))
+	_, err := d.Deliver(context.Background(), PendingDelivery{
+		Delivery: Delivery{ID: 1, EventType: EventPing},
+		URL:      srv.URL,
+		Secret:   "s",
+	}, time.Now())
+	if !errors.Is(err, netguard.ErrBlocked) {
+		t.Fatalf("redirect to metadata: err = %v, want ErrBlocked", err)
+	}
+}
@@ -0,0 +1,10 @@
+package webhook
+
+import "errors"
+
+var (
+	ErrNotFound   = errors.New("webhook not found")
+	ErrInvalidURL = errors.New("url must be http or https")
+	ErrBlockedURL = errors.New("url points to a private or reserved address")
+	ErrTooMany    = errors.New("too many webhooks")
+)
@@ -0,0 +1,178 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/auth"
+	"github.com/SHILOP0P/Yardly/backend/internal/httpx"
+	"github.com/SHILOP0P/Yardly/backend/internal/netguard"
+)
+
+type Handler struct {
+	repo  Repo
+	guard *netguard.Guard
+}
+
+func NewHandler(repo Repo, guard *netguard.Guard) *Handler {
+	return &Handler{repo: repo, guard: guard}
+}
+
+// ValidateURL — абсолютный http(s) адрес, хост которого не резолвится во внутреннюю сеть.
+// При доставке адрес проверяется ещё раз при соединении (см. netguard).
+func ValidateURL(ctx context.Context, guard *netguard.Guard, raw string) error {
+	err := guard.CheckURL(ctx, raw)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, netguard.ErrBlocked):
+		return ErrBlockedURL
+	default:
+		return ErrInvalidURL
+	}
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		httpx.WriteError(w, http.StatusNotFound, "webhook not found")
+	case errors.Is(err, ErrInvalidURL), errors.Is(err, ErrBlockedURL):
+		httpx.WriteError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrTooMany):
+		httpx.WriteError(w, http.StatusConflict, err.Error())
+	default:
+		log.Println("webhook error:", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// ownedWebhook — {id} из пути, если вебхук принадлежит текущему пользователю.
+func (h *Handler) ownedWebhook(w http.ResponseWriter, r *http.Request) (Webhook, bool) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return Webhook{}, false
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid webhook id")
+		return Webhook{}, false
+	}
+	wh, err := h.repo.Get(r.Context(), userID, id)
+	if err != nil {
+		writeWebhookError(w, err)
+		return Webhook{}, false
+	}
+	return wh, true
+}
+
+type createRequest struct {
+	URL    string      `json:"url"`
+	Events []EventType `json:"events,omitempty"`
+}
+
+// POST /api/my/webhooks — секрет для проверки подписи отдаётся только в этом ответе.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req createRequest
+	if err := httpx.ReadJSON(r, &req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	req.URL = strings.TrimSpace(req.URL)
+	if err := ValidateURL(r.Context(), h.guard, req.URL); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	events := make([]EventType, 0, len(req.Events))
+	seen := make(map[EventType]bool, len(req.Events))
+	for _, e := range req.Events {
+		if !e.Valid() {
+			httpx.WriteError(w, http.StatusBadRequest, "unknown event: "+string(e))
+			return
+		}
+		if !seen[e] {
+			seen[e] = true
+			events = append(events, e)
+		}
+	}
+
+	wh := Webhook{UserID: userID, URL: req.URL, Events: events}
+	if err := h.repo.Create(r.Context(), &wh); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusCreated, wh)
+}
+
+// GET /api/my/webhooks
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	list, err := h.repo.List(r.Context(), userID)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, map[string]any{"items": list})
+}
+
+// DELETE /api/my/webhooks/{id} — журнал доставок удаляется вместе с вебхуком.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	wh, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	if err := h.repo.Delete(r.Context(), wh.UserID, wh.ID); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/my/webhooks/{id}/ping — тестовая доставка, уходит обычным воркером.
+func (h *Handler) Ping(w http.ResponseWriter, r *http.Request) {
+	wh, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	d, err := h.repo.EnqueuePing(r.Context(), wh.ID)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusAccepted, d)
+}
+
+// GET /api/my/webhooks/{id}/deliveries?limit&offset — журнал доставок, новые сверху.
+func (h *Handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	wh, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	limit := httpx.QueryInt(r, "limit", 20, 1, 100)
+	offset := httpx.QueryInt(r, "offset", 0, 0, 1_000_000)
+
+	list, err := h.repo.ListDeliveries(r.Context(), wh.ID, limit, offset)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	httpx.WriteJSON(w, http.StatusOK, map[string]any{
+		"items":  list,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventBookingStatusChanged EventType = "booking.status_changed"
+	EventItemCreated          EventType = "item.created"
+	EventItemUpdated          EventType = "item.updated"
+	EventItemBlocked          EventType = "item.blocked" // админ заблокировал вещь
+	EventItemUnblocked        EventType = "item.unblocked"
+	EventItemDeleted          EventType = "item.deleted"
+	EventWaitlistOffered      EventType = "waitlist.offered" // даты из листа ожидания придержаны за пользователем
+
+	// тестовая доставка по запросу владельца вебхука; на неё не подписываются
+	EventPing EventType = "ping"
+)
+
+// Valid — на это событие можно подписаться.
+func (e EventType) Valid() bool {
+	switch e {
+	case EventBookingStatusChanged, EventItemCreated, EventItemUpdated,
+		EventItemBlocked, EventItemUnblocked, EventItemDeleted, EventWaitlistOffered:
+		return true
+	default:
+		return false
+	}
+}
+
+// Webhook — адрес пользователя для доставки событий его броней и вещей.
+type Webhook struct {
+	ID        int64       `json:"id"`
+	UserID    int64       `json:"user_id"`
+	URL       string      `json:"url"`
+	Secret    string      `json:"secret,omitempty"` // только в ответе на создание
+	Events    []EventType `json:"events"`           // пусто — все события
+	Active    bool        `json:"active"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed" // попытки исчерпаны
+)
+
+type Delivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhook_id"`
+	EventType      EventType       `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode *int            `json:"last_status_code,omitempty"`
+	LastError      *string         `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// PendingDelivery — доставка, взятая воркером, вместе с адресом и ключом подписи.
+type PendingDelivery struct {
+	Delivery
+	URL    string
+	Secret string
+}
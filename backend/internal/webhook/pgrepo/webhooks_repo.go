@@ -0,0 +1,289 @@
+package pgrepo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/webhook"
+)
+
+const maxWebhooksPerUser = 10
+
+type Repo struct {
+	pool *pgxpool.Pool
+}
+
+func New(pool *pgxpool.Pool) *Repo {
+	return &Repo{pool: pool}
+}
+
+const selectWebhookCols = `id, user_id, url, events, active, created_at`
+
+func scanWebhook(rs interface{ Scan(...any) error }, w *webhook.Webhook) error {
+	var events []string
+	if err := rs.Scan(&w.ID, &w.UserID, &w.URL, &events, &w.Active, &w.CreatedAt); err != nil {
+		return err
+	}
+	w.Events = make([]webhook.EventType, 0, len(events))
+	for _, e := range events {
+		w.Events = append(w.Events, webhook.EventType(e))
+	}
+	return nil
+}
+
+const selectDeliveryCols = `
+  id, webhook_id, event_type, payload, status, attempts,
+  next_attempt_at, last_status_code, last_error, delivered_at, created_at
+`
+
+func scanDelivery(rs interface{ Scan(...any) error }, d *webhook.Delivery) error {
+	return rs.Scan(&d.ID, &d.WebhookID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt)
+}
+
+func newSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+func (r *Repo) Create(ctx context.Context, w *webhook.Webhook) error {
+	secret, err := newSecret()
+	if err != nil {
+		return fmt.Errorf("webhook pgrepo: secret: %w", err)
+	}
+	events := make([]string, 0, len(w.Events))
+	for _, e := range w.Events {
+		events = append(events, string(e))
+	}
+
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("webhook pgrepo: begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// лимит считаем под блокировкой пользователя, чтобы параллельные запросы его не обошли
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, w.UserID); err != nil {
+		return fmt.Errorf("webhook pgrepo: lock user: %w", err)
+	}
+	var n int
+	if err := tx.QueryRow(ctx, `SELECT count(*) FROM webhooks WHERE user_id = $1`, w.UserID).Scan(&n); err != nil {
+		return fmt.Errorf("webhook pgrepo: count: %w", err)
+	}
+	if n >= maxWebhooksPerUser {
+		return webhook.ErrTooMany
+	}
+
+	const q = `
+	INSERT INTO webhooks (user_id, url, secret, events)
+	VALUES ($1, $2, $3, $4)
+	RETURNING ` + selectWebhookCols
+	if err := scanWebhook(tx.QueryRow(ctx, q, w.UserID, w.URL, secret, events), w); err != nil {
+		return fmt.Errorf("webhook pgrepo: create: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("webhook pgrepo: commit: %w", err)
+	}
+	w.Secret = secret
+	return nil
+}
+
+func (r *Repo) List(ctx context.Context, userID int64) ([]webhook.Webhook, error) {
+	const q = `
+	SELECT ` + selectWebhookCols + `
+	FROM webhooks
+	WHERE user_id = $1
+	ORDER BY id
+	`
+	rows, err := r.pool.Query(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("webhook pgrepo: list: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]webhook.Webhook, 0)
+	for rows.Next() {
+		var w webhook.Webhook
+		if err := scanWebhook(rows, &w); err != nil {
+			return nil, fmt.Errorf("webhook pgrepo: list scan: %w", err)
+		}
+		out = append(out, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("webhook pgrepo: list rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *Repo) Get(ctx context.Context, userID, id int64) (webhook.Webhook, error) {
+	const q = `
+	SELECT ` + selectWebhookCols + `
+	FROM webhooks
+	WHERE id = $1 AND user_id = $2
+	`
+	var w webhook.Webhook
+	if err := scanWebhook(r.pool.QueryRow(ctx, q, id, userID), &w); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return webhook.Webhook{}, webhook.ErrNotFound
+		}
+		return webhook.Webhook{}, fmt.Errorf("webhook pgrepo: get: %w", err)
+	}
+	return w, nil
+}
+
+func (r *Repo) Delete(ctx context.Context, userID, id int64) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM webhooks WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("webhook pgrepo: delete: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return webhook.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repo) EnqueuePing(ctx context.Context, webhookID int64) (webhook.Delivery, error) {
+	const q = `
+	INSERT INTO webhook_deliveries (webhook_id, event_type, payload)
+	VALUES ($1, $2, json_build_object('webhook_id', $1::bigint, 'occurred_at', now()))
+	RETURNING ` + selectDeliveryCols
+	var d webhook.Delivery
+	if err := scanDelivery(r.pool.QueryRow(ctx, q, webhookID, webhook.EventPing), &d); err != nil {
+		return webhook.Delivery{}, fmt.Errorf("webhook pgrepo: enqueue ping: %w", err)
+	}
+	return d, nil
+}
+
+func (r *Repo) ListDeliveries(ctx context.Context, webhookID int64, limit, offset int) ([]webhook.Delivery, error) {
+	const q = `
+	SELECT ` + selectDeliveryCols + `
+	FROM webhook_deliveries
+	WHERE webhook_id = $1
+	ORDER BY id DESC
+	LIMIT $2 OFFSET $3
+	`
+	rows, err := r.pool.Query(ctx, q, webhookID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("webhook pgrepo: list deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]webhook.Delivery, 0)
+	for rows.Next() {
+		var d webhook.Delivery
+		if err := scanDelivery(rows, &d); err != nil {
+			return nil, fmt.Errorf("webhook pgrepo: list deliveries scan: %w", err)
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("webhook pgrepo: list deliveries rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *Repo) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]webhook.PendingDelivery, error) {
+	// SKIP LOCKED — несколько воркеров не возьмут одну доставку; lease сдвигает next_attempt_at,
+	// так что упавший воркер не потеряет её, а только задержит
+	const q = `
+	WITH due AS (
+		SELECT d.id
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id AND w.active
+		WHERE d.status = 'pending'
+		  AND d.next_attempt_at <= $1
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT $3
+		FOR UPDATE OF d SKIP LOCKED
+	)
+	UPDATE webhook_deliveries d
+	SET next_attempt_at = $2
+	FROM due, webhooks w
+	WHERE d.id = due.id
+	  AND w.id = d.webhook_id
+	RETURNING d.id, d.webhook_id, d.event_type, d.payload, d.status, d.attempts,
+	          d.next_attempt_at, d.last_status_code, d.last_error, d.delivered_at, d.created_at,
+	          w.url, w.secret
+	`
+	rows, err := r.pool.Query(ctx, q, now, leaseUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("webhook pgrepo: claim due: %w", err)
+	}
+	defer rows.Close()
+
+	out := make([]webhook.PendingDelivery, 0)
+	for rows.Next() {
+		var pd webhook.PendingDelivery
+		d := &pd.Delivery
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt,
+			&pd.URL, &pd.Secret); err != nil {
+			return nil, fmt.Errorf("webhook pgrepo: claim due scan: %w", err)
+		}
+		out = append(out, pd)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("webhook pgrepo: claim due rows: %w", err)
+	}
+	return out, nil
+}
+
+func (r *Repo) MarkDelivered(ctx context.Context, id int64, statusCode int, now time.Time) error {
+	const q = `
+	UPDATE webhook_deliveries
+	SET status = 'delivered',
+	    attempts = attempts + 1,
+	    last_status_code = $2,
+	    last_error = NULL,
+	    delivered_at = $3
+	WHERE id = $1
+	`
+	if _, err := r.pool.Exec(ctx, q, id, statusCode, now); err != nil {
+		return fmt.Errorf("webhook pgrepo: mark delivered: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) MarkAttemptFailed(ctx context.Context, id int64, statusCode *int, msg string, next *time.Time) error {
+	const q = `
+	UPDATE webhook_deliveries
+	SET attempts = attempts + 1,
+	    last_status_code = $2,
+	    last_error = $3,
+	    status = CASE WHEN $4::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+	    next_attempt_at = COALESCE($4, next_attempt_at)
+	WHERE id = $1
+	`
+	if _, err := r.pool.Exec(ctx, q, id, statusCode, msg, next); err != nil {
+		return fmt.Errorf("webhook pgrepo: mark failed: %w", err)
+	}
+	return nil
+}
+
+func (r *Repo) EnqueueEvent(ctx context.Context, outboxEventID int64, ev webhook.EventType, userIDs []int64, payload json.RawMessage) error {
+	// повторная обработка того же события outbox не создаёт вторую доставку
+	const q = `
+	INSERT INTO webhook_deliveries (webhook_id, event_type, payload, outbox_event_id)
+	SELECT w.id, $2, $3, $4
+	FROM webhooks w
+	WHERE w.user_id = ANY($1)
+	  AND w.active
+	  AND (cardinality(w.events) = 0 OR $2 = ANY(w.events))
+	ON CONFLICT (webhook_id, outbox_event_id) DO NOTHING
+	`
+	if _, err := r.pool.Exec(ctx, q, userIDs, string(ev), []byte(payload), outboxEventID); err != nil {
+		return fmt.Errorf("webhook pgrepo: enqueue event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type Repo interface {
+	// Create — секрет генерируется здесь и возвращается в w.Secret.
+	Create(ctx context.Context, w *Webhook) error
+	List(ctx context.Context, userID int64) ([]Webhook, error)
+	Get(ctx context.Context, userID, id int64) (Webhook, error)
+	Delete(ctx context.Context, userID, id int64) error
+
+	EnqueuePing(ctx context.Context, webhookID int64) (Delivery, error)
+	// EnqueueEvent — доставка на активные вебхуки пользователей userIDs, подписанные на ev; идемпотентно по outboxEventID.
+	EnqueueEvent(ctx context.Context, outboxEventID int64, ev EventType, userIDs []int64, payload json.RawMessage) error
+	ListDeliveries(ctx context.Context, webhookID int64, limit, offset int) ([]Delivery, error)
+
+	// ClaimDue — берёт созревшие доставки и откладывает их до leaseUntil, чтобы другой воркер их не взял.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]PendingDelivery, error)
+	MarkDelivered(ctx context.Context, id int64, statusCode int, now time.Time) error
+	// MarkAttemptFailed — next = nil: попытки исчерпаны, доставка становится failed.
+	MarkAttemptFailed(ctx context.Context, id int64, statusCode *int, msg string, next *time.Time) error
+}
@@ -0,0 +1,19 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/SHILOP0P/Yardly/backend/internal/netguard"
+)
+
+type Middleware func(http.Handler) http.Handler
+
+func RegisterRoutes(mux *http.ServeMux, repo Repo, guard *netguard.Guard, authMw Middleware) {
+	h := NewHandler(repo, guard)
+
+	mux.Handle("GET /api/my/webhooks", authMw(http.HandlerFunc(h.List)))
+	mux.Handle("POST /api/my/webhooks", authMw(http.HandlerFunc(h.Create)))
+	mux.Handle("DELETE /api/my/webhooks/{id}", authMw(http.HandlerFunc(h.Delete)))
+	mux.Handle("POST /api/my/webhooks/{id}/ping", authMw(http.HandlerFunc(h.Ping)))
+	mux.Handle("GET /api/my/webhooks/{id}/deliveries", authMw(http.HandlerFunc(h.ListDeliveries)))
+}
@@ -0,0 +1,33 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+const (
+	HeaderSignature = "X-Yardly-Signature" // "sha256=<hex>"
+	HeaderTimestamp = "X-Yardly-Timestamp" // unix-секунды, входят в подпись
+	HeaderEvent     = "X-Yardly-Event"
+	HeaderDelivery  = "X-Yardly-Delivery" // id доставки, одинаковый во всех попытках
+)
+
+// Sign — HMAC-SHA256 от "<timestamp>.<body>" ключом вебхука.
+func Sign(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify — проверка подписи на стороне получателя (сравнение за постоянное время).
+func Verify(secret string, ts int64, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature))
+}